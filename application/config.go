@@ -10,6 +10,7 @@ import (
 	"os"
 	"path"
 	"runtime"
+	"strings"
 	"time"
 )
 
@@ -28,25 +29,102 @@ func (s maskString) MarshalJSON() ([]byte, error) {
 }
 
 type Configuration struct {
-	Server *Server `yaml:"server" json:"server"`
-	GitHub *GitHub `yaml:"github" json:"github"`
-	Job    *Job    `yaml:"job" json:"job"`
+	Server       *Server                `yaml:"server" json:"server"`
+	GitHub       *GitHub                `yaml:"github" json:"github"`
+	Job          *Job                   `yaml:"job" json:"job"`
+	Repositories map[string]*Repository `yaml:"repositories" json:"repositories"`
+	Policy       *Policy                `yaml:"policy" json:"policy"`
 }
 
 type Server struct {
 	WorkDir      string `yaml:"workdir" json:"workdir"`
 	Port         int    `yaml:"port" json:"port"`
 	DatabasePath string `yaml:"database_path" json:"databasePath"`
+	// GCInterval is interval in seconds to gc mirrors of repositories
+	GCInterval int64 `yaml:"gc_interval" json:"gcInterval"`
+	// SecretKey is key to encrypt secrets of repositories, and AdminToken is bearer token for the secrets and cancel api
+	SecretKey  maskString `yaml:"secret_key" json:"secretKey"`
+	AdminToken maskString `yaml:"admin_token" json:"adminToken"`
 }
 
 type GitHub struct {
-	SSHKeyPath string     `yaml:"ssh_key_path" json:"sshKeyPath"`
-	APIToken   maskString `yaml:"api_token" json:"apiToken"`
+	SSHKeyPath    string                 `yaml:"ssh_key_path" json:"sshKeyPath"`
+	APIToken      maskString             `yaml:"api_token" json:"apiToken"`
+	WebhookSecret maskString             `yaml:"webhook_secret" json:"webhookSecret"`
+	PullRequest   *PullRequest           `yaml:"pull_request" json:"pullRequest"`
+	Reporter      string                 `yaml:"reporter" json:"reporter"`
+	App           *App                   `yaml:"app" json:"app"`
+	BaseURL       string                 `yaml:"base_url" json:"baseUrl"`
+	UploadURL     string                 `yaml:"upload_url" json:"uploadUrl"`
+	Hosts         map[string]*GitHubHost `yaml:"hosts" json:"hosts"`
+	Clone         string                 `yaml:"clone" json:"clone"`
+	// SSHKeyPassphrase is passphrase of the ssh key, if it is encrypted
+	SSHKeyPassphrase maskString `yaml:"ssh_key_passphrase" json:"sshKeyPassphrase"`
+	// KnownHostsPath is known_hosts file to verify host keys ( default: SSH_KNOWN_HOSTS or $HOME/.ssh/known_hosts )
+	KnownHostsPath string `yaml:"known_hosts_path" json:"knownHostsPath"`
+}
+
+// GitHubHost is settings for another GitHub host ( e.g. GitHub Enterprise Server ), keyed by host name.
+type GitHubHost struct {
+	BaseURL   string     `yaml:"base_url" json:"baseUrl"`
+	UploadURL string     `yaml:"upload_url" json:"uploadUrl"`
+	APIToken  maskString `yaml:"api_token" json:"apiToken"`
+	App       *App       `yaml:"app" json:"app"`
+}
+
+// App is settings to authenticate as a GitHub App instead of api token.
+type App struct {
+	ID             int64  `yaml:"id" json:"id"`
+	PrivateKeyPath string `yaml:"private_key_path" json:"privateKeyPath"`
+}
+
+// PullRequest is settings for builds triggered by pull_request event.
+type PullRequest struct {
+	Actions   []string `yaml:"actions" json:"actions"`
+	SkipDraft bool     `yaml:"skip_draft" json:"skipDraft"`
+	// Checkout is the ref to build, branch ( refs/heads/<branch> ), head ( refs/pull/<n>/head ) or merge ( refs/pull/<n>/merge )
+	Checkout string `yaml:"checkout" json:"checkout"`
+}
+
+// Repository is settings for each repository, keyed by full name ( owner/repo ).
+type Repository struct {
+	WebhookSecret maskString `yaml:"webhook_secret" json:"webhookSecret"`
+	Clone         string     `yaml:"clone" json:"clone"`
+	// SSHKeyPath is deploy key of the repository, used instead of the ssh key of server
+	SSHKeyPath       string     `yaml:"ssh_key_path" json:"sshKeyPath"`
+	SSHKeyPassphrase maskString `yaml:"ssh_key_passphrase" json:"sshKeyPassphrase"`
+}
+
+// Policy is restrictions on jobs by the administrator.
+type Policy struct {
+	// Volumes is host path prefixes which may be mounted, keyed by full name of repository ( owner/repo ) or owner
+	Volumes map[string][]string `yaml:"volumes" json:"volumes"`
 }
 
 type Job struct {
-	Timeout     int64 `yaml:"timeout" json:"timeout"`
-	Concurrency int   `yaml:"concurrency" json:"concurrency"`
+	Timeout        int64 `yaml:"timeout" json:"timeout"`
+	Concurrency    int   `yaml:"concurrency" json:"concurrency"`
+	CancelPrevious bool  `yaml:"cancel_previous" json:"cancelPrevious"`
+	RemoveImage    bool  `yaml:"remove_image" json:"removeImage"`
+	KeepOnFailure  bool  `yaml:"keep_on_failure" json:"keepOnFailure"`
+	// Networks is network modes which repositories may request. The first is the default.
+	Networks []string `yaml:"networks" json:"networks"`
+	// DefaultResources is limits for jobs not requesting, and MaxResources is the maximum jobs may request
+	DefaultResources *Resources `yaml:"default_resources" json:"defaultResources"`
+	MaxResources     *Resources `yaml:"max_resources" json:"maxResources"`
+	// SecretsForPullRequests gives secrets to builds of pull requests, which may run code from forks
+	SecretsForPullRequests bool `yaml:"secrets_for_pull_requests" json:"secretsForPullRequests"`
+}
+
+// Resources is limits of resources for containers of jobs. Zero means unlimited.
+type Resources struct {
+	// Memory is limit with unit ( e.g. 512m, 2g )
+	Memory    string `yaml:"memory" json:"memory"`
+	CPUShares int64  `yaml:"cpu_shares" json:"cpuShares"`
+	// CPUQuota is CPU time in microseconds per 100 milliseconds ( e.g. 50000 for half of a CPU )
+	CPUQuota  int64            `yaml:"cpu_quota" json:"cpuQuota"`
+	PidsLimit int64            `yaml:"pids_limit" json:"pidsLimit"`
+	Ulimits   map[string]int64 `yaml:"ulimits" json:"ulimits"`
 }
 
 func init() {
@@ -55,14 +133,27 @@ func init() {
 			WorkDir:      path.Join(os.TempDir(), Name),
 			Port:         8080,
 			DatabasePath: path.Join(os.Getenv("HOME"), ".duci/db"),
+			GCInterval:   86400,
+			SecretKey:    maskString(os.Getenv("DUCI_SECRET_KEY")),
+			AdminToken:   maskString(os.Getenv("DUCI_ADMIN_TOKEN")),
 		},
 		GitHub: &GitHub{
-			SSHKeyPath: path.Join(os.Getenv("HOME"), ".ssh/id_rsa"),
-			APIToken:   maskString(os.Getenv("GITHUB_API_TOKEN")),
+			SSHKeyPath:    path.Join(os.Getenv("HOME"), ".ssh/id_rsa"),
+			APIToken:      maskString(os.Getenv("GITHUB_API_TOKEN")),
+			WebhookSecret: maskString(os.Getenv("GITHUB_WEBHOOK_SECRET")),
+			PullRequest: &PullRequest{
+				Actions:   []string{"opened", "synchronize", "reopened"},
+				SkipDraft: true,
+				Checkout:  "branch",
+			},
+			Reporter:         "status",
+			Clone:            "ssh",
+			SSHKeyPassphrase: maskString(os.Getenv("SSH_KEY_PASSPHRASE")),
 		},
 		Job: &Job{
 			Timeout:     600,
 			Concurrency: runtime.NumCPU(),
+			Networks:    []string{"bridge", "isolated", "none"},
 		},
 	}
 }
@@ -86,6 +177,39 @@ func (c *Configuration) Addr() string {
 	return fmt.Sprintf(":%d", c.Server.Port)
 }
 
+// MirrorDir returns the directory to keep mirrors of repositories.
+func (c *Configuration) MirrorDir() string {
+	return path.Join(c.Server.WorkDir, "mirrors")
+}
+
 func (c *Configuration) Timeout() time.Duration {
 	return time.Duration(c.Job.Timeout) * time.Second
 }
+
+// WebhookSecret returns secret for verifying webhook payloads of the repository.
+// A repository setting takes precedence over the server-wide one.
+func (c *Configuration) WebhookSecret(fullName string) string {
+	if repo, ok := c.Repositories[fullName]; ok && len(repo.WebhookSecret) > 0 {
+		return string(repo.WebhookSecret)
+	}
+	return string(c.GitHub.WebhookSecret)
+}
+
+// CloneProtocol returns the protocol to clone the repository, ssh or https.
+// A repository setting takes precedence over the server-wide one.
+func (c *Configuration) CloneProtocol(fullName string) string {
+	if repo, ok := c.Repositories[fullName]; ok && len(repo.Clone) > 0 {
+		return repo.Clone
+	}
+	return c.GitHub.Clone
+}
+
+// AllowedVolumes returns host path prefixes which the repository may mount,
+// allowed for the repository or its owner.
+func (c *Configuration) AllowedVolumes(fullName string) []string {
+	if c.Policy == nil {
+		return nil
+	}
+	owner := strings.SplitN(fullName, "/", 2)[0]
+	return append(append([]string{}, c.Policy.Volumes[fullName]...), c.Policy.Volumes[owner]...)
+}
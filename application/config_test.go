@@ -19,8 +19,9 @@ func TestConfiguration_String(t *testing.T) {
 			Port:         1234,
 		},
 		GitHub: &application.GitHub{
-			SSHKeyPath: "/path/to/ssh_key_path",
-			APIToken:   "github_api_token",
+			SSHKeyPath:    "/path/to/ssh_key_path",
+			APIToken:      "github_api_token",
+			WebhookSecret: "github_webhook_secret",
 		},
 		Job: &application.Job{
 			Timeout:     60,
@@ -30,8 +31,11 @@ func TestConfiguration_String(t *testing.T) {
 
 	// and
 	expected := fmt.Sprintf(
-		"{\"server\":{\"workdir\":\"%s\",\"port\":%d,\"databasePath\":\"%s\"},"+
-			"\"github\":{\"sshKeyPath\":\"%s\",\"apiToken\":\"***\"},\"job\":{\"timeout\":%d,\"concurrency\":%d}}",
+		"{\"server\":{\"workdir\":\"%s\",\"port\":%d,\"databasePath\":\"%s\",\"gcInterval\":0,\"secretKey\":\"***\",\"adminToken\":\"***\"},"+
+			"\"github\":{\"sshKeyPath\":\"%s\",\"apiToken\":\"***\",\"webhookSecret\":\"***\",\"pullRequest\":null,\"reporter\":\"\",\"app\":null,\"baseUrl\":\"\",\"uploadUrl\":\"\",\"hosts\":null,\"clone\":\"\","+
+			"\"sshKeyPassphrase\":\"***\",\"knownHostsPath\":\"\"},"+
+			"\"job\":{\"timeout\":%d,\"concurrency\":%d,\"cancelPrevious\":false,"+
+			"\"removeImage\":false,\"keepOnFailure\":false,\"networks\":null,\"defaultResources\":null,\"maxResources\":null,\"secretsForPullRequests\":false},\"repositories\":null,\"policy\":null}",
 		conf.Server.WorkDir,
 		conf.Server.Port,
 		conf.Server.DatabasePath,
@@ -57,14 +61,65 @@ func TestConfiguration_Set(t *testing.T) {
 				WorkDir:      "/path/to/workdir",
 				Port:         8823,
 				DatabasePath: "/path/to/database",
+				GCInterval:   3600,
+				SecretKey:    "duci_secret_key",
+				AdminToken:   "duci_admin_token",
 			},
 			GitHub: &application.GitHub{
-				SSHKeyPath: "/path/to/ssh_key",
-				APIToken:   "github_api_token",
+				SSHKeyPath:    "/path/to/ssh_key",
+				APIToken:      "github_api_token",
+				WebhookSecret: "github_webhook_secret",
+				PullRequest: &application.PullRequest{
+					Actions:   []string{"opened"},
+					SkipDraft: false,
+					Checkout:  "merge",
+				},
+				Reporter: "checks",
+				App: &application.App{
+					ID:             12345,
+					PrivateKeyPath: "/path/to/private-key.pem",
+				},
+				BaseURL:   "https://github.example.com/api/v3/",
+				UploadURL: "https://github.example.com/api/uploads/",
+				Hosts: map[string]*application.GitHubHost{
+					"github.com": {
+						BaseURL:  "https://api.github.com/",
+						APIToken: "github_com_api_token",
+					},
+				},
+				Clone:            "ssh",
+				SSHKeyPassphrase: "ssh_key_passphrase",
+				KnownHostsPath:   "/path/to/known_hosts",
 			},
 			Job: &application.Job{
 				Timeout:     300,
 				Concurrency: 5,
+				Networks:    []string{"isolated", "none"},
+				DefaultResources: &application.Resources{
+					Memory:    "1g",
+					PidsLimit: 256,
+				},
+				MaxResources: &application.Resources{
+					Memory:    "4g",
+					CPUShares: 1024,
+					CPUQuota:  200000,
+					PidsLimit: 1024,
+					Ulimits:   map[string]int64{"nofile": 4096},
+				},
+			},
+			Repositories: map[string]*application.Repository{
+				"duck8823/duci": {
+					WebhookSecret:    "repository_webhook_secret",
+					Clone:            "https",
+					SSHKeyPath:       "/path/to/deploy_key",
+					SSHKeyPassphrase: "deploy_key_passphrase",
+				},
+			},
+			Policy: &application.Policy{
+				Volumes: map[string][]string{
+					"duck8823/duci": {"/var/cache/duci"},
+					"duck8823":      {"/var/cache/shared"},
+				},
 			},
 		}
 
@@ -134,3 +189,105 @@ func TestConfiguration_Timeout(t *testing.T) {
 		t.Errorf("addr should equal 8823 sec, but got %+v", actual)
 	}
 }
+
+func TestConfiguration_WebhookSecret(t *testing.T) {
+	// given
+	conf := &application.Configuration{
+		GitHub: &application.GitHub{
+			WebhookSecret: "server_secret",
+		},
+		Repositories: map[string]*application.Repository{
+			"duck8823/duci":  {WebhookSecret: "repository_secret"},
+			"duck8823/empty": {},
+		},
+	}
+
+	for _, tt := range []struct {
+		fullName string
+		expected string
+	}{
+		{fullName: "duck8823/duci", expected: "repository_secret"},
+		{fullName: "duck8823/empty", expected: "server_secret"},
+		{fullName: "duck8823/unknown", expected: "server_secret"},
+	} {
+		t.Run(tt.fullName, func(t *testing.T) {
+			// when
+			actual := conf.WebhookSecret(tt.fullName)
+
+			// then
+			if actual != tt.expected {
+				t.Errorf("secret should equal %s, but got %s", tt.expected, actual)
+			}
+		})
+	}
+}
+
+func TestConfiguration_CloneProtocol(t *testing.T) {
+	// given
+	conf := &application.Configuration{
+		GitHub: &application.GitHub{
+			Clone: "ssh",
+		},
+		Repositories: map[string]*application.Repository{
+			"duck8823/duci":  {Clone: "https"},
+			"duck8823/empty": {},
+		},
+	}
+
+	for _, tt := range []struct {
+		fullName string
+		expected string
+	}{
+		{fullName: "duck8823/duci", expected: "https"},
+		{fullName: "duck8823/empty", expected: "ssh"},
+		{fullName: "duck8823/unknown", expected: "ssh"},
+	} {
+		t.Run(tt.fullName, func(t *testing.T) {
+			// when
+			actual := conf.CloneProtocol(tt.fullName)
+
+			// then
+			if actual != tt.expected {
+				t.Errorf("protocol should equal %s, but got %s", tt.expected, actual)
+			}
+		})
+	}
+}
+
+func TestConfiguration_AllowedVolumes(t *testing.T) {
+	// given
+	conf := &application.Configuration{
+		Policy: &application.Policy{
+			Volumes: map[string][]string{
+				"duck8823/duci": {"/var/cache/duci"},
+				"duck8823":      {"/var/cache/shared"},
+			},
+		},
+	}
+
+	for _, tt := range []struct {
+		fullName string
+		expected []string
+	}{
+		{fullName: "duck8823/duci", expected: []string{"/var/cache/duci", "/var/cache/shared"}},
+		{fullName: "duck8823/other", expected: []string{"/var/cache/shared"}},
+		{fullName: "unknown/duci", expected: []string{}},
+	} {
+		t.Run(tt.fullName, func(t *testing.T) {
+			// when
+			actual := conf.AllowedVolumes(tt.fullName)
+
+			// then
+			if !reflect.DeepEqual(actual, tt.expected) {
+				t.Errorf("volumes should equal %+v, but got %+v", tt.expected, actual)
+			}
+		})
+	}
+
+	t.Run("without policy", func(t *testing.T) {
+		// expect
+		if actual := (&application.Configuration{}).AllowedVolumes("duck8823/duci"); len(actual) != 0 {
+			t.Errorf("volumes must be empty, but got %+v", actual)
+		}
+	})
+}
@@ -7,11 +7,17 @@ import (
 	"time"
 )
 
+var (
+	Canceled         = context.Canceled
+	DeadlineExceeded = context.DeadlineExceeded
+)
+
 type Context interface {
 	context.Context
 	UUID() uuid.UUID
 	TaskName() string
 	Url() *url.URL
+	Trigger() string
 }
 
 type jobContext struct {
@@ -19,6 +25,7 @@ type jobContext struct {
 	uuid     uuid.UUID
 	taskName string
 	url      *url.URL
+	trigger  string
 }
 
 func New(taskName string, id uuid.UUID, url *url.URL) Context {
@@ -42,6 +49,32 @@ func (c *jobContext) Url() *url.URL {
 	return c.url
 }
 
+func (c *jobContext) Trigger() string {
+	return c.trigger
+}
+
+// WithTrigger returns a copy of parent with the event triggered the job.
+func WithTrigger(parent Context, trigger string) Context {
+	return &jobContext{
+		Context:  parent,
+		uuid:     parent.UUID(),
+		taskName: parent.TaskName(),
+		url:      parent.Url(),
+		trigger:  trigger,
+	}
+}
+
+// WithTaskName returns a copy of parent with the task name, to report a part of the job separately.
+func WithTaskName(parent Context, taskName string) Context {
+	return &jobContext{
+		Context:  parent,
+		uuid:     parent.UUID(),
+		taskName: taskName,
+		url:      parent.Url(),
+		trigger:  parent.Trigger(),
+	}
+}
+
 func WithTimeout(parent Context, timeout time.Duration) (Context, context.CancelFunc) {
 	ctx, cancel := context.WithTimeout(parent, timeout)
 	return &jobContext{
@@ -49,5 +82,6 @@ func WithTimeout(parent Context, timeout time.Duration) (Context, context.Cancel
 		uuid:     parent.UUID(),
 		taskName: parent.TaskName(),
 		url:      parent.Url(),
+		trigger:  parent.Trigger(),
 	}, cancel
 }
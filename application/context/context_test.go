@@ -20,6 +20,55 @@ func TestContextWithUUID_UUID(t *testing.T) {
 	}
 }
 
+func TestWithTrigger(t *testing.T) {
+	// given
+	parent := context.New("test/task", uuid.New(), &url.URL{})
+
+	// when
+	ctx := context.WithTrigger(parent, "push")
+
+	// then
+	if ctx.Trigger() != "push" {
+		t.Errorf("trigger must be push, but got %s", ctx.Trigger())
+	}
+
+	if ctx.UUID() != parent.UUID() {
+		t.Errorf("uuid must be %s, but got %s", parent.UUID(), ctx.UUID())
+	}
+
+	// and
+	timeout, cancel := context.WithTimeout(ctx, time.Second)
+	defer cancel()
+
+	if timeout.Trigger() != "push" {
+		t.Errorf("trigger must be inherited, but got %s", timeout.Trigger())
+	}
+}
+
+func TestWithTaskName(t *testing.T) {
+	// given
+	ctx, cancel := context.WithTimeout(context.WithTrigger(context.New("test/task", uuid.New(), &url.URL{}), "push"), time.Minute)
+	defer cancel()
+
+	// when
+	step := context.WithTaskName(ctx, "test/task/step")
+
+	// then
+	if step.TaskName() != "test/task/step" {
+		t.Errorf("task name must be test/task/step, but got %s", step.TaskName())
+	}
+
+	if step.UUID() != ctx.UUID() || step.Trigger() != "push" {
+		t.Errorf("uuid and trigger must be inherited, but got %s, %s", step.UUID(), step.Trigger())
+	}
+
+	// and
+	cancel()
+	if step.Err() == nil {
+		t.Error("step must be cancelled with parent")
+	}
+}
+
 func TestWithTimeout(t *testing.T) {
 	t.Run("when timeout", func(t *testing.T) {
 		// when
@@ -1,53 +1,140 @@
 package git
 
 import (
+	"github.com/duck8823/duci/application"
 	"github.com/duck8823/duci/application/context"
+	"github.com/duck8823/duci/application/service/github"
 	"github.com/pkg/errors"
-	"gopkg.in/src-d/go-git.v4"
 	"gopkg.in/src-d/go-git.v4/plumbing"
 	"gopkg.in/src-d/go-git.v4/plumbing/transport"
-	"gopkg.in/src-d/go-git.v4/plumbing/transport/ssh"
+	"gopkg.in/src-d/go-git.v4/plumbing/transport/http"
+)
+
+// Protocols to clone repositories.
+const (
+	SSH   = "ssh"
+	HTTPS = "https"
 )
 
 type Service interface {
-	Clone(ctx context.Context, dir string, sshUrl string, ref string, sha plumbing.Hash) error
+	Clone(ctx context.Context, dir string, repo github.Repository, ref string, sha plumbing.Hash) error
+	// Update initializes submodules and fetches lfs objects of the checkout, as the options.
+	Update(ctx context.Context, dir string, repo github.Repository, opts Options) error
 }
 
-type sshGitService struct {
-	auth transport.AuthMethod
+// Options is settings of the checkout in .duci/config.yml.
+type Options struct {
+	Submodules bool `yaml:"submodules"`
+	LFS        bool `yaml:"lfs"`
 }
 
-func New(sshKeyPath string) (Service, error) {
-	auth, err := ssh.NewPublicKeysFromFile("git", sshKeyPath, "")
-	if err != nil {
-		return nil, err
+// TokenSource provides the token to clone the repository over https.
+type TokenSource interface {
+	Token(ctx context.Context, repo github.Repository) (string, error)
+}
+
+type gitService struct {
+	sshKey     *sshKey
+	deployKeys map[string]*sshKey
+	tokens     TokenSource
+	mirrors    *mirrors
+}
+
+// New returns the service cloning with the protocol configured for each repository.
+// The ssh key is required unless the server clones over https.
+// Host keys are verified with the known_hosts file, and deploy keys are used for the repositories configured.
+func New(sshKeyPath string, tokens TokenSource) (Service, error) {
+	conf := application.Config.GitHub
+	callback, callbackErr := hostKeyCallback(conf.KnownHostsPath)
+	if callbackErr != nil && len(conf.KnownHostsPath) > 0 {
+		return nil, errors.Wrapf(callbackErr, "invalid known_hosts %s", conf.KnownHostsPath)
+	}
+
+	key := newSSHKey(sshKeyPath, string(conf.SSHKeyPassphrase), callback, callbackErr)
+	if key.err != nil && conf.Clone != HTTPS {
+		return nil, errors.WithStack(key.err)
+	}
+
+	deployKeys := make(map[string]*sshKey)
+	for name, repo := range application.Config.Repositories {
+		if len(repo.SSHKeyPath) == 0 {
+			continue
+		}
+		deployKey := newSSHKey(repo.SSHKeyPath, string(repo.SSHKeyPassphrase), callback, callbackErr)
+		if deployKey.err != nil {
+			return nil, errors.Wrapf(deployKey.err, "invalid deploy key of %s", name)
+		}
+		deployKeys[name] = deployKey
 	}
-	return &sshGitService{auth: auth}, nil
+
+	return &gitService{
+		sshKey:     key,
+		deployKeys: deployKeys,
+		tokens:     tokens,
+		mirrors:    &mirrors{},
+	}, nil
 }
 
-func (s *sshGitService) Clone(ctx context.Context, dir string, sshUrl string, ref string, sha plumbing.Hash) error {
-	gitRepository, err := git.PlainClone(dir, false, &git.CloneOptions{
-		URL:           sshUrl,
-		Auth:          s.auth,
-		Progress:      &ProgressLogger{ctx.UUID()},
-		ReferenceName: plumbing.ReferenceName(ref),
-		Depth:         1,
-	})
+// Clone checks out the commit into the directory.
+// Objects are fetched into the mirror of the repository, and objects of the commit are copied to the directory.
+// For the merge ref of pull request, it checks out the merge commit of the head commit.
+func (s *gitService) Clone(ctx context.Context, dir string, repo github.Repository, ref string, sha plumbing.Hash) error {
+	url, auth, err := s.remote(ctx, repo)
 	if err != nil {
 		return errors.WithStack(err)
 	}
 
-	wt, err := gitRepository.Worktree()
+	mirror := mirrorDir(repo)
+	unlock := s.mirrors.lock(mirror)
+	defer unlock()
+
+	if mergeRef.MatchString(ref) {
+		sha, err = fetchMerge(ctx, mirror, url, auth, ref, sha)
+	} else {
+		sha, err = fetch(ctx, mirror, url, auth, ref, sha)
+	}
 	if err != nil {
 		return errors.WithStack(err)
 	}
-
-	if err := wt.Checkout(&git.CheckoutOptions{
-		Hash:   sha,
-		Branch: plumbing.ReferenceName(sha.String()),
-		Create: true,
-	}); err != nil {
+	if err := checkout(dir, mirror, sha); err != nil {
 		return errors.WithStack(err)
 	}
 	return nil
 }
+
+// remote returns url and auth method of the repository, for the protocol configured.
+func (s *gitService) remote(ctx context.Context, repo github.Repository) (string, transport.AuthMethod, error) {
+	switch protocol := application.Config.CloneProtocol(repo.GetFullName()); protocol {
+	case SSH:
+		auth, err := s.auth(ctx, repo, protocol)
+		return repo.GetSSHURL(), auth, errors.WithStack(err)
+	case HTTPS:
+		auth, err := s.auth(ctx, repo, protocol)
+		return repo.GetCloneURL(), auth, errors.WithStack(err)
+	default:
+		return "", nil, errors.Errorf("unsupported protocol to clone: %s", protocol)
+	}
+}
+
+// auth returns auth method of the protocol, to fetch the repository ( or its submodules ).
+func (s *gitService) auth(ctx context.Context, repo github.Repository, protocol string) (transport.AuthMethod, error) {
+	switch protocol {
+	case SSH:
+		key := s.sshKeyOf(repo)
+		if key.err != nil {
+			return nil, errors.Wrap(key.err, "ssh key is not available")
+		}
+		return key.auth, nil
+	case HTTPS, "http":
+		token, err := s.tokens.Token(ctx, repo)
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+		if len(token) == 0 {
+			return nil, nil
+		}
+		return &http.BasicAuth{Username: "x-access-token", Password: token}, nil
+	default:
+		return nil, errors.Errorf("unsupported protocol to clone: %s", protocol)
+	}
+}
@@ -2,30 +2,157 @@ package git_test
 
 import (
 	"fmt"
+	"github.com/duck8823/duci/application"
 	"github.com/duck8823/duci/application/context"
 	"github.com/duck8823/duci/application/service/git"
+	"github.com/duck8823/duci/application/service/github"
+	"github.com/duck8823/duci/data/model"
+	"github.com/duck8823/duci/infrastructure/clock"
 	"github.com/google/uuid"
+	"golang.org/x/crypto/ssh"
 	"gopkg.in/src-d/go-git.v4/plumbing"
+	"io/ioutil"
 	"net/url"
 	"os"
+	"os/exec"
 	"path"
+	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 )
 
 func TestNew(t *testing.T) {
+	// setup
+	reset := application.Config.GitHub.Clone
+	defer func() {
+		application.Config.GitHub.Clone = reset
+	}()
+
 	t.Run("when missing ssh key", func(t *testing.T) {
+		// given
+		application.Config.GitHub.Clone = git.SSH
+
 		// expect
-		if _, err := git.New("/path/to/wrong/"); err == nil {
+		if _, err := git.New("/path/to/wrong/", nil); err == nil {
 			t.Error("error must occur")
 		}
 	})
+
+	t.Run("when missing ssh key but clone over https", func(t *testing.T) {
+		// given
+		application.Config.GitHub.Clone = git.HTTPS
+
+		// expect
+		if _, err := git.New("/path/to/wrong/", nil); err != nil {
+			t.Errorf("error must not occur, but got %+v", err)
+		}
+	})
+}
+
+type MockTokenSource struct {
+	token string
+}
+
+func (s *MockTokenSource) Token(_ context.Context, _ github.Repository) (string, error) {
+	return s.token, nil
+}
+
+func TestGitService_Clone(t *testing.T) {
+	// setup
+	upstream := newUpstream(t)
+	defer upstream.Close()
+
+	reset := *application.Config.GitHub
+	resetServer := *application.Config.Server
+	resetRepositories := application.Config.Repositories
+	defer func() {
+		*application.Config.GitHub = reset
+		*application.Config.Server = resetServer
+		application.Config.Repositories = resetRepositories
+	}()
+
+	// and
+	key := generateKey(t)
+	publicKey, err := ssh.NewPublicKey(&key.PublicKey)
+	if err != nil {
+		t.Fatalf("error occurred: %+v", err)
+	}
+	keyPath := writeKey(t, upstream.root, key)
+
+	addr, knownHosts := upstream.serveSSH(t, publicKey)
+	resetKnownHosts := os.Getenv("SSH_KNOWN_HOSTS")
+	defer os.Setenv("SSH_KNOWN_HOSTS", resetKnownHosts)
+	os.Setenv("SSH_KNOWN_HOSTS", knownHosts)
+
+	server := upstream.serveHTTP(t, "token")
+	defer server.Close()
+
+	// and
+	repo := &model.Repository{
+		FullName: "duck8823/duci",
+		SSHURL:   fmt.Sprintf("ssh://git@%s/%s", addr, upstream.name),
+		CloneURL: fmt.Sprintf("%s/%s", server.URL, upstream.name),
+	}
+
+	for _, tt := range []struct {
+		name       string
+		server     string
+		repository string
+		token      string
+		err        bool
+	}{
+		{name: "over ssh", server: git.SSH},
+		{name: "over https", server: git.HTTPS, token: "token"},
+		{name: "over https selected by repository", server: git.SSH, repository: git.HTTPS, token: "token"},
+		{name: "over https with wrong token", server: git.HTTPS, token: "wrong", err: true},
+		{name: "with unsupported protocol", server: "ftp", err: true},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			// given
+			application.Config.GitHub.Clone = tt.server
+			application.Config.Repositories = map[string]*application.Repository{
+				repo.FullName: {Clone: tt.repository},
+			}
+			application.Config.Server.WorkDir = tempDir(t)
+			defer os.RemoveAll(application.Config.Server.WorkDir)
+
+			// and
+			sut, err := git.New(keyPath, &MockTokenSource{token: tt.token})
+			if err != nil {
+				t.Fatalf("error occurred: %+v", err)
+			}
+
+			// and
+			dir := tempDir(t)
+			defer os.RemoveAll(dir)
+
+			// when
+			err = sut.Clone(context.New("test/task", uuid.New(), &url.URL{}), dir, repo, "refs/heads/master", upstream.head)
+
+			// then
+			if tt.err {
+				if err == nil {
+					t.Error("error must occur, but got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("error must not occur, but got %+v", err)
+			}
+
+			// and
+			if head := command(t, "-C", dir, "rev-parse", "HEAD"); head != upstream.head.String() {
+				t.Errorf("must checkout %s, but got %s", upstream.head, head)
+			}
+		})
+	}
 }
 
 func TestSshGitService_Clone(t *testing.T) {
 	t.Run("with correct key", func(t *testing.T) {
 		// setup
-		client, err := git.New(path.Join(os.Getenv("HOME"), ".ssh/id_rsa"))
+		client, err := git.New(path.Join(os.Getenv("HOME"), ".ssh/id_rsa"), nil)
 		if err != nil {
 			t.Fatalf("error occurred. %+v", err)
 		}
@@ -41,7 +168,7 @@ func TestSshGitService_Clone(t *testing.T) {
 			err := client.Clone(
 				context.New("test/task", uuid.New(), &url.URL{}),
 				tempDir,
-				"git@github.com:duck8823/duci.git",
+				&model.Repository{SSHURL: "git@github.com:duck8823/duci.git"},
 				"refs/heads/master",
 				plumbing.ZeroHash,
 			)
@@ -68,7 +195,7 @@ func TestSshGitService_Clone(t *testing.T) {
 			err := client.Clone(
 				context.New("test/task", uuid.New(), &url.URL{}),
 				wrongPath,
-				"git@github.com:duck8823/duci.git",
+				&model.Repository{SSHURL: "git@github.com:duck8823/duci.git"},
 				"refs/heads/master",
 				plumbing.ZeroHash,
 			)
@@ -80,3 +207,516 @@ func TestSshGitService_Clone(t *testing.T) {
 		})
 	})
 }
+
+func TestGitService_Clone_WithMirror(t *testing.T) {
+	// setup
+	upstream := newUpstream(t)
+	defer upstream.Close()
+
+	server := upstream.serveHTTP(t, "")
+
+	reset := *application.Config.GitHub
+	resetServer := *application.Config.Server
+	defer func() {
+		*application.Config.GitHub = reset
+		*application.Config.Server = resetServer
+	}()
+	application.Config.GitHub.Clone = git.HTTPS
+	application.Config.Server.WorkDir = tempDir(t)
+	defer os.RemoveAll(application.Config.Server.WorkDir)
+
+	// and
+	repo := &model.Repository{
+		FullName: "duck8823/duci",
+		HTMLURL:  "https://github.example.com/duck8823/duci",
+		CloneURL: fmt.Sprintf("%s/%s", server.URL, upstream.name),
+	}
+	mirror := path.Join(application.Config.Server.WorkDir, "mirrors", "github.example.com", "duck8823/duci.git")
+
+	sut, err := git.New("/path/to/nothing", &MockTokenSource{})
+	if err != nil {
+		t.Fatalf("error occurred: %+v", err)
+	}
+	ctx := context.New("test/task", uuid.New(), &url.URL{})
+
+	t.Run("with concurrent jobs", func(t *testing.T) {
+		// given
+		dirs := []string{tempDir(t), tempDir(t), tempDir(t)}
+		errs := make(chan error, len(dirs))
+
+		// when
+		for _, dir := range dirs {
+			defer os.RemoveAll(dir)
+			go func(dir string) {
+				errs <- sut.Clone(ctx, dir, repo, "refs/heads/master", upstream.head)
+			}(dir)
+		}
+
+		// then
+		for range dirs {
+			if err := <-errs; err != nil {
+				t.Errorf("error must not occur, but got %+v", err)
+			}
+		}
+
+		// and
+		if _, err := os.Stat(path.Join(mirror, "objects")); err != nil {
+			t.Errorf("mirror must be created, but got %+v", err)
+		}
+		for _, dir := range dirs {
+			if _, err := os.Stat(path.Join(dir, ".git/objects/info/alternates")); !os.IsNotExist(err) {
+				t.Errorf("objects must not refer the mirror, but got %+v", err)
+			}
+		}
+	})
+
+	t.Run("without mirror", func(t *testing.T) {
+		// given
+		dir := tempDir(t)
+		defer os.RemoveAll(dir)
+		if err := sut.Clone(ctx, dir, repo, "refs/heads/master", upstream.head); err != nil {
+			t.Fatalf("error occurred: %+v", err)
+		}
+
+		// and
+		moved := fmt.Sprintf("%s.moved", mirror)
+		if err := os.Rename(mirror, moved); err != nil {
+			t.Fatalf("error occurred: %+v", err)
+		}
+		defer os.Rename(moved, mirror)
+
+		// expect
+		if head := command(t, "-C", dir, "rev-parse", "HEAD"); head != upstream.head.String() {
+			t.Errorf("must checkout %s, but got %s", upstream.head, head)
+		}
+		command(t, "-C", dir, "log", "--oneline")
+		command(t, "-C", dir, "fsck")
+	})
+
+	t.Run("with new commit", func(t *testing.T) {
+		// given
+		sha := upstream.commit(t)
+		dir := tempDir(t)
+		defer os.RemoveAll(dir)
+
+		// when
+		err := sut.Clone(ctx, dir, repo, "refs/heads/master", sha)
+
+		// then
+		if err != nil {
+			t.Fatalf("error must not occur, but got %+v", err)
+		}
+		if head := command(t, "-C", dir, "rev-parse", "HEAD"); head != sha.String() {
+			t.Errorf("must checkout %s, but got %s", sha, head)
+		}
+	})
+
+	t.Run("with commit in mirror", func(t *testing.T) {
+		// given
+		sha := upstream.head
+		server.Close()
+
+		// and
+		dir := tempDir(t)
+		defer os.RemoveAll(dir)
+
+		// when
+		err := sut.Clone(ctx, dir, repo, "refs/heads/master", sha)
+
+		// then
+		if err != nil {
+			t.Fatalf("must not fetch, but got %+v", err)
+		}
+	})
+}
+
+func TestGitService_Clone_GC(t *testing.T) {
+	// setup
+	upstream := newUpstream(t)
+	defer upstream.Close()
+
+	server := upstream.serveHTTP(t, "")
+	defer server.Close()
+
+	reset := *application.Config.GitHub
+	resetServer := *application.Config.Server
+	defer func() {
+		*application.Config.GitHub = reset
+		*application.Config.Server = resetServer
+	}()
+	application.Config.GitHub.Clone = git.HTTPS
+	application.Config.Server.WorkDir = tempDir(t)
+	application.Config.Server.GCInterval = 3600
+	defer os.RemoveAll(application.Config.Server.WorkDir)
+
+	now := time.Date(2018, time.October, 1, 0, 0, 0, 0, time.UTC)
+	defer clock.Adjust()
+
+	// and
+	repo := &model.Repository{
+		FullName: "duck8823/duci",
+		CloneURL: fmt.Sprintf("%s/%s", server.URL, upstream.name),
+	}
+	stamp := path.Join(application.Config.Server.WorkDir, "mirrors", "duck8823/duci.git", "duci_gc")
+
+	sut, err := git.New("/path/to/nothing", &MockTokenSource{})
+	if err != nil {
+		t.Fatalf("error occurred: %+v", err)
+	}
+	ctx := context.New("test/task", uuid.New(), &url.URL{})
+
+	for _, tt := range []struct {
+		after    time.Duration
+		expected time.Duration
+	}{
+		{after: 0, expected: 0},
+		{after: 30 * time.Minute, expected: 0},
+		{after: 2 * time.Hour, expected: 2 * time.Hour},
+	} {
+		// given
+		clock.Now = func() time.Time {
+			return now.Add(tt.after)
+		}
+		sha := upstream.commit(t)
+		dir := tempDir(t)
+
+		// when
+		err := sut.Clone(ctx, dir, repo, "refs/heads/master", sha)
+		os.RemoveAll(dir)
+
+		// then
+		if err != nil {
+			t.Fatalf("error must not occur, but got %+v", err)
+		}
+		info, err := os.Stat(stamp)
+		if err != nil {
+			t.Fatalf("error occurred: %+v", err)
+		}
+		if !info.ModTime().Equal(now.Add(tt.expected)) {
+			t.Errorf("last gc after %s must be %s, but got %s", tt.after, now.Add(tt.expected), info.ModTime())
+		}
+	}
+}
+
+func TestGitService_Update(t *testing.T) {
+	// setup
+	upstream := newUpstream(t)
+	defer upstream.Close()
+
+	server := upstream.serveHTTP(t, "token")
+	defer server.Close()
+
+	reset := *application.Config.GitHub
+	resetServer := *application.Config.Server
+	defer func() {
+		*application.Config.GitHub = reset
+		*application.Config.Server = resetServer
+	}()
+	application.Config.GitHub.Clone = git.HTTPS
+	application.Config.Server.WorkDir = tempDir(t)
+	defer os.RemoveAll(application.Config.Server.WorkDir)
+
+	// and
+	sha := upstream.addSubmodule(t, fmt.Sprintf("%s/sub.git", server.URL))
+	repo := &model.Repository{
+		FullName: "duck8823/duci",
+		CloneURL: fmt.Sprintf("%s/%s", server.URL, upstream.name),
+	}
+
+	sut, err := git.New("/path/to/nothing", &MockTokenSource{token: "token"})
+	if err != nil {
+		t.Fatalf("error occurred: %+v", err)
+	}
+	ctx := context.New("test/task", uuid.New(), &url.URL{})
+
+	t.Run("with submodules", func(t *testing.T) {
+		// given
+		dir := tempDir(t)
+		defer os.RemoveAll(dir)
+
+		if err := sut.Clone(ctx, dir, repo, "refs/heads/master", sha); err != nil {
+			t.Fatalf("error occurred: %+v", err)
+		}
+
+		// when
+		err := sut.Update(ctx, dir, repo, git.Options{Submodules: true})
+
+		// then
+		if err != nil {
+			t.Fatalf("error must not occur, but got %+v", err)
+		}
+		if _, err := os.Stat(path.Join(dir, "sub", "README")); err != nil {
+			t.Errorf("submodule must be checked out, but got %+v", err)
+		}
+	})
+
+	t.Run("with relative url of submodule", func(t *testing.T) {
+		// given
+		sha := upstream.setSubmoduleURL(t, "../sub.git")
+
+		dir := tempDir(t)
+		defer os.RemoveAll(dir)
+
+		if err := sut.Clone(ctx, dir, repo, "refs/heads/master", sha); err != nil {
+			t.Fatalf("error occurred: %+v", err)
+		}
+
+		// when
+		err := sut.Update(ctx, dir, repo, git.Options{Submodules: true})
+
+		// then
+		if err != nil {
+			t.Fatalf("error must not occur, but got %+v", err)
+		}
+		if _, err := os.Stat(path.Join(dir, "sub", "README")); err != nil {
+			t.Errorf("submodule must be checked out, but got %+v", err)
+		}
+	})
+
+	t.Run("without options", func(t *testing.T) {
+		// given
+		dir := tempDir(t)
+		defer os.RemoveAll(dir)
+
+		if err := sut.Clone(ctx, dir, repo, "refs/heads/master", sha); err != nil {
+			t.Fatalf("error occurred: %+v", err)
+		}
+
+		// when
+		err := sut.Update(ctx, dir, repo, git.Options{})
+
+		// then
+		if err != nil {
+			t.Fatalf("error must not occur, but got %+v", err)
+		}
+		if _, err := os.Stat(path.Join(dir, "sub", "README")); !os.IsNotExist(err) {
+			t.Errorf("submodule must not be checked out, but got %+v", err)
+		}
+	})
+
+	t.Run("with lfs when git-lfs is not installed", func(t *testing.T) {
+		if _, err := exec.LookPath("git-lfs"); err == nil {
+			t.Skip("git-lfs is installed")
+		}
+
+		// given
+		dir := tempDir(t)
+		defer os.RemoveAll(dir)
+
+		if err := sut.Clone(ctx, dir, repo, "refs/heads/master", sha); err != nil {
+			t.Fatalf("error occurred: %+v", err)
+		}
+
+		// when
+		err := sut.Update(ctx, dir, repo, git.Options{LFS: true})
+
+		// then
+		if err == nil || !strings.Contains(err.Error(), "git-lfs is not installed") {
+			t.Errorf("error must tell git-lfs is not installed, but got %+v", err)
+		}
+	})
+}
+
+func TestGitService_Update_LFS_SSH(t *testing.T) {
+	// setup
+	reset := *application.Config.GitHub
+	defer func() {
+		*application.Config.GitHub = reset
+	}()
+	application.Config.GitHub.Clone = git.SSH
+
+	keyDir := tempDir(t)
+	defer os.RemoveAll(keyDir)
+
+	// and
+	knownHosts := filepath.Join(keyDir, "known_hosts")
+	if err := ioutil.WriteFile(knownHosts, []byte{}, 0600); err != nil {
+		t.Fatalf("error occurred: %+v", err)
+	}
+	application.Config.GitHub.KnownHostsPath = knownHosts
+	application.Config.GitHub.SSHKeyPassphrase = "passphrase"
+
+	sut, err := git.New(writeEncryptedKey(t, keyDir, generateKey(t), "passphrase"), &MockTokenSource{})
+	if err != nil {
+		t.Fatalf("error occurred: %+v", err)
+	}
+	repo := &model.Repository{FullName: "duck8823/duci", SSHURL: "git@github.com:duck8823/duci.git"}
+
+	// when
+	err = sut.Update(context.New("test/task", uuid.New(), &url.URL{}), keyDir, repo, git.Options{LFS: true})
+
+	// then
+	if err == nil || !strings.Contains(err.Error(), "without passphrase") {
+		t.Errorf("error must tell the key is encrypted, but got %+v", err)
+	}
+}
+
+func TestGitService_Clone_PullRequest(t *testing.T) {
+	// setup
+	upstream := newUpstream(t)
+	defer upstream.Close()
+
+	server := upstream.serveHTTP(t, "")
+	defer server.Close()
+
+	reset := *application.Config.GitHub
+	resetServer := *application.Config.Server
+	defer func() {
+		*application.Config.GitHub = reset
+		*application.Config.Server = resetServer
+	}()
+	application.Config.GitHub.Clone = git.HTTPS
+	application.Config.Server.WorkDir = tempDir(t)
+	defer os.RemoveAll(application.Config.Server.WorkDir)
+
+	// and
+	head, merge := upstream.pullRequest(t, 1)
+	repo := &model.Repository{
+		FullName: "duck8823/duci",
+		CloneURL: fmt.Sprintf("%s/%s", server.URL, upstream.name),
+	}
+
+	sut, err := git.New("/path/to/nothing", &MockTokenSource{})
+	if err != nil {
+		t.Fatalf("error occurred: %+v", err)
+	}
+	ctx := context.New("test/task", uuid.New(), &url.URL{})
+
+	for _, tt := range []struct {
+		name     string
+		ref      string
+		sha      plumbing.Hash
+		expected plumbing.Hash
+		err      bool
+	}{
+		{name: "with head ref", ref: "refs/pull/1/head", sha: head, expected: head},
+		{name: "with merge ref", ref: "refs/pull/1/merge", sha: head, expected: merge},
+		{name: "with merge ref of another head", ref: "refs/pull/1/merge", sha: upstream.head, err: true},
+		{name: "with merge ref not exists", ref: "refs/pull/2/merge", sha: head, err: true},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			// given
+			dir := tempDir(t)
+			defer os.RemoveAll(dir)
+
+			// when
+			err := sut.Clone(ctx, dir, repo, tt.ref, tt.sha)
+
+			// then
+			if tt.err {
+				if err == nil {
+					t.Error("error must occur, but got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("error must not occur, but got %+v", err)
+			}
+			if actual := command(t, "-C", dir, "rev-parse", "HEAD"); actual != tt.expected.String() {
+				t.Errorf("must checkout %s, but got %s", tt.expected, actual)
+			}
+		})
+	}
+}
+
+func TestGitService_Clone_SSH(t *testing.T) {
+	// setup
+	upstream := newUpstream(t)
+	defer upstream.Close()
+
+	reset := *application.Config.GitHub
+	resetServer := *application.Config.Server
+	resetRepositories := application.Config.Repositories
+	defer func() {
+		*application.Config.GitHub = reset
+		*application.Config.Server = resetServer
+		application.Config.Repositories = resetRepositories
+	}()
+	application.Config.GitHub.Clone = git.SSH
+
+	// and
+	key := generateKey(t)
+	publicKey, err := ssh.NewPublicKey(&key.PublicKey)
+	if err != nil {
+		t.Fatalf("error occurred: %+v", err)
+	}
+	keyPath := writeKey(t, upstream.root, key)
+	encryptedKeyPath := writeEncryptedKey(t, upstream.root, key, "passphrase")
+	application.Config.GitHub.SSHKeyPassphrase = "passphrase"
+
+	keyDir := tempDir(t)
+	defer os.RemoveAll(keyDir)
+	otherKeyPath := writeKey(t, keyDir, generateKey(t))
+	otherEncryptedKeyPath := writeEncryptedKey(t, keyDir, key, "other")
+
+	addr, knownHosts := upstream.serveSSH(t, publicKey)
+	otherUpstream := newUpstream(t)
+	defer otherUpstream.Close()
+	_, otherKnownHosts := otherUpstream.serveSSH(t, publicKey)
+
+	// and
+	repo := &model.Repository{
+		FullName: "duck8823/duci",
+		SSHURL:   fmt.Sprintf("ssh://git@%s/%s", addr, upstream.name),
+	}
+
+	for _, tt := range []struct {
+		name       string
+		keyPath    string
+		knownHosts string
+		deployKey  string
+		newErr     bool
+		cloneErr   bool
+	}{
+		{name: "with known_hosts", keyPath: keyPath, knownHosts: knownHosts},
+		{name: "with known_hosts of another host", keyPath: keyPath, knownHosts: otherKnownHosts, cloneErr: true},
+		{name: "with missing known_hosts", keyPath: keyPath, knownHosts: "/path/to/nothing", newErr: true},
+		{name: "with passphrase", keyPath: encryptedKeyPath, knownHosts: knownHosts},
+		{name: "with wrong passphrase", keyPath: otherEncryptedKeyPath, knownHosts: knownHosts, newErr: true},
+		{name: "with deploy key", keyPath: otherKeyPath, knownHosts: knownHosts, deployKey: keyPath},
+		{name: "without deploy key", keyPath: otherKeyPath, knownHosts: knownHosts, cloneErr: true},
+		{name: "with missing deploy key", keyPath: keyPath, knownHosts: knownHosts, deployKey: "/path/to/nothing", newErr: true},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			// given
+			application.Config.GitHub.KnownHostsPath = tt.knownHosts
+			application.Config.Repositories = map[string]*application.Repository{
+				repo.FullName: {SSHKeyPath: tt.deployKey},
+			}
+			application.Config.Server.WorkDir = tempDir(t)
+			defer os.RemoveAll(application.Config.Server.WorkDir)
+
+			// when
+			sut, err := git.New(tt.keyPath, &MockTokenSource{})
+
+			// then
+			if tt.newErr {
+				if err == nil {
+					t.Error("error must occur, but got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("error must not occur, but got %+v", err)
+			}
+
+			// and
+			dir := tempDir(t)
+			defer os.RemoveAll(dir)
+
+			// when
+			err = sut.Clone(context.New("test/task", uuid.New(), &url.URL{}), dir, repo, "refs/heads/master", upstream.head)
+
+			// then
+			if tt.cloneErr {
+				if err == nil {
+					t.Error("error must occur, but got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Errorf("error must not occur, but got %+v", err)
+			}
+		})
+	}
+}
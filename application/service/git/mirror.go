@@ -0,0 +1,283 @@
+package git
+
+import (
+	"fmt"
+	"github.com/duck8823/duci/application"
+	"github.com/duck8823/duci/application/context"
+	"github.com/duck8823/duci/application/service/github"
+	"github.com/duck8823/duci/infrastructure/clock"
+	"github.com/duck8823/duci/infrastructure/logger"
+	"github.com/pkg/errors"
+	"gopkg.in/src-d/go-git.v4"
+	"gopkg.in/src-d/go-git.v4/config"
+	"gopkg.in/src-d/go-git.v4/plumbing"
+	"gopkg.in/src-d/go-git.v4/plumbing/filemode"
+	"gopkg.in/src-d/go-git.v4/plumbing/object"
+	"gopkg.in/src-d/go-git.v4/plumbing/storer"
+	"gopkg.in/src-d/go-git.v4/plumbing/transport"
+	"io"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"path"
+	"regexp"
+	"sync"
+	"time"
+)
+
+const gcStamp = "duci_gc"
+
+// mergeRef matches the ref of merge commit, which GitHub creates for each pull request.
+var mergeRef = regexp.MustCompile(`^refs/pull/\d+/merge$`)
+
+// mirrors keeps bare mirrors of repositories, and locks each of them.
+type mirrors struct {
+	mu    sync.Mutex
+	locks map[string]*sync.Mutex
+}
+
+// lock locks the mirror, and returns the function to unlock.
+func (m *mirrors) lock(dir string) func() {
+	m.mu.Lock()
+	if m.locks == nil {
+		m.locks = make(map[string]*sync.Mutex)
+	}
+	lock, ok := m.locks[dir]
+	if !ok {
+		lock = &sync.Mutex{}
+		m.locks[dir] = lock
+	}
+	m.mu.Unlock()
+
+	lock.Lock()
+	return lock.Unlock
+}
+
+// mirrorDir returns the directory of mirror for the repository, separated by host.
+func mirrorDir(repo github.Repository) string {
+	host := ""
+	if u, err := url.Parse(repo.GetHTMLURL()); err == nil {
+		host = u.Host
+	}
+	return path.Join(application.Config.MirrorDir(), host, fmt.Sprintf("%s.git", repo.GetFullName()))
+}
+
+// fetch fetches the ref into the mirror unless it has the commit already.
+// It returns the commit, which is head of the ref if the hash is zero.
+func fetch(ctx context.Context, dir string, remoteURL string, auth transport.AuthMethod, ref string, sha plumbing.Hash) (plumbing.Hash, error) {
+	mirror, err := openMirror(dir, remoteURL)
+	if err != nil {
+		return plumbing.ZeroHash, errors.WithStack(err)
+	}
+
+	if !sha.IsZero() {
+		if _, err := mirror.CommitObject(sha); err == nil {
+			logger.Debugf(ctx.UUID(), "%s is already in mirror", sha)
+			return sha, nil
+		}
+	}
+
+	if err := mirror.Fetch(&git.FetchOptions{
+		RemoteName: git.DefaultRemoteName,
+		RefSpecs:   []config.RefSpec{config.RefSpec(fmt.Sprintf("+%s:%s", ref, ref))},
+		Auth:       auth,
+		Progress:   &ProgressLogger{ctx.UUID()},
+	}); err != nil && err != git.NoErrAlreadyUpToDate {
+		return plumbing.ZeroHash, errors.WithStack(err)
+	}
+
+	if sha.IsZero() {
+		head, err := mirror.Reference(plumbing.ReferenceName(ref), true)
+		if err != nil {
+			return plumbing.ZeroHash, errors.WithStack(err)
+		}
+		sha = head.Hash()
+	}
+	if _, err := mirror.CommitObject(sha); err != nil {
+		return plumbing.ZeroHash, errors.Wrapf(err, "commit %s not found in %s", sha, ref)
+	}
+
+	if err := gc(ctx, dir, mirror); err != nil {
+		logger.Errorf(ctx.UUID(), "Failed to gc mirror %s: %+v", dir, err)
+	}
+	return sha, nil
+}
+
+// fetchMerge fetches the merge ref always, because GitHub recreates it when the base branch is updated.
+// It returns the merge commit, after verifying it merges the head commit.
+func fetchMerge(ctx context.Context, dir string, remoteURL string, auth transport.AuthMethod, ref string, head plumbing.Hash) (plumbing.Hash, error) {
+	merge, err := fetch(ctx, dir, remoteURL, auth, ref, plumbing.ZeroHash)
+	if err != nil {
+		return plumbing.ZeroHash, errors.Wrapf(err, "failed to fetch %s, the pull request may not be mergeable", ref)
+	}
+	if head.IsZero() {
+		return merge, nil
+	}
+
+	mirror, err := git.PlainOpen(dir)
+	if err != nil {
+		return plumbing.ZeroHash, errors.WithStack(err)
+	}
+	commit, err := mirror.CommitObject(merge)
+	if err != nil {
+		return plumbing.ZeroHash, errors.WithStack(err)
+	}
+	// parents of the merge commit are base and head
+	if len(commit.ParentHashes) == 2 && commit.ParentHashes[1] == head {
+		return merge, nil
+	}
+	return plumbing.ZeroHash, errors.Errorf("%s is not a merge commit of %s, the pull request may not be mergeable or be updated", ref, head)
+}
+
+func openMirror(dir string, remoteURL string) (*git.Repository, error) {
+	mirror, err := git.PlainOpen(dir)
+	if err == git.ErrRepositoryNotExists {
+		mirror, err = git.PlainInit(dir, true)
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+		if _, err := mirror.CreateRemote(&config.RemoteConfig{
+			Name: git.DefaultRemoteName,
+			URLs: []string{remoteURL},
+		}); err != nil {
+			return nil, errors.WithStack(err)
+		}
+		return mirror, nil
+	} else if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	// url changes with the protocol to clone
+	remote, err := mirror.Remote(git.DefaultRemoteName)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	if urls := remote.Config().URLs; len(urls) != 1 || urls[0] != remoteURL {
+		if err := mirror.DeleteRemote(git.DefaultRemoteName); err != nil {
+			return nil, errors.WithStack(err)
+		}
+		if _, err := mirror.CreateRemote(&config.RemoteConfig{
+			Name: git.DefaultRemoteName,
+			URLs: []string{remoteURL},
+		}); err != nil {
+			return nil, errors.WithStack(err)
+		}
+	}
+	return mirror, nil
+}
+
+// gc prunes unreachable objects and repacks the mirror, if the interval has passed since last time.
+func gc(ctx context.Context, dir string, mirror *git.Repository) error {
+	interval := time.Duration(application.Config.Server.GCInterval) * time.Second
+	if interval <= 0 {
+		return nil
+	}
+
+	stamp := path.Join(dir, gcStamp)
+	info, err := os.Stat(stamp)
+	if err == nil && clock.Now().Sub(info.ModTime()) < interval {
+		return nil
+	} else if err != nil && !os.IsNotExist(err) {
+		return errors.WithStack(err)
+	}
+
+	logger.Infof(ctx.UUID(), "gc mirror %s", dir)
+	if err := mirror.Prune(git.PruneOptions{Handler: mirror.DeleteObject}); err != nil {
+		return errors.WithStack(err)
+	}
+	if err := mirror.RepackObjects(&git.RepackConfig{}); err != nil {
+		return errors.WithStack(err)
+	}
+
+	if err := ioutil.WriteFile(stamp, nil, 0600); err != nil {
+		return errors.WithStack(err)
+	}
+	now := clock.Now()
+	return errors.WithStack(os.Chtimes(stamp, now, now))
+}
+
+// checkout creates the worktree of the commit, with objects copied from the mirror.
+// It is self-contained as shallow clone of depth 1, not to refer the mirror from containers or after gc.
+func checkout(dir string, mirror string, sha plumbing.Hash) error {
+	source, err := git.PlainOpen(mirror)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	repository, err := git.PlainInit(dir, false)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	if err := copyObjects(source.Storer, repository.Storer, sha); err != nil {
+		return errors.WithStack(err)
+	}
+	if err := repository.Storer.SetShallow([]plumbing.Hash{sha}); err != nil {
+		return errors.WithStack(err)
+	}
+
+	wt, err := repository.Worktree()
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	if err := wt.Checkout(&git.CheckoutOptions{
+		Hash:   sha,
+		Branch: plumbing.ReferenceName(sha.String()),
+		Create: true,
+	}); err != nil {
+		return errors.WithStack(err)
+	}
+	return nil
+}
+
+// copyObjects copies the commit and objects of its tree.
+// Commits of submodules are not in the repository, then they are fetched by update.
+func copyObjects(src storer.EncodedObjectStorer, dst storer.EncodedObjectStorer, sha plumbing.Hash) error {
+	commit, err := object.GetCommit(src, sha)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	for _, hash := range []plumbing.Hash{commit.Hash, commit.TreeHash} {
+		if err := copyObject(src, dst, hash); err != nil {
+			return errors.WithStack(err)
+		}
+	}
+
+	tree, err := commit.Tree()
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	walker := object.NewTreeWalker(tree, true, nil)
+	defer walker.Close()
+
+	// the same blobs and trees appear in many places of large trees
+	seen := map[plumbing.Hash]struct{}{}
+	for {
+		_, entry, err := walker.Next()
+		if err == io.EOF {
+			return nil
+		} else if err != nil {
+			return errors.WithStack(err)
+		}
+		if _, ok := seen[entry.Hash]; ok || entry.Mode == filemode.Submodule {
+			continue
+		}
+		seen[entry.Hash] = struct{}{}
+		if err := copyObject(src, dst, entry.Hash); err != nil {
+			return errors.WithStack(err)
+		}
+	}
+}
+
+// copyObject copies the object as it is encoded.
+func copyObject(src storer.EncodedObjectStorer, dst storer.EncodedObjectStorer, hash plumbing.Hash) error {
+	obj, err := src.EncodedObject(plumbing.AnyObject, hash)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	if _, err := dst.SetEncodedObject(obj); err != nil {
+		return errors.WithStack(err)
+	}
+	return nil
+}
@@ -6,6 +6,8 @@ package mock_git
 
 import (
 	context "github.com/duck8823/duci/application/context"
+	git "github.com/duck8823/duci/application/service/git"
+	github "github.com/duck8823/duci/application/service/github"
 	gomock "github.com/golang/mock/gomock"
 	plumbing "gopkg.in/src-d/go-git.v4/plumbing"
 	reflect "reflect"
@@ -35,13 +37,61 @@ func (m *MockService) EXPECT() *MockServiceMockRecorder {
 }
 
 // Clone mocks base method
-func (m *MockService) Clone(ctx context.Context, dir, sshUrl, ref string, sha plumbing.Hash) error {
-	ret := m.ctrl.Call(m, "Clone", ctx, dir, sshUrl, ref, sha)
+func (m *MockService) Clone(ctx context.Context, dir string, repo github.Repository, ref string, sha plumbing.Hash) error {
+	ret := m.ctrl.Call(m, "Clone", ctx, dir, repo, ref, sha)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // Clone indicates an expected call of Clone
-func (mr *MockServiceMockRecorder) Clone(ctx, dir, sshUrl, ref, sha interface{}) *gomock.Call {
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Clone", reflect.TypeOf((*MockService)(nil).Clone), ctx, dir, sshUrl, ref, sha)
+func (mr *MockServiceMockRecorder) Clone(ctx, dir, repo, ref, sha interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Clone", reflect.TypeOf((*MockService)(nil).Clone), ctx, dir, repo, ref, sha)
+}
+
+// Update mocks base method
+func (m *MockService) Update(ctx context.Context, dir string, repo github.Repository, opts git.Options) error {
+	ret := m.ctrl.Call(m, "Update", ctx, dir, repo, opts)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Update indicates an expected call of Update
+func (mr *MockServiceMockRecorder) Update(ctx, dir, repo, opts interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Update", reflect.TypeOf((*MockService)(nil).Update), ctx, dir, repo, opts)
+}
+
+// MockTokenSource is a mock of TokenSource interface
+type MockTokenSource struct {
+	ctrl     *gomock.Controller
+	recorder *MockTokenSourceMockRecorder
+}
+
+// MockTokenSourceMockRecorder is the mock recorder for MockTokenSource
+type MockTokenSourceMockRecorder struct {
+	mock *MockTokenSource
+}
+
+// NewMockTokenSource creates a new mock instance
+func NewMockTokenSource(ctrl *gomock.Controller) *MockTokenSource {
+	mock := &MockTokenSource{ctrl: ctrl}
+	mock.recorder = &MockTokenSourceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use
+func (m *MockTokenSource) EXPECT() *MockTokenSourceMockRecorder {
+	return m.recorder
+}
+
+// Token mocks base method
+func (m *MockTokenSource) Token(ctx context.Context, repo github.Repository) (string, error) {
+	ret := m.ctrl.Call(m, "Token", ctx, repo)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Token indicates an expected call of Token
+func (mr *MockTokenSourceMockRecorder) Token(ctx, repo interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Token", reflect.TypeOf((*MockTokenSource)(nil).Token), ctx, repo)
 }
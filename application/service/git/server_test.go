@@ -0,0 +1,314 @@
+package git_test
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/knownhosts"
+	"gopkg.in/src-d/go-git.v4/plumbing"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/cgi"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// upstream is a bare repository served by local git servers.
+type upstream struct {
+	root      string
+	name      string
+	head      plumbing.Hash
+	listeners []net.Listener
+}
+
+// newUpstream creates a bare repository with a commit on master, using git command.
+func newUpstream(t *testing.T) *upstream {
+	t.Helper()
+
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git command is required to serve repositories")
+	}
+
+	root, err := ioutil.TempDir("", "duci_git_server")
+	if err != nil {
+		t.Fatalf("error occurred: %+v", err)
+	}
+
+	work := filepath.Join(root, "work")
+	for _, args := range [][]string{
+		{"init", "-q", work},
+		{"-C", work, "checkout", "-q", "-b", "master"},
+		{"-C", work, "-c", "user.name=duci", "-c", "user.email=duci@example.com", "commit", "-q", "--allow-empty", "-m", "init"},
+		{"clone", "-q", "--bare", work, filepath.Join(root, "repo.git")},
+	} {
+		command(t, args...)
+	}
+
+	return &upstream{root: root, name: "repo.git", head: plumbing.NewHash(command(t, "-C", work, "rev-parse", "HEAD"))}
+}
+
+// commit pushes a new commit on master, and returns its hash.
+func (u *upstream) commit(t *testing.T) plumbing.Hash {
+	t.Helper()
+
+	work := filepath.Join(u.root, "work")
+	command(t, "-C", work, "-c", "user.name=duci", "-c", "user.email=duci@example.com", "commit", "-q", "--allow-empty", "-m", "next")
+	command(t, "-C", work, "push", "-q", filepath.Join(u.root, u.name), "master")
+	u.head = plumbing.NewHash(command(t, "-C", work, "rev-parse", "HEAD"))
+	return u.head
+}
+
+// addSubmodule pushes a commit adding a submodule at `sub` with the url, and returns its hash.
+// The submodule has README file.
+func (u *upstream) addSubmodule(t *testing.T, url string) plumbing.Hash {
+	t.Helper()
+
+	sub := filepath.Join(u.root, "sub")
+	command(t, "init", "-q", sub)
+	command(t, "-C", sub, "checkout", "-q", "-b", "master")
+	if err := ioutil.WriteFile(filepath.Join(sub, "README"), []byte("submodule"), 0600); err != nil {
+		t.Fatalf("error occurred: %+v", err)
+	}
+	command(t, "-C", sub, "add", "README")
+	command(t, "-C", sub, "-c", "user.name=duci", "-c", "user.email=duci@example.com", "commit", "-q", "-m", "init")
+	command(t, "clone", "-q", "--bare", sub, filepath.Join(u.root, "sub.git"))
+
+	work := filepath.Join(u.root, "work")
+	command(t, "-C", work, "-c", "protocol.file.allow=always", "submodule", "add", "-q", filepath.Join(u.root, "sub.git"), "sub")
+	command(t, "-C", work, "config", "-f", ".gitmodules", "submodule.sub.url", url)
+	command(t, "-C", work, "add", ".gitmodules")
+	command(t, "-C", work, "-c", "user.name=duci", "-c", "user.email=duci@example.com", "commit", "-q", "-m", "add submodule")
+	command(t, "-C", work, "push", "-q", filepath.Join(u.root, u.name), "master")
+	u.head = plumbing.NewHash(command(t, "-C", work, "rev-parse", "HEAD"))
+	return u.head
+}
+
+// setSubmoduleURL pushes a commit changing the url of the submodule at `sub`, and returns its hash.
+func (u *upstream) setSubmoduleURL(t *testing.T, url string) plumbing.Hash {
+	t.Helper()
+
+	work := filepath.Join(u.root, "work")
+	command(t, "-C", work, "config", "-f", ".gitmodules", "submodule.sub.url", url)
+	command(t, "-C", work, "add", ".gitmodules")
+	command(t, "-C", work, "-c", "user.name=duci", "-c", "user.email=duci@example.com", "commit", "-q", "-m", "change url of submodule")
+	command(t, "-C", work, "push", "-q", filepath.Join(u.root, u.name), "master")
+	u.head = plumbing.NewHash(command(t, "-C", work, "rev-parse", "HEAD"))
+	return u.head
+}
+
+// pullRequest pushes refs/pull/<n>/head of a new branch and refs/pull/<n>/merge of it into master,
+// as GitHub does, and returns their hashes.
+func (u *upstream) pullRequest(t *testing.T, number int) (plumbing.Hash, plumbing.Hash) {
+	t.Helper()
+
+	work := filepath.Join(u.root, "work")
+	branch := fmt.Sprintf("pr-%d", number)
+	command(t, "-C", work, "checkout", "-q", "-b", branch, "master")
+	command(t, "-C", work, "-c", "user.name=duci", "-c", "user.email=duci@example.com", "commit", "-q", "--allow-empty", "-m", branch)
+	head := command(t, "-C", work, "rev-parse", "HEAD")
+
+	command(t, "-C", work, "checkout", "-q", "--detach", "master")
+	command(t, "-C", work, "-c", "user.name=duci", "-c", "user.email=duci@example.com", "merge", "-q", "--no-ff", "-m", "merge", branch)
+	merge := command(t, "-C", work, "rev-parse", "HEAD")
+	command(t, "-C", work, "checkout", "-q", "master")
+
+	command(t, "-C", work, "push", "-q", filepath.Join(u.root, u.name),
+		fmt.Sprintf("%s:refs/pull/%d/head", head, number),
+		fmt.Sprintf("%s:refs/pull/%d/merge", merge, number),
+	)
+	return plumbing.NewHash(head), plumbing.NewHash(merge)
+}
+
+func (u *upstream) Close() {
+	for _, listener := range u.listeners {
+		listener.Close()
+	}
+	os.RemoveAll(u.root)
+}
+
+// serveHTTP serves the repository over smart http, requiring basic auth with the token.
+func (u *upstream) serveHTTP(t *testing.T, token string) *httptest.Server {
+	t.Helper()
+
+	backend := &cgi.Handler{
+		Path: filepath.Join(command(t, "--exec-path"), "git-http-backend"),
+		Env:  []string{fmt.Sprintf("GIT_PROJECT_ROOT=%s", u.root), "GIT_HTTP_EXPORT_ALL=1"},
+	}
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if _, password, _ := r.BasicAuth(); password != token {
+			w.Header().Set("WWW-Authenticate", `Basic realm="git"`)
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		backend.ServeHTTP(w, r)
+	}))
+}
+
+// serveSSH serves the repository over ssh, authorizing the key.
+// It returns address of the server and known_hosts file of its host key.
+func (u *upstream) serveSSH(t *testing.T, authorized ssh.PublicKey) (string, string) {
+	t.Helper()
+
+	hostKey, err := ssh.NewSignerFromKey(generateKey(t))
+	if err != nil {
+		t.Fatalf("error occurred: %+v", err)
+	}
+	config := &ssh.ServerConfig{
+		PublicKeyCallback: func(_ ssh.ConnMetadata, key ssh.PublicKey) (*ssh.Permissions, error) {
+			if string(key.Marshal()) != string(authorized.Marshal()) {
+				return nil, fmt.Errorf("unauthorized key")
+			}
+			return nil, nil
+		},
+	}
+	config.AddHostKey(hostKey)
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("error occurred: %+v", err)
+	}
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go u.handleSSH(conn, config)
+		}
+	}()
+	u.listeners = append(u.listeners, listener)
+
+	addr := listener.Addr().String()
+	knownHosts := filepath.Join(u.root, "known_hosts")
+	line := knownhosts.Line([]string{knownhosts.Normalize(addr)}, hostKey.PublicKey())
+	if err := ioutil.WriteFile(knownHosts, []byte(line+"\n"), 0600); err != nil {
+		t.Fatalf("error occurred: %+v", err)
+	}
+	return addr, knownHosts
+}
+
+func (u *upstream) handleSSH(conn net.Conn, config *ssh.ServerConfig) {
+	_, chans, reqs, err := ssh.NewServerConn(conn, config)
+	if err != nil {
+		return
+	}
+	go ssh.DiscardRequests(reqs)
+
+	for newChannel := range chans {
+		if newChannel.ChannelType() != "session" {
+			newChannel.Reject(ssh.UnknownChannelType, "unknown channel type")
+			continue
+		}
+		channel, requests, err := newChannel.Accept()
+		if err != nil {
+			return
+		}
+		go func() {
+			for req := range requests {
+				if req.Type != "exec" {
+					req.Reply(false, nil)
+					continue
+				}
+				req.Reply(true, nil)
+				u.exec(channel, string(req.Payload[4:]))
+				return
+			}
+		}()
+	}
+}
+
+// exec runs git-upload-pack for the repository in the command, like `git-upload-pack '/repo.git'`.
+func (u *upstream) exec(channel ssh.Channel, command string) {
+	defer channel.Close()
+
+	args := strings.SplitN(command, " ", 2)
+	if len(args) != 2 || args[0] != "git-upload-pack" {
+		channel.SendRequest("exit-status", false, ssh.Marshal(struct{ Status uint32 }{1}))
+		return
+	}
+	dir := filepath.Join(u.root, strings.Trim(args[1], "'/"))
+
+	cmd := exec.Command("git-upload-pack", dir)
+	cmd.Stdout = channel
+	cmd.Stderr = channel.Stderr()
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return
+	}
+	go func() {
+		io.Copy(stdin, channel)
+		stdin.Close()
+	}()
+
+	status := uint32(0)
+	if err := cmd.Run(); err != nil {
+		status = 1
+	}
+	channel.SendRequest("exit-status", false, ssh.Marshal(struct{ Status uint32 }{status}))
+}
+
+func command(t *testing.T, args ...string) string {
+	t.Helper()
+
+	out, err := exec.Command("git", args...).CombinedOutput()
+	if err != nil {
+		t.Fatalf("failed to git %s: %s", strings.Join(args, " "), out)
+	}
+	return strings.TrimSpace(string(out))
+}
+
+func generateKey(t *testing.T) *rsa.PrivateKey {
+	t.Helper()
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("error occurred: %+v", err)
+	}
+	return key
+}
+
+// writeKey writes the private key in PEM format, and returns path of the file.
+func writeKey(t *testing.T, dir string, key *rsa.PrivateKey) string {
+	t.Helper()
+
+	keyPath := filepath.Join(dir, "id_rsa")
+	data := pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)})
+	if err := ioutil.WriteFile(keyPath, data, 0600); err != nil {
+		t.Fatalf("error occurred: %+v", err)
+	}
+	return keyPath
+}
+
+// writeEncryptedKey writes the private key encrypted with the passphrase, and returns path of the file.
+func writeEncryptedKey(t *testing.T, dir string, key *rsa.PrivateKey, passphrase string) string {
+	t.Helper()
+
+	block, err := x509.EncryptPEMBlock(rand.Reader, "RSA PRIVATE KEY", x509.MarshalPKCS1PrivateKey(key), []byte(passphrase), x509.PEMCipherAES256)
+	if err != nil {
+		t.Fatalf("error occurred: %+v", err)
+	}
+	keyPath := filepath.Join(dir, "id_rsa_encrypted")
+	if err := ioutil.WriteFile(keyPath, pem.EncodeToMemory(block), 0600); err != nil {
+		t.Fatalf("error occurred: %+v", err)
+	}
+	return keyPath
+}
+
+func tempDir(t *testing.T) string {
+	t.Helper()
+
+	dir, err := ioutil.TempDir("", "duci_test")
+	if err != nil {
+		t.Fatalf("error occurred: %+v", err)
+	}
+	return dir
+}
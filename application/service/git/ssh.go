@@ -0,0 +1,51 @@
+package git
+
+import (
+	"github.com/duck8823/duci/application/service/github"
+	"github.com/pkg/errors"
+	gossh "golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/knownhosts"
+	"gopkg.in/src-d/go-git.v4/plumbing/transport"
+	"gopkg.in/src-d/go-git.v4/plumbing/transport/ssh"
+)
+
+// sshKey is a private key to clone over ssh, or the error occurred on loading it.
+type sshKey struct {
+	path      string
+	encrypted bool
+	auth      transport.AuthMethod
+	err       error
+}
+
+// newSSHKey loads the private key, which verifies host keys with the callback.
+func newSSHKey(path string, passphrase string, callback gossh.HostKeyCallback, callbackErr error) *sshKey {
+	if callbackErr != nil {
+		return &sshKey{path: path, err: errors.Wrap(callbackErr, "could not read known_hosts")}
+	}
+
+	auth, err := ssh.NewPublicKeysFromFile("git", path, passphrase)
+	if err != nil {
+		return &sshKey{path: path, err: errors.WithStack(err)}
+	}
+	auth.HostKeyCallback = callback
+	return &sshKey{path: path, encrypted: len(passphrase) > 0, auth: auth}
+}
+
+// hostKeyCallback returns the callback rejecting hosts which are not in the known_hosts file.
+// Without the file, it reads SSH_KNOWN_HOSTS or $HOME/.ssh/known_hosts as go-git does.
+func hostKeyCallback(knownHostsPath string) (gossh.HostKeyCallback, error) {
+	if len(knownHostsPath) == 0 {
+		callback, err := ssh.NewKnownHostsCallback()
+		return callback, errors.WithStack(err)
+	}
+	callback, err := knownhosts.New(knownHostsPath)
+	return callback, errors.WithStack(err)
+}
+
+// sshKeyOf returns the deploy key of the repository if configured, or the ssh key of server.
+func (s *gitService) sshKeyOf(repo github.Repository) *sshKey {
+	if key, ok := s.deployKeys[repo.GetFullName()]; ok {
+		return key
+	}
+	return s.sshKey
+}
@@ -0,0 +1,177 @@
+package git
+
+import (
+	"encoding/base64"
+	"fmt"
+	"github.com/duck8823/duci/application"
+	"github.com/duck8823/duci/application/context"
+	"github.com/duck8823/duci/application/service/github"
+	"github.com/duck8823/duci/infrastructure/logger"
+	"github.com/pkg/errors"
+	"gopkg.in/src-d/go-git.v4"
+	"gopkg.in/src-d/go-git.v4/plumbing/transport"
+	"gopkg.in/src-d/go-git.v4/plumbing/transport/http"
+	"gopkg.in/src-d/go-git.v4/plumbing/transport/ssh"
+	neturl "net/url"
+	"os"
+	"os/exec"
+	"path"
+	"strings"
+)
+
+// Update initializes submodules recursively and fetches lfs objects of the commit checked out, as the options.
+// Submodules are fetched with the same credentials as the repository.
+func (s *gitService) Update(ctx context.Context, dir string, repo github.Repository, opts Options) error {
+	if opts.Submodules {
+		if err := s.updateSubmodules(ctx, dir, repo); err != nil {
+			return errors.Wrap(err, "failed to update submodules")
+		}
+	}
+
+	if opts.LFS {
+		url, auth, err := s.remote(ctx, repo)
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		if err := pullLFS(ctx, dir, url, auth, s.sshKeyOf(repo)); err != nil {
+			return errors.Wrap(err, "failed to fetch lfs objects")
+		}
+	}
+	return nil
+}
+
+func (s *gitService) updateSubmodules(ctx context.Context, dir string, repo github.Repository) error {
+	repository, err := git.PlainOpen(dir)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	url, _, err := s.remote(ctx, repo)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	return s.updateSubmodulesOf(ctx, repository, url, repo, git.DefaultSubmoduleRecursionDepth)
+}
+
+// updateSubmodulesOf initializes submodules of the repository fetched from the url, and their submodules down to the depth.
+func (s *gitService) updateSubmodulesOf(ctx context.Context, repository *git.Repository, url string, repo github.Repository, depth git.SubmoduleRescursivity) error {
+	wt, err := repository.Worktree()
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	submodules, err := wt.Submodules()
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	for _, submodule := range submodules {
+		config := submodule.Config()
+		config.URL, err = resolveURL(url, config.URL)
+		if err != nil {
+			return errors.Wrapf(err, "invalid url of submodule %s", config.Path)
+		}
+		logger.Infof(ctx.UUID(), "update submodule %s from %s", config.Path, config.URL)
+
+		endpoint, err := transport.NewEndpoint(config.URL)
+		if err != nil {
+			return errors.Wrapf(err, "invalid url of submodule %s", config.Path)
+		}
+		auth, err := s.auth(ctx, repo, endpoint.Protocol)
+		if err != nil {
+			return errors.Wrapf(err, "submodule %s", config.Path)
+		}
+
+		if err := submodule.UpdateContext(ctx, &git.SubmoduleUpdateOptions{
+			Init:              true,
+			RecurseSubmodules: git.NoRecurseSubmodules,
+			Auth:              auth,
+		}); err != nil {
+			return errors.Wrapf(err, "submodule %s", config.Path)
+		}
+
+		if depth <= 1 {
+			continue
+		}
+		child, err := submodule.Repository()
+		if err != nil {
+			return errors.Wrapf(err, "submodule %s", config.Path)
+		}
+		if err := s.updateSubmodulesOf(ctx, child, config.URL, repo, depth-1); err != nil {
+			return errors.Wrapf(err, "submodule %s", config.Path)
+		}
+	}
+	return nil
+}
+
+// resolveURL resolves the url of submodule relative to the url of its parent ( such as `../lib.git` ), as git command does.
+// Other urls are returned as they are.
+func resolveURL(parent string, url string) (string, error) {
+	if !strings.HasPrefix(url, "./") && !strings.HasPrefix(url, "../") {
+		return url, nil
+	}
+
+	if strings.Contains(parent, "://") {
+		base, err := neturl.Parse(parent)
+		if err != nil {
+			return "", errors.WithStack(err)
+		}
+		base.Path = path.Join(base.Path, url)
+		return base.String(), nil
+	}
+
+	// scp-like url such as git@github.com:owner/repo.git
+	i := strings.Index(parent, ":")
+	if i < 0 {
+		return "", errors.Errorf("can not resolve %s against %s", url, parent)
+	}
+	return fmt.Sprintf("%s:%s", parent[:i], path.Join(parent[i+1:], url)), nil
+}
+
+// pullLFS downloads lfs objects of HEAD and replaces pointer files with them, using git-lfs command.
+// Over ssh, the key must not be encrypted, because ssh command can not be given the passphrase.
+func pullLFS(ctx context.Context, dir string, url string, auth transport.AuthMethod, key *sshKey) error {
+	if _, ok := auth.(*ssh.PublicKeys); ok && key.encrypted {
+		return errors.Errorf("lfs over ssh requires a private key without passphrase: %s", key.path)
+	}
+
+	if _, err := exec.LookPath("git-lfs"); err != nil {
+		return errors.New("git-lfs is not installed on the server")
+	}
+
+	cmd := exec.CommandContext(ctx, "git", "-C", dir, "lfs", "pull", url)
+	cmd.Env = os.Environ()
+	switch auth := auth.(type) {
+	case *http.BasicAuth:
+		// pass credentials by environment variables, not to show them in arguments
+		credentials := base64.StdEncoding.EncodeToString([]byte(fmt.Sprintf("%s:%s", auth.Username, auth.Password)))
+		cmd.Env = append(cmd.Env,
+			"GIT_CONFIG_COUNT=1",
+			"GIT_CONFIG_KEY_0=http.extraheader",
+			fmt.Sprintf("GIT_CONFIG_VALUE_0=Authorization: Basic %s", credentials),
+		)
+	case *ssh.PublicKeys:
+		// never prompt, for passwords or unknown hosts
+		sshCommand := fmt.Sprintf("ssh -i %s -o IdentitiesOnly=yes -o BatchMode=yes -o StrictHostKeyChecking=yes", quote(key.path))
+		if knownHosts := application.Config.GitHub.KnownHostsPath; len(knownHosts) > 0 {
+			sshCommand = fmt.Sprintf("%s -o UserKnownHostsFile=%s", sshCommand, quote(knownHosts))
+		}
+		cmd.Env = append(cmd.Env, fmt.Sprintf("GIT_SSH_COMMAND=%s", sshCommand))
+	}
+
+	out, err := cmd.CombinedOutput()
+	for _, line := range strings.Split(strings.TrimSpace(string(out)), "\n") {
+		if len(line) > 0 {
+			logger.Info(ctx.UUID(), line)
+		}
+	}
+	if err != nil {
+		return errors.Wrapf(err, "git lfs pull: %s", strings.TrimSpace(string(out)))
+	}
+	return nil
+}
+
+// quote quotes the argument for shell, as git runs GIT_SSH_COMMAND with shell.
+func quote(arg string) string {
+	return fmt.Sprintf("'%s'", strings.Replace(arg, "'", `'\''`, -1))
+}
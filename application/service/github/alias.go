@@ -5,6 +5,8 @@ import "github.com/google/go-github/github"
 type Repository interface {
 	GetFullName() string
 	GetSSHURL() string
+	GetHTMLURL() string
+	GetCloneURL() string
 }
 
 type PullRequest = github.PullRequest
@@ -0,0 +1,228 @@
+package github
+
+import (
+	ctx "context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"github.com/duck8823/duci/infrastructure/clock"
+	"github.com/google/go-github/github"
+	"github.com/pkg/errors"
+	"golang.org/x/oauth2"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+)
+
+// requestTimeout is timeout of requests to issue tokens.
+const requestTimeout = 30 * time.Second
+
+// app authenticates as a GitHub App, and issues tokens of its installations.
+type app struct {
+	id  int64
+	key *rsa.PrivateKey
+	cli *github.Client
+
+	mu            sync.Mutex
+	installations map[string]int64
+	tokens        map[int64]*oauth2.Token
+}
+
+func newApp(id int64, keyPath string, baseURL *url.URL) (*app, error) {
+	data, err := ioutil.ReadFile(keyPath)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	key, err := parsePrivateKey(data)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	a := &app{
+		id:            id,
+		key:           key,
+		installations: make(map[string]int64),
+		tokens:        make(map[int64]*oauth2.Token),
+	}
+	a.cli = github.NewClient(&http.Client{
+		Transport: &jwtTransport{app: a, base: http.DefaultTransport},
+		Timeout:   requestTimeout,
+	})
+	a.cli.BaseURL = baseURL
+	return a, nil
+}
+
+// register remembers the installation of the repository, given by webhook payload.
+func (a *app) register(fullName string, installationID int64) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	a.installations[fullName] = installationID
+}
+
+// token returns the installation token for the repository.
+// The token is cached, and refreshed a minute before its expiration.
+// Requests to GitHub are sent without lock, not to block tokens of other repositories.
+func (a *app) token(fullName string) (*oauth2.Token, error) {
+	id, err := a.installationID(fullName)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	a.mu.Lock()
+	token, ok := a.tokens[id]
+	a.mu.Unlock()
+	if ok && clock.Now().Add(time.Minute).Before(token.Expiry) {
+		return token, nil
+	}
+
+	token, err = a.issueToken(id)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.tokens[id] = token
+	return token, nil
+}
+
+// installationID returns the installation for the repository, registered or found by api.
+func (a *app) installationID(fullName string) (int64, error) {
+	a.mu.Lock()
+	id, ok := a.installations[fullName]
+	a.mu.Unlock()
+	if ok {
+		return id, nil
+	}
+
+	name := &RepositoryName{fullName}
+	owner, err := name.Owner()
+	if err != nil {
+		return 0, errors.WithStack(err)
+	}
+	repo, err := name.Repo()
+	if err != nil {
+		return 0, errors.WithStack(err)
+	}
+
+	c, cancel := ctx.WithTimeout(ctx.Background(), requestTimeout)
+	defer cancel()
+	installation, _, err := a.cli.Apps.FindRepositoryInstallation(c, owner, repo)
+	if err != nil {
+		return 0, errors.WithStack(err)
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.installations[fullName] = installation.GetID()
+	return installation.GetID(), nil
+}
+
+// issueToken requests new token of the installation.
+func (a *app) issueToken(id int64) (*oauth2.Token, error) {
+	// go-github requests the endpoint no longer available
+	req, err := a.cli.NewRequest("POST", fmt.Sprintf("app/installations/%d/access_tokens", id), nil)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	req.Header.Set("Accept", "application/vnd.github.machine-man-preview+json")
+
+	c, cancel := ctx.WithTimeout(ctx.Background(), requestTimeout)
+	defer cancel()
+	installationToken := &github.InstallationToken{}
+	if _, err := a.cli.Do(c, req, installationToken); err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return &oauth2.Token{
+		AccessToken: installationToken.GetToken(),
+		TokenType:   "token",
+		Expiry:      installationToken.GetExpiresAt(),
+	}, nil
+}
+
+// jwt returns JSON Web Token signed with the private key, to authenticate as the app.
+func (a *app) jwt() (string, error) {
+	now := clock.Now()
+	header, err := json.Marshal(map[string]string{"alg": "RS256", "typ": "JWT"})
+	if err != nil {
+		return "", errors.WithStack(err)
+	}
+	// issued a minute ago to allow clock drift, and expires in 10 minutes at most
+	claims, err := json.Marshal(map[string]int64{
+		"iat": now.Add(-time.Minute).Unix(),
+		"exp": now.Add(9 * time.Minute).Unix(),
+		"iss": a.id,
+	})
+	if err != nil {
+		return "", errors.WithStack(err)
+	}
+
+	unsigned := fmt.Sprintf("%s.%s", base64.RawURLEncoding.EncodeToString(header), base64.RawURLEncoding.EncodeToString(claims))
+	hashed := sha256.Sum256([]byte(unsigned))
+	signature, err := rsa.SignPKCS1v15(rand.Reader, a.key, crypto.SHA256, hashed[:])
+	if err != nil {
+		return "", errors.WithStack(err)
+	}
+	return fmt.Sprintf("%s.%s", unsigned, base64.RawURLEncoding.EncodeToString(signature)), nil
+}
+
+// jwtTransport authenticates requests as the app.
+type jwtTransport struct {
+	app  *app
+	base http.RoundTripper
+}
+
+func (t *jwtTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	token, err := t.app.jwt()
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	// must not modify the original request
+	clone := new(http.Request)
+	*clone = *req
+	clone.Header = make(http.Header, len(req.Header))
+	for k, v := range req.Header {
+		clone.Header[k] = append([]string(nil), v...)
+	}
+	clone.Header.Set("Authorization", fmt.Sprintf("Bearer %s", token))
+	return t.base.RoundTrip(clone)
+}
+
+// installationTokenSource is a token source of the installation for the repository.
+type installationTokenSource struct {
+	app      *app
+	fullName string
+}
+
+func (s *installationTokenSource) Token() (*oauth2.Token, error) {
+	return s.app.token(s.fullName)
+}
+
+func parsePrivateKey(data []byte) (*rsa.PrivateKey, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("invalid private key: not PEM encoded")
+	}
+	if key, err := x509.ParsePKCS1PrivateKey(block.Bytes); err == nil {
+		return key, nil
+	}
+	key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	rsaKey, ok := key.(*rsa.PrivateKey)
+	if !ok {
+		return nil, errors.New("invalid private key: not RSA")
+	}
+	return rsaKey, nil
+}
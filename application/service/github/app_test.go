@@ -0,0 +1,328 @@
+package github_test
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"github.com/duck8823/duci/application"
+	"github.com/duck8823/duci/application/context"
+	"github.com/duck8823/duci/application/service/github"
+	"github.com/duck8823/duci/infrastructure/clock"
+	"github.com/google/uuid"
+	"gopkg.in/h2non/gock.v1"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeApp issues installation tokens, and records requests.
+type fakeApp struct {
+	mu       sync.Mutex
+	key      *rsa.PublicKey
+	requests []string
+	tokens   int
+}
+
+func (f *fakeApp) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.requests = append(f.requests, fmt.Sprintf("%s %s", r.Method, r.URL.Path))
+	w.Header().Set("Content-Type", "application/json")
+
+	switch {
+	case r.URL.Path == "/repos/duck8823/duci/installation":
+		if err := f.verify(r); err != nil {
+			http.Error(w, err.Error(), http.StatusUnauthorized)
+			return
+		}
+		fmt.Fprint(w, `{"id": 42}`)
+	case r.URL.Path == "/app/installations/42/access_tokens":
+		if err := f.verify(r); err != nil {
+			http.Error(w, err.Error(), http.StatusUnauthorized)
+			return
+		}
+		f.tokens++
+		expiresAt := clock.Now().Add(time.Hour).Format(time.RFC3339)
+		fmt.Fprintf(w, `{"token": "token-%d", "expires_at": "%s"}`, f.tokens, expiresAt)
+	case r.URL.Path == "/repos/duck8823/duci/pulls/5":
+		if r.Header.Get("Authorization") != fmt.Sprintf("token token-%d", f.tokens) {
+			http.Error(w, "invalid token", http.StatusUnauthorized)
+			return
+		}
+		fmt.Fprint(w, `{"id": 19}`)
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+// verify checks the JSON Web Token signed by the app.
+func (f *fakeApp) verify(r *http.Request) error {
+	token := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return fmt.Errorf("invalid jwt: %s", token)
+	}
+
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return err
+	}
+	hashed := sha256.Sum256([]byte(parts[0] + "." + parts[1]))
+	if err := rsa.VerifyPKCS1v15(f.key, crypto.SHA256, hashed[:], signature); err != nil {
+		return err
+	}
+
+	data, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return err
+	}
+	claims := map[string]int64{}
+	if err := json.Unmarshal(data, &claims); err != nil {
+		return err
+	}
+	if claims["iss"] != 12345 {
+		return fmt.Errorf("invalid issuer: %d", claims["iss"])
+	}
+	if now := clock.Now().Unix(); claims["iat"] > now || claims["exp"] <= now {
+		return fmt.Errorf("invalid term: %+v", claims)
+	}
+	return nil
+}
+
+func TestService_Token(t *testing.T) {
+	// setup
+	gock.Off()
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("error occurred: %+v", err)
+	}
+	file, err := ioutil.TempFile("", "private-key")
+	if err != nil {
+		t.Fatalf("error occurred: %+v", err)
+	}
+	defer os.Remove(file.Name())
+	if err := pem.Encode(file, &pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)}); err != nil {
+		t.Fatalf("error occurred: %+v", err)
+	}
+	file.Close()
+
+	reset, resetURL := application.Config.GitHub.App, application.Config.GitHub.BaseURL
+	defer func() {
+		application.Config.GitHub.App = reset
+		application.Config.GitHub.BaseURL = resetURL
+	}()
+	application.Config.GitHub.App = &application.App{ID: 12345, PrivateKeyPath: file.Name()}
+
+	now := time.Date(2018, time.October, 1, 0, 0, 0, 0, time.UTC)
+	defer clock.Adjust()
+	clock.Now = func() time.Time {
+		return now
+	}
+
+	// and
+	ctx := context.New("test/task", uuid.New(), &url.URL{})
+	repo := &MockRepo{FullName: "duck8823/duci"}
+
+	t.Run("with installation of webhook payload", func(t *testing.T) {
+		// given
+		fake := &fakeApp{key: &key.PublicKey}
+		server := httptest.NewServer(fake)
+		defer server.Close()
+
+		application.Config.GitHub.BaseURL = server.URL
+		sut, err := github.New()
+		if err != nil {
+			t.Fatalf("error occurred: %+v", err)
+		}
+		sut.RegisterInstallation(repo, 42)
+
+		// when
+		token, err := sut.Token(ctx, repo)
+
+		// then
+		if err != nil {
+			t.Fatalf("error must not occur, but got %+v", err)
+		}
+		if token != "token-1" {
+			t.Errorf("token must be %s, but got %s", "token-1", token)
+		}
+
+		// and
+		if _, err := sut.GetPullRequest(ctx, repo, 5); err != nil {
+			t.Errorf("must request with installation token, but got %+v", err)
+		}
+
+		// and
+		expected := []string{"POST /app/installations/42/access_tokens", "GET /repos/duck8823/duci/pulls/5"}
+		if strings.Join(fake.requests, ",") != strings.Join(expected, ",") {
+			t.Errorf("requests must be %+v, but got %+v", expected, fake.requests)
+		}
+	})
+
+	t.Run("without installation of webhook payload", func(t *testing.T) {
+		// given
+		fake := &fakeApp{key: &key.PublicKey}
+		server := httptest.NewServer(fake)
+		defer server.Close()
+
+		application.Config.GitHub.BaseURL = server.URL
+		sut, err := github.New()
+		if err != nil {
+			t.Fatalf("error occurred: %+v", err)
+		}
+
+		// when
+		token, err := sut.Token(ctx, repo)
+
+		// then
+		if err != nil {
+			t.Fatalf("error must not occur, but got %+v", err)
+		}
+		if token != "token-1" {
+			t.Errorf("token must be %s, but got %s", "token-1", token)
+		}
+
+		// and
+		expected := []string{"GET /repos/duck8823/duci/installation", "POST /app/installations/42/access_tokens"}
+		if strings.Join(fake.requests, ",") != strings.Join(expected, ",") {
+			t.Errorf("requests must be %+v, but got %+v", expected, fake.requests)
+		}
+	})
+
+	t.Run("when token expires", func(t *testing.T) {
+		// given
+		fake := &fakeApp{key: &key.PublicKey}
+		server := httptest.NewServer(fake)
+		defer server.Close()
+
+		application.Config.GitHub.BaseURL = server.URL
+		sut, err := github.New()
+		if err != nil {
+			t.Fatalf("error occurred: %+v", err)
+		}
+		sut.RegisterInstallation(repo, 42)
+
+		// and
+		if _, err := sut.Token(ctx, repo); err != nil {
+			t.Fatalf("error occurred: %+v", err)
+		}
+
+		for _, tt := range []struct {
+			after    time.Duration
+			expected string
+		}{
+			{after: 30 * time.Minute, expected: "token-1"},
+			{after: 59 * time.Minute, expected: "token-2"},
+		} {
+			// when
+			clock.Now = func() time.Time {
+				return now.Add(tt.after)
+			}
+			token, err := sut.Token(ctx, repo)
+
+			// then
+			if err != nil {
+				t.Fatalf("error must not occur, but got %+v", err)
+			}
+			if token != tt.expected {
+				t.Errorf("token after %s must be %s, but got %s", tt.after, tt.expected, token)
+			}
+		}
+	})
+
+	t.Run("while other repository waits for response", func(t *testing.T) {
+		// given
+		fake := &fakeApp{key: &key.PublicKey}
+		arrived, release := make(chan struct{}), make(chan struct{})
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path == "/repos/duck8823/slow/installation" {
+				close(arrived)
+				<-release
+			}
+			fake.ServeHTTP(w, r)
+		}))
+		defer server.Close()
+		defer close(release)
+
+		application.Config.GitHub.BaseURL = server.URL
+		sut, err := github.New()
+		if err != nil {
+			t.Fatalf("error occurred: %+v", err)
+		}
+		sut.RegisterInstallation(repo, 42)
+
+		// and
+		go sut.Token(ctx, &MockRepo{FullName: "duck8823/slow"})
+		<-arrived
+
+		// when
+		done := make(chan error, 1)
+		go func() {
+			_, err := sut.Token(ctx, repo)
+			done <- err
+		}()
+
+		// then
+		select {
+		case err := <-done:
+			if err != nil {
+				t.Errorf("error must not occur, but got %+v", err)
+			}
+		case <-time.After(5 * time.Second):
+			t.Error("token must not be blocked by other repository")
+		}
+	})
+
+	t.Run("with invalid private key", func(t *testing.T) {
+		// given
+		application.Config.GitHub.App = &application.App{ID: 12345, PrivateKeyPath: "/path/to/not/exists"}
+		defer func() {
+			application.Config.GitHub.App = &application.App{ID: 12345, PrivateKeyPath: file.Name()}
+		}()
+
+		// expect
+		if _, err := github.New(); err == nil {
+			t.Error("error must occur, but got nil")
+		}
+	})
+}
+
+func TestService_Token_WithAPIToken(t *testing.T) {
+	// setup
+	reset := application.Config.GitHub.APIToken
+	defer func() {
+		application.Config.GitHub.APIToken = reset
+	}()
+	application.Config.GitHub.APIToken = "api_token"
+
+	// given
+	sut, err := github.New()
+	if err != nil {
+		t.Fatalf("error occurred: %+v", err)
+	}
+
+	// when
+	token, err := sut.Token(context.New("test/task", uuid.New(), &url.URL{}), &MockRepo{FullName: "duck8823/duci"})
+
+	// then
+	if err != nil {
+		t.Fatalf("error must not occur, but got %+v", err)
+	}
+	if token != "api_token" {
+		t.Errorf("token must be api token, but got %s", token)
+	}
+}
@@ -0,0 +1,96 @@
+package github
+
+import (
+	"fmt"
+	"github.com/duck8823/duci/application/context"
+	"github.com/duck8823/duci/infrastructure/logger"
+	"github.com/pkg/errors"
+	"time"
+)
+
+// Check runs are still in preview.
+const checksPreview = "application/vnd.github.antiope-preview+json"
+
+// CheckRun is a request body of the Checks API.
+// The types of go-github follow the older preview, so that they are defined here.
+type CheckRun struct {
+	Name        string          `json:"name,omitempty"`
+	HeadSHA     string          `json:"head_sha,omitempty"`
+	DetailsURL  string          `json:"details_url,omitempty"`
+	ExternalID  string          `json:"external_id,omitempty"`
+	Status      string          `json:"status,omitempty"`
+	Conclusion  string          `json:"conclusion,omitempty"`
+	StartedAt   *time.Time      `json:"started_at,omitempty"`
+	CompletedAt *time.Time      `json:"completed_at,omitempty"`
+	Output      *CheckRunOutput `json:"output,omitempty"`
+}
+
+type CheckRunOutput struct {
+	Title       string        `json:"title"`
+	Summary     string        `json:"summary"`
+	Text        string        `json:"text,omitempty"`
+	Annotations []*Annotation `json:"annotations,omitempty"`
+}
+
+// Annotation points out a line of the file in the check run.
+type Annotation struct {
+	Path            string `json:"path"`
+	StartLine       int    `json:"start_line"`
+	EndLine         int    `json:"end_line"`
+	AnnotationLevel string `json:"annotation_level"`
+	Message         string `json:"message"`
+	Title           string `json:"title,omitempty"`
+}
+
+// CreateCheckRun creates the check run, and returns its id.
+// When the details url is empty, url of logs of the job is used.
+func (s *serviceImpl) CreateCheckRun(ctx context.Context, repository Repository, run *CheckRun) (int64, error) {
+	if len(run.DetailsURL) == 0 {
+		run.DetailsURL = targetURL(ctx)
+	}
+	if len(run.ExternalID) == 0 {
+		run.ExternalID = ctx.UUID().String()
+	}
+
+	created := &struct {
+		ID int64 `json:"id"`
+	}{}
+	if err := s.checkRun(ctx, "POST", repository, "", run, created); err != nil {
+		logger.Errorf(ctx.UUID(), "Failed to create check run: %+v", err)
+		return 0, errors.WithStack(err)
+	}
+	return created.ID, nil
+}
+
+// UpdateCheckRun updates the check run.
+func (s *serviceImpl) UpdateCheckRun(ctx context.Context, repository Repository, id int64, run *CheckRun) error {
+	if err := s.checkRun(ctx, "PATCH", repository, fmt.Sprintf("/%d", id), run, nil); err != nil {
+		logger.Errorf(ctx.UUID(), "Failed to update check run: %+v", err)
+		return errors.WithStack(err)
+	}
+	return nil
+}
+
+func (s *serviceImpl) checkRun(ctx context.Context, method string, repository Repository, suffix string, run *CheckRun, v interface{}) error {
+	name := &RepositoryName{repository.GetFullName()}
+	owner, err := name.Owner()
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	repo, err := name.Repo()
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	cli := s.client(repository)
+	req, err := cli.NewRequest(method, fmt.Sprintf("repos/%s/%s/check-runs%s", owner, repo, suffix), run)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	req.Header.Set("Accept", checksPreview)
+
+	if _, err := cli.Do(ctx, req, v); err != nil {
+		return errors.WithStack(err)
+	}
+	return nil
+}
@@ -24,19 +24,102 @@ const (
 type Service interface {
 	GetPullRequest(ctx context.Context, repository Repository, num int) (*PullRequest, error)
 	CreateCommitStatus(ctx context.Context, repo Repository, hash plumbing.Hash, state State, description string) error
+	CreateCheckRun(ctx context.Context, repo Repository, run *CheckRun) (int64, error)
+	UpdateCheckRun(ctx context.Context, repo Repository, id int64, run *CheckRun) error
+	RegisterInstallation(repo Repository, installationID int64)
+	Token(ctx context.Context, repo Repository) (string, error)
 }
 
 type serviceImpl struct {
-	cli *github.Client
+	cli   *github.Client
+	token string
+	app   *app
 }
 
+// New returns the service for github.com, or GitHub Enterprise Server of base_url.
+// With hosts, it returns the service picking the host by html url of the repository.
 func New() (Service, error) {
+	conf := application.Config.GitHub
+	defaultService, err := newService(conf.BaseURL, conf.UploadURL, string(conf.APIToken), conf.App)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	if len(conf.Hosts) == 0 {
+		return defaultService, nil
+	}
+
+	hosts := make(map[string]Service)
+	for name, host := range conf.Hosts {
+		service, err := newService(host.BaseURL, host.UploadURL, string(host.APIToken), host.App)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid settings of host %s", name)
+		}
+		hosts[name] = service
+	}
+	return &hostsService{defaultService: defaultService, hosts: hosts}, nil
+}
+
+func newService(baseURL string, uploadURL string, token string, appConfig *application.App) (*serviceImpl, error) {
+	if len(baseURL) == 0 {
+		baseURL = "https://api.github.com/"
+		uploadURL = "https://uploads.github.com/"
+	}
+	if len(uploadURL) == 0 {
+		uploadURL = baseURL
+	}
+
 	ts := oauth2.StaticTokenSource(
-		&oauth2.Token{AccessToken: string(application.Config.GitHub.APIToken)},
+		&oauth2.Token{AccessToken: token},
 	)
 	tc := oauth2.NewClient(ctx.Background(), ts)
 
-	return &serviceImpl{github.NewClient(tc)}, nil
+	cli, err := github.NewEnterpriseClient(baseURL, uploadURL, tc)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	service := &serviceImpl{cli: cli, token: token}
+	if appConfig != nil && appConfig.ID > 0 {
+		service.app, err = newApp(appConfig.ID, appConfig.PrivateKeyPath, cli.BaseURL)
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+	}
+	return service, nil
+}
+
+// RegisterInstallation remembers the installation of the repository given by webhook payload.
+// It does nothing unless authenticating as a GitHub App.
+func (s *serviceImpl) RegisterInstallation(repository Repository, installationID int64) {
+	if s.app == nil {
+		return
+	}
+	s.app.register(repository.GetFullName(), installationID)
+}
+
+// Token returns the token to access the repository.
+// As a GitHub App, it is the installation token for the repository.
+func (s *serviceImpl) Token(ctx context.Context, repository Repository) (string, error) {
+	if s.app == nil {
+		return s.token, nil
+	}
+	token, err := s.app.token(repository.GetFullName())
+	if err != nil {
+		logger.Errorf(ctx.UUID(), "Failed to get installation token for %s: %+v", repository.GetFullName(), err)
+		return "", errors.WithStack(err)
+	}
+	return token.AccessToken, nil
+}
+
+// client returns the client authenticated for the repository.
+func (s *serviceImpl) client(repository Repository) *github.Client {
+	if s.app == nil {
+		return s.cli
+	}
+	ts := &installationTokenSource{app: s.app, fullName: repository.GetFullName()}
+	cli := github.NewClient(oauth2.NewClient(ctx.Background(), ts))
+	cli.BaseURL = s.cli.BaseURL
+	return cli
 }
 
 func (s *serviceImpl) GetPullRequest(ctx context.Context, repository Repository, num int) (*PullRequest, error) {
@@ -49,7 +132,7 @@ func (s *serviceImpl) GetPullRequest(ctx context.Context, repository Repository,
 	if err != nil {
 		return nil, errors.WithStack(err)
 	}
-	pr, resp, err := s.cli.PullRequests.Get(
+	pr, resp, err := s.client(repository).PullRequests.Get(
 		ctx,
 		owner,
 		repo,
@@ -77,9 +160,7 @@ func (s *serviceImpl) CreateCommitStatus(ctx context.Context, repository Reposit
 	if len(description) >= 50 {
 		description = string([]rune(description)[:46]) + "..."
 	}
-	targetUrl := *ctx.Url()
-	targetUrl.Path = path.Join(targetUrl.Path, "logs", ctx.UUID().String())
-	targetUrlStr := targetUrl.String()
+	targetUrlStr := targetURL(ctx)
 	status := &Status{
 		Context:     &taskName,
 		Description: &description,
@@ -87,7 +168,7 @@ func (s *serviceImpl) CreateCommitStatus(ctx context.Context, repository Reposit
 		TargetURL:   &targetUrlStr,
 	}
 
-	if _, _, err := s.cli.Repositories.CreateStatus(
+	if _, _, err := s.client(repository).Repositories.CreateStatus(
 		ctx,
 		owner,
 		repo,
@@ -99,3 +180,10 @@ func (s *serviceImpl) CreateCommitStatus(ctx context.Context, repository Reposit
 	}
 	return nil
 }
+
+// targetURL returns url of logs of the job.
+func targetURL(ctx context.Context) string {
+	targetUrl := *ctx.Url()
+	targetUrl.Path = path.Join(targetUrl.Path, "logs", ctx.UUID().String())
+	return targetUrl.String()
+}
@@ -15,6 +15,8 @@ import (
 type MockRepo struct {
 	FullName string
 	SSHURL   string
+	HTMLURL  string
+	CloneURL string
 }
 
 func (r *MockRepo) GetFullName() string {
@@ -25,6 +27,14 @@ func (r *MockRepo) GetSSHURL() string {
 	return r.SSHURL
 }
 
+func (r *MockRepo) GetHTMLURL() string {
+	return r.HTMLURL
+}
+
+func (r *MockRepo) GetCloneURL() string {
+	return r.CloneURL
+}
+
 func TestService_GetPullRequest(t *testing.T) {
 	// setup
 	s, err := github.New()
@@ -215,3 +225,83 @@ func TestService_CreateCommitStatus(t *testing.T) {
 		gock.Clean()
 	})
 }
+
+func TestService_CreateCheckRun(t *testing.T) {
+	// setup
+	s, err := github.New()
+	if err != nil {
+		t.Fatalf("error occurred. %+v", err)
+	}
+
+	t.Run("when github server returns status created", func(t *testing.T) {
+		// given
+		repo := &MockRepo{FullName: "duck8823/duci"}
+		requestId := uuid.New()
+
+		// and
+		gock.New("https://api.github.com").
+			Post("/repos/duck8823/duci/check-runs").
+			MatchHeader("Accept", "antiope-preview").
+			MatchType("json").
+			JSON(&github.CheckRun{
+				Name:       "test/task",
+				HeadSHA:    "0000000000000000000000000000000000000000",
+				DetailsURL: fmt.Sprintf("http://host:8080/logs/%s", requestId),
+				ExternalID: requestId.String(),
+				Status:     "in_progress",
+			}).
+			Reply(201).
+			JSON(map[string]int64{"id": 42})
+		defer gock.Clean()
+
+		// when
+		id, err := s.CreateCheckRun(
+			context.New("test/task", requestId, &url.URL{Scheme: "http", Host: "host:8080"}),
+			repo,
+			&github.CheckRun{Name: "test/task", HeadSHA: plumbing.ZeroHash.String(), Status: "in_progress"},
+		)
+
+		// then
+		if err != nil {
+			t.Fatalf("error must not occur, but got %+v", err)
+		}
+
+		if id != 42 {
+			t.Errorf("id must be 42, but got %d", id)
+		}
+	})
+
+	t.Run("with invalid repository", func(t *testing.T) {
+		// given
+		repo := &MockRepo{FullName: ""}
+
+		// expect
+		if _, err := s.CreateCheckRun(context.New("test/task", uuid.New(), &url.URL{}), repo, &github.CheckRun{}); err == nil {
+			t.Error("error must occur, but got nil")
+		}
+	})
+}
+
+func TestService_UpdateCheckRun(t *testing.T) {
+	// setup
+	s, err := github.New()
+	if err != nil {
+		t.Fatalf("error occurred. %+v", err)
+	}
+
+	t.Run("when github server returns status not found", func(t *testing.T) {
+		// given
+		repo := &MockRepo{FullName: "duck8823/duci"}
+
+		// and
+		gock.New("https://api.github.com").
+			Patch("/repos/duck8823/duci/check-runs/42").
+			Reply(404)
+		defer gock.Clean()
+
+		// expect
+		if err := s.UpdateCheckRun(context.New("test/task", uuid.New(), &url.URL{}), repo, 42, &github.CheckRun{Status: "completed"}); err == nil {
+			t.Error("error must occur, but got nil")
+		}
+	})
+}
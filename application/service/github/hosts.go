@@ -0,0 +1,50 @@
+package github
+
+import (
+	"github.com/duck8823/duci/application/context"
+	"gopkg.in/src-d/go-git.v4/plumbing"
+	"net/url"
+)
+
+// hostsService delegates to the service of the host the repository belongs to.
+type hostsService struct {
+	defaultService Service
+	hosts          map[string]Service
+}
+
+func (s *hostsService) GetPullRequest(ctx context.Context, repo Repository, num int) (*PullRequest, error) {
+	return s.service(repo).GetPullRequest(ctx, repo, num)
+}
+
+func (s *hostsService) CreateCommitStatus(ctx context.Context, repo Repository, hash plumbing.Hash, state State, description string) error {
+	return s.service(repo).CreateCommitStatus(ctx, repo, hash, state, description)
+}
+
+func (s *hostsService) CreateCheckRun(ctx context.Context, repo Repository, run *CheckRun) (int64, error) {
+	return s.service(repo).CreateCheckRun(ctx, repo, run)
+}
+
+func (s *hostsService) UpdateCheckRun(ctx context.Context, repo Repository, id int64, run *CheckRun) error {
+	return s.service(repo).UpdateCheckRun(ctx, repo, id, run)
+}
+
+func (s *hostsService) RegisterInstallation(repo Repository, installationID int64) {
+	s.service(repo).RegisterInstallation(repo, installationID)
+}
+
+func (s *hostsService) Token(ctx context.Context, repo Repository) (string, error) {
+	return s.service(repo).Token(ctx, repo)
+}
+
+// service returns the service of the host in html url of the repository.
+// Repositories of unknown hosts belong to the default one.
+func (s *hostsService) service(repo Repository) Service {
+	u, err := url.Parse(repo.GetHTMLURL())
+	if err != nil {
+		return s.defaultService
+	}
+	if service, ok := s.hosts[u.Host]; ok {
+		return service
+	}
+	return s.defaultService
+}
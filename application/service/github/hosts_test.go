@@ -0,0 +1,103 @@
+package github_test
+
+import (
+	"fmt"
+	"github.com/duck8823/duci/application"
+	"github.com/duck8823/duci/application/context"
+	"github.com/duck8823/duci/application/service/github"
+	"github.com/google/uuid"
+	"gopkg.in/h2non/gock.v1"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+)
+
+func TestNew_WithHosts(t *testing.T) {
+	// setup
+	gock.Off()
+
+	enterprise := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/v3/repos/duck8823/duci/pulls/5" {
+			http.NotFound(w, r)
+			return
+		}
+		if r.Header.Get("Authorization") != "Bearer enterprise_token" {
+			http.Error(w, "invalid token", http.StatusUnauthorized)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"id": 1}`)
+	}))
+	defer enterprise.Close()
+
+	other := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer other_token" {
+			http.Error(w, "invalid token", http.StatusUnauthorized)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"id": 2}`)
+	}))
+	defer other.Close()
+
+	reset := *application.Config.GitHub
+	defer func() {
+		*application.Config.GitHub = reset
+	}()
+	application.Config.GitHub.APIToken = "enterprise_token"
+	application.Config.GitHub.BaseURL = enterprise.URL + "/api/v3"
+	application.Config.GitHub.UploadURL = enterprise.URL + "/api/uploads"
+	application.Config.GitHub.Hosts = map[string]*application.GitHubHost{
+		"other.example.com": {BaseURL: other.URL, APIToken: "other_token"},
+	}
+
+	// and
+	sut, err := github.New()
+	if err != nil {
+		t.Fatalf("error occurred: %+v", err)
+	}
+	ctx := context.New("test/task", uuid.New(), &url.URL{})
+
+	for _, tt := range []struct {
+		name     string
+		htmlURL  string
+		expected int64
+	}{
+		{name: "with html url of the host", htmlURL: "https://other.example.com/duck8823/duci", expected: 2},
+		{name: "with html url of unknown host", htmlURL: "https://github.example.com/duck8823/duci", expected: 1},
+		{name: "without html url", htmlURL: "", expected: 1},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			// given
+			repo := &MockRepo{FullName: "duck8823/duci", HTMLURL: tt.htmlURL}
+
+			// when
+			pr, err := sut.GetPullRequest(ctx, repo, 5)
+
+			// then
+			if err != nil {
+				t.Fatalf("error must not occur, but got %+v", err)
+			}
+			if pr.GetID() != tt.expected {
+				t.Errorf("must request to host of id %d, but got %d", tt.expected, pr.GetID())
+			}
+		})
+	}
+
+	t.Run("with token", func(t *testing.T) {
+		// given
+		repo := &MockRepo{FullName: "duck8823/duci", HTMLURL: "https://other.example.com/duck8823/duci"}
+
+		// when
+		token, err := sut.Token(ctx, repo)
+
+		// then
+		if err != nil {
+			t.Fatalf("error must not occur, but got %+v", err)
+		}
+		if token != "other_token" {
+			t.Errorf("token must be of the host, but got %s", token)
+		}
+	})
+}
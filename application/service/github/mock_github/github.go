@@ -1,5 +1,5 @@
 // Code generated by MockGen. DO NOT EDIT.
-// Source: service/github/github.go
+// Source: application/service/github/github.go
 
 // Package mock_github is a generated GoMock package.
 package mock_github
@@ -59,3 +59,51 @@ func (m *MockService) CreateCommitStatus(ctx context.Context, repo github.Reposi
 func (mr *MockServiceMockRecorder) CreateCommitStatus(ctx, repo, hash, state, description interface{}) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateCommitStatus", reflect.TypeOf((*MockService)(nil).CreateCommitStatus), ctx, repo, hash, state, description)
 }
+
+// CreateCheckRun mocks base method
+func (m *MockService) CreateCheckRun(ctx context.Context, repo github.Repository, run *github.CheckRun) (int64, error) {
+	ret := m.ctrl.Call(m, "CreateCheckRun", ctx, repo, run)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateCheckRun indicates an expected call of CreateCheckRun
+func (mr *MockServiceMockRecorder) CreateCheckRun(ctx, repo, run interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateCheckRun", reflect.TypeOf((*MockService)(nil).CreateCheckRun), ctx, repo, run)
+}
+
+// UpdateCheckRun mocks base method
+func (m *MockService) UpdateCheckRun(ctx context.Context, repo github.Repository, id int64, run *github.CheckRun) error {
+	ret := m.ctrl.Call(m, "UpdateCheckRun", ctx, repo, id, run)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateCheckRun indicates an expected call of UpdateCheckRun
+func (mr *MockServiceMockRecorder) UpdateCheckRun(ctx, repo, id, run interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateCheckRun", reflect.TypeOf((*MockService)(nil).UpdateCheckRun), ctx, repo, id, run)
+}
+
+// RegisterInstallation mocks base method
+func (m *MockService) RegisterInstallation(repo github.Repository, installationID int64) {
+	m.ctrl.Call(m, "RegisterInstallation", repo, installationID)
+}
+
+// RegisterInstallation indicates an expected call of RegisterInstallation
+func (mr *MockServiceMockRecorder) RegisterInstallation(repo, installationID interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RegisterInstallation", reflect.TypeOf((*MockService)(nil).RegisterInstallation), repo, installationID)
+}
+
+// Token mocks base method
+func (m *MockService) Token(ctx context.Context, repo github.Repository) (string, error) {
+	ret := m.ctrl.Call(m, "Token", ctx, repo)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Token indicates an expected call of Token
+func (mr *MockServiceMockRecorder) Token(ctx, repo interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Token", reflect.TypeOf((*MockService)(nil).Token), ctx, repo)
+}
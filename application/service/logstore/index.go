@@ -0,0 +1,173 @@
+package logstore
+
+import (
+	"fmt"
+	"github.com/duck8823/duci/data/model"
+	"github.com/duck8823/duci/infrastructure/store"
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+	"strings"
+	"time"
+)
+
+// Query is conditions to list jobs. Empty fields are not used for filtering.
+type Query struct {
+	Repository string
+	Ref        string
+	SHA        string
+	State      model.State
+	Since      time.Time
+	Until      time.Time
+	Offset     int
+	Limit      int
+}
+
+func (q *Query) match(job *model.Job) bool {
+	switch {
+	case len(q.Repository) > 0 && job.Repository != q.Repository:
+		return false
+	case len(q.Ref) > 0 && job.Ref != q.Ref:
+		return false
+	case len(q.SHA) > 0 && job.SHA != q.SHA:
+		return false
+	case len(q.State) > 0 && job.State != q.State:
+		return false
+	case !q.Since.IsZero() && job.QueuedAt.Before(q.Since):
+		return false
+	case !q.Until.IsZero() && job.QueuedAt.After(q.Until):
+		return false
+	}
+	return true
+}
+
+// prefix returns the most selective index for the query.
+func (q *Query) prefix() []byte {
+	switch {
+	case len(q.SHA) > 0:
+		return indexPrefix("sha", q.SHA)
+	case len(q.Repository) > 0 && len(q.Ref) > 0:
+		return indexPrefix("ref", q.Repository, q.Ref)
+	case len(q.Repository) > 0:
+		return indexPrefix("repo", q.Repository)
+	case len(q.State) > 0:
+		return indexPrefix("state", q.State)
+	default:
+		return indexPrefix("time")
+	}
+}
+
+// List returns metadata of jobs matching the query, newest first.
+func (s *storeServiceImpl) List(q *Query) ([]*model.Job, error) {
+	prefix := q.prefix()
+	slice := store.Prefix(prefix)
+	if !q.Since.IsZero() {
+		slice.Start = indexKey(prefix, q.Since, uuid.Nil)
+	}
+	if !q.Until.IsZero() {
+		slice.Limit = indexKey(prefix, q.Until.Add(time.Nanosecond), uuid.Nil)
+	}
+
+	iter := s.db.NewIterator(slice, nil)
+	defer iter.Release()
+
+	jobs := make([]*model.Job, 0)
+	skipped := 0
+	for ok := iter.Last(); ok; ok = iter.Prev() {
+		if q.Limit > 0 && len(jobs) >= q.Limit {
+			break
+		}
+
+		key := iter.Key()
+		id, err := uuid.Parse(string(key[len(key)-36:]))
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+		job, err := s.metadata(id)
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+		if !q.match(job) {
+			continue
+		}
+		if skipped < q.Offset {
+			skipped++
+			continue
+		}
+		job.Stream = nil
+		jobs = append(jobs, job)
+	}
+	if err := iter.Error(); err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return jobs, nil
+}
+
+// putIndexes stores secondary index keys of the job.
+func (s *storeServiceImpl) putIndexes(job *model.Job) error {
+	for _, prefix := range indexPrefixes(job) {
+		if err := s.db.Put(indexKey(prefix, job.QueuedAt, job.ID), []byte{}, nil); err != nil {
+			return errors.WithStack(err)
+		}
+	}
+	return nil
+}
+
+// deleteIndexes removes secondary index keys of the job stored, if exists.
+func (s *storeServiceImpl) deleteIndexes(id uuid.UUID) error {
+	job, err := s.metadata(id)
+	if errors.Cause(err) == store.NotFoundError {
+		return nil
+	} else if err != nil {
+		return errors.WithStack(err)
+	}
+
+	for _, prefix := range indexPrefixes(job) {
+		if err := s.db.Delete(indexKey(prefix, job.QueuedAt, id), nil); err != nil {
+			return errors.WithStack(err)
+		}
+	}
+	return nil
+}
+
+// updateStateIndex moves the job to the index of new state.
+func (s *storeServiceImpl) updateStateIndex(job *model.Job, old model.State) error {
+	if job.QueuedAt.IsZero() || old == job.State {
+		return nil
+	}
+	if len(old) > 0 {
+		if err := s.db.Delete(indexKey(indexPrefix("state", old), job.QueuedAt, job.ID), nil); err != nil {
+			return errors.WithStack(err)
+		}
+	}
+	if err := s.db.Put(indexKey(indexPrefix("state", job.State), job.QueuedAt, job.ID), []byte{}, nil); err != nil {
+		return errors.WithStack(err)
+	}
+	return nil
+}
+
+func indexPrefixes(job *model.Job) [][]byte {
+	if job.QueuedAt.IsZero() {
+		return nil
+	}
+
+	prefixes := [][]byte{indexPrefix("time"), indexPrefix("state", job.State)}
+	if len(job.Repository) > 0 {
+		prefixes = append(prefixes, indexPrefix("repo", job.Repository))
+		if len(job.Ref) > 0 {
+			prefixes = append(prefixes, indexPrefix("ref", job.Repository, job.Ref))
+		}
+	}
+	if len(job.SHA) > 0 {
+		prefixes = append(prefixes, indexPrefix("sha", job.SHA))
+	}
+	return prefixes
+}
+
+// indexPrefix returns prefix of index keys. Values are separated by NUL, because refs may contain slashes.
+func indexPrefix(name string, values ...string) []byte {
+	return []byte(fmt.Sprintf("index/%s/%s\x00", name, strings.Join(values, "\x00")))
+}
+
+func indexKey(prefix []byte, queuedAt time.Time, uuid uuid.UUID) []byte {
+	return append(append([]byte{}, prefix...), []byte(fmt.Sprintf("%020d/%s", queuedAt.UnixNano(), uuid.String()))...)
+}
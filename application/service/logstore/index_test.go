@@ -0,0 +1,188 @@
+package logstore
+
+import (
+	"fmt"
+	"github.com/duck8823/duci/data/model"
+	"github.com/duck8823/duci/infrastructure/clock"
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/uuid"
+	"github.com/syndtr/goleveldb/leveldb"
+	"github.com/syndtr/goleveldb/leveldb/storage"
+	"testing"
+	"time"
+)
+
+func TestStoreServiceImpl_List(t *testing.T) {
+	// setup
+	db, err := leveldb.Open(storage.NewMemStorage(), nil)
+	if err != nil {
+		t.Fatalf("error occurred: %+v", err)
+	}
+	defer db.Close()
+
+	service := &storeServiceImpl{db: db}
+	defer clock.Adjust()
+
+	// given
+	jobs := []*model.Job{
+		{ID: uuid.New(), Repository: "duck8823/duci", Ref: "refs/heads/master", SHA: "aaa"},
+		{ID: uuid.New(), Repository: "duck8823/duci", Ref: "refs/heads/feature/master", SHA: "bbb"},
+		{ID: uuid.New(), Repository: "duck8823/duci", Ref: "refs/heads/master", SHA: "ccc"},
+		{ID: uuid.New(), Repository: "duck8823/other", Ref: "refs/heads/master", SHA: "ddd"},
+	}
+	for i, job := range jobs {
+		clock.Now = func() time.Time {
+			return time.Unix(int64(i*10), 0).UTC()
+		}
+		if err := service.Start(job); err != nil {
+			t.Fatalf("error occurred: %+v", err)
+		}
+	}
+
+	// and
+	if err := service.Running(jobs[0].ID); err != nil {
+		t.Fatalf("error occurred: %+v", err)
+	}
+	if err := service.Finish(jobs[0].ID, model.FAILURE, 1); err != nil {
+		t.Fatalf("error occurred: %+v", err)
+	}
+	if err := service.Running(jobs[2].ID); err != nil {
+		t.Fatalf("error occurred: %+v", err)
+	}
+
+	for _, tt := range []struct {
+		name     string
+		query    *Query
+		expected []*model.Job
+	}{
+		{
+			name:     "without conditions",
+			query:    &Query{},
+			expected: []*model.Job{jobs[3], jobs[2], jobs[1], jobs[0]},
+		},
+		{
+			name:     "with repository",
+			query:    &Query{Repository: "duck8823/duci"},
+			expected: []*model.Job{jobs[2], jobs[1], jobs[0]},
+		},
+		{
+			name:     "with repository and ref",
+			query:    &Query{Repository: "duck8823/duci", Ref: "refs/heads/master"},
+			expected: []*model.Job{jobs[2], jobs[0]},
+		},
+		{
+			name:     "with ref only",
+			query:    &Query{Ref: "refs/heads/master"},
+			expected: []*model.Job{jobs[3], jobs[2], jobs[0]},
+		},
+		{
+			name:     "with sha",
+			query:    &Query{SHA: "bbb"},
+			expected: []*model.Job{jobs[1]},
+		},
+		{
+			name:     "with state",
+			query:    &Query{State: model.RUNNING},
+			expected: []*model.Job{jobs[2]},
+		},
+		{
+			name:     "with repository and state",
+			query:    &Query{Repository: "duck8823/duci", State: model.FAILURE},
+			expected: []*model.Job{jobs[0]},
+		},
+		{
+			name:     "with since and until",
+			query:    &Query{Since: time.Unix(10, 0), Until: time.Unix(20, 0)},
+			expected: []*model.Job{jobs[2], jobs[1]},
+		},
+		{
+			name:     "with offset and limit",
+			query:    &Query{Offset: 1, Limit: 2},
+			expected: []*model.Job{jobs[2], jobs[1]},
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			// when
+			actual, err := service.List(tt.query)
+
+			// then
+			if err != nil {
+				t.Fatalf("error must not occur, but got %+v", err)
+			}
+
+			if !cmp.Equal(ids(actual), ids(tt.expected)) {
+				t.Errorf("find differences: %+v", cmp.Diff(ids(actual), ids(tt.expected)))
+			}
+		})
+	}
+
+	t.Run("state index is updated", func(t *testing.T) {
+		// when
+		actual, err := service.List(&Query{State: model.QUEUED})
+
+		// then
+		if err != nil {
+			t.Fatalf("error must not occur, but got %+v", err)
+		}
+
+		expected := []uuid.UUID{jobs[3].ID, jobs[1].ID}
+		if !cmp.Equal(ids(actual), expected) {
+			t.Errorf("find differences: %+v", cmp.Diff(ids(actual), expected))
+		}
+	})
+}
+
+func TestStoreServiceImpl_List_Restarted(t *testing.T) {
+	// setup
+	db, err := leveldb.Open(storage.NewMemStorage(), nil)
+	if err != nil {
+		t.Fatalf("error occurred: %+v", err)
+	}
+	defer db.Close()
+
+	service := &storeServiceImpl{db: db}
+	defer clock.Adjust()
+
+	// given
+	job := &model.Job{ID: uuid.New(), Repository: "duck8823/duci", Ref: "refs/heads/master", SHA: "aaa"}
+	for i := 0; i < 2; i++ {
+		clock.Now = func() time.Time {
+			return time.Unix(int64(i*10), 0).UTC()
+		}
+		if err := service.Start(job); err != nil {
+			t.Fatalf("error occurred: %+v", err)
+		}
+	}
+
+	for _, query := range []*Query{
+		{},
+		{Repository: "duck8823/duci"},
+		{Repository: "duck8823/duci", Ref: "refs/heads/master"},
+		{SHA: "aaa"},
+		{State: model.QUEUED},
+		{Since: time.Unix(10, 0)},
+	} {
+		t.Run(fmt.Sprintf("%+v", *query), func(t *testing.T) {
+			// when
+			actual, err := service.List(query)
+
+			// then
+			if err != nil {
+				t.Fatalf("error must not occur, but got %+v", err)
+			}
+
+			expected := []uuid.UUID{job.ID}
+			if !cmp.Equal(ids(actual), expected) {
+				t.Errorf("find differences: %+v", cmp.Diff(ids(actual), expected))
+			}
+		})
+	}
+}
+
+func ids(jobs []*model.Job) []uuid.UUID {
+	ids := make([]uuid.UUID, 0)
+	for _, job := range jobs {
+		ids = append(ids, job.ID)
+	}
+	return ids
+}
@@ -0,0 +1,45 @@
+package logstore
+
+import (
+	"github.com/google/uuid"
+	"sync"
+)
+
+// locks holds mutexes of jobs, to serialize appending messages to the same job.
+type locks struct {
+	mu    sync.Mutex
+	locks map[uuid.UUID]*lock
+}
+
+type lock struct {
+	sync.Mutex
+	refs int
+}
+
+// lock locks the job, and returns the function to unlock it.
+// The mutex is released from the map when no one holds or waits it.
+func (l *locks) lock(id uuid.UUID) func() {
+	l.mu.Lock()
+	if l.locks == nil {
+		l.locks = make(map[uuid.UUID]*lock)
+	}
+	m, ok := l.locks[id]
+	if !ok {
+		m = &lock{}
+		l.locks[id] = m
+	}
+	m.refs++
+	l.mu.Unlock()
+
+	m.Lock()
+	return func() {
+		m.Unlock()
+
+		l.mu.Lock()
+		defer l.mu.Unlock()
+		m.refs--
+		if m.refs == 0 {
+			delete(l.locks, id)
+		}
+	}
+}
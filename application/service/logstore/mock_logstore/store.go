@@ -5,6 +5,7 @@
 package mock_logstore
 
 import (
+	logstore "github.com/duck8823/duci/application/service/logstore"
 	model "github.com/duck8823/duci/data/model"
 	gomock "github.com/golang/mock/gomock"
 	uuid "github.com/google/uuid"
@@ -47,6 +48,19 @@ func (mr *MockServiceMockRecorder) Get(uuid interface{}) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Get", reflect.TypeOf((*MockService)(nil).Get), uuid)
 }
 
+// GetFrom mocks base method
+func (m *MockService) GetFrom(uuid uuid.UUID, offset int) (*model.Job, error) {
+	ret := m.ctrl.Call(m, "GetFrom", uuid, offset)
+	ret0, _ := ret[0].(*model.Job)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetFrom indicates an expected call of GetFrom
+func (mr *MockServiceMockRecorder) GetFrom(uuid, offset interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetFrom", reflect.TypeOf((*MockService)(nil).GetFrom), uuid, offset)
+}
+
 // Append mocks base method
 func (m *MockService) Append(uuid uuid.UUID, message model.Message) error {
 	ret := m.ctrl.Call(m, "Append", uuid, message)
@@ -60,27 +74,65 @@ func (mr *MockServiceMockRecorder) Append(uuid, message interface{}) *gomock.Cal
 }
 
 // Start mocks base method
-func (m *MockService) Start(uuid uuid.UUID) error {
-	ret := m.ctrl.Call(m, "Start", uuid)
+func (m *MockService) Start(job *model.Job) error {
+	ret := m.ctrl.Call(m, "Start", job)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // Start indicates an expected call of Start
-func (mr *MockServiceMockRecorder) Start(uuid interface{}) *gomock.Call {
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Start", reflect.TypeOf((*MockService)(nil).Start), uuid)
+func (mr *MockServiceMockRecorder) Start(job interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Start", reflect.TypeOf((*MockService)(nil).Start), job)
+}
+
+// Running mocks base method
+func (m *MockService) Running(uuid uuid.UUID) error {
+	ret := m.ctrl.Call(m, "Running", uuid)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Running indicates an expected call of Running
+func (mr *MockServiceMockRecorder) Running(uuid interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Running", reflect.TypeOf((*MockService)(nil).Running), uuid)
 }
 
 // Finish mocks base method
-func (m *MockService) Finish(uuid uuid.UUID) error {
-	ret := m.ctrl.Call(m, "Finish", uuid)
+func (m *MockService) Finish(uuid uuid.UUID, state model.State, exitCode int64) error {
+	ret := m.ctrl.Call(m, "Finish", uuid, state, exitCode)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // Finish indicates an expected call of Finish
-func (mr *MockServiceMockRecorder) Finish(uuid interface{}) *gomock.Call {
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Finish", reflect.TypeOf((*MockService)(nil).Finish), uuid)
+func (mr *MockServiceMockRecorder) Finish(uuid, state, exitCode interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Finish", reflect.TypeOf((*MockService)(nil).Finish), uuid, state, exitCode)
+}
+
+// List mocks base method
+func (m *MockService) List(query *logstore.Query) ([]*model.Job, error) {
+	ret := m.ctrl.Call(m, "List", query)
+	ret0, _ := ret[0].([]*model.Job)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// List indicates an expected call of List
+func (mr *MockServiceMockRecorder) List(query interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "List", reflect.TypeOf((*MockService)(nil).List), query)
+}
+
+// Subscribe mocks base method
+func (m *MockService) Subscribe(uuid uuid.UUID) (<-chan struct{}, func()) {
+	ret := m.ctrl.Call(m, "Subscribe", uuid)
+	ret0, _ := ret[0].(<-chan struct{})
+	ret1, _ := ret[1].(func())
+	return ret0, ret1
+}
+
+// Subscribe indicates an expected call of Subscribe
+func (mr *MockServiceMockRecorder) Subscribe(uuid interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Subscribe", reflect.TypeOf((*MockService)(nil).Subscribe), uuid)
 }
 
 // Close mocks base method
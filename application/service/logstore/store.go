@@ -3,109 +3,207 @@ package logstore
 import (
 	"bytes"
 	"encoding/json"
-	"github.com/duck8823/duci/application"
+	"fmt"
 	"github.com/duck8823/duci/data/model"
+	"github.com/duck8823/duci/infrastructure/clock"
 	"github.com/duck8823/duci/infrastructure/store"
 	"github.com/google/uuid"
 	"github.com/pkg/errors"
-	"github.com/syndtr/goleveldb/leveldb"
+	"strconv"
 )
 
 type Level = string
 
 type Service interface {
 	Get(uuid uuid.UUID) (*model.Job, error)
+	GetFrom(uuid uuid.UUID, offset int) (*model.Job, error)
 	Append(uuid uuid.UUID, message model.Message) error
-	Start(uuid uuid.UUID) error
-	Finish(uuid uuid.UUID) error
+	Start(job *model.Job) error
+	Running(uuid uuid.UUID) error
+	Finish(uuid uuid.UUID, state model.State, exitCode int64) error
+	List(query *Query) ([]*model.Job, error)
+	Subscribe(uuid uuid.UUID) (<-chan struct{}, func())
 	Close() error
 }
 
 type storeServiceImpl struct {
-	db store.Store
+	db          store.Store
+	subscribers subscribers
+	locks       locks
 }
 
-func New() (Service, error) {
-	database, err := leveldb.OpenFile(application.Config.Server.DatabasePath, nil)
-	if err != nil {
-		return nil, errors.WithStack(err)
-	}
-	return &storeServiceImpl{database}, nil
+func New(database store.Store) Service {
+	return &storeServiceImpl{db: database}
 }
 
+// Append stores the message under its own key ( <uuid>/<seq> ), so that appending does not rewrite whole job.
+// Appending to the same job is serialized, not to give the same sequence to messages of parallel steps.
 func (s *storeServiceImpl) Append(uuid uuid.UUID, message model.Message) error {
-	job, err := s.findOrInitialize(uuid)
+	unlock := s.locks.lock(uuid)
+	defer unlock()
+
+	has, err := s.db.Has(jobKey(uuid), nil)
 	if err != nil {
 		return errors.WithStack(err)
 	}
+	if !has {
+		if err := s.Start(&model.Job{ID: uuid}); err != nil {
+			return errors.WithStack(err)
+		}
+	}
 
-	job.Stream = append(job.Stream, message)
+	seq, err := s.nextSeq(uuid)
+	if err != nil {
+		return errors.WithStack(err)
+	}
 
-	data, err := json.Marshal(job)
+	data, err := json.Marshal(message)
 	if err != nil {
 		return errors.WithStack(err)
 	}
-	if err := s.db.Put([]byte(uuid.String()), data, nil); err != nil {
+	if err := s.db.Put(lineKey(uuid, seq), data, nil); err != nil {
 		return errors.WithStack(err)
 	}
+	s.subscribers.publish(uuid)
 	return nil
 }
 
-func (s *storeServiceImpl) findOrInitialize(uuid uuid.UUID) (*model.Job, error) {
-	job := &model.Job{}
+func (s *storeServiceImpl) nextSeq(uuid uuid.UUID) (int, error) {
+	iter := s.db.NewIterator(store.Prefix(linePrefix(uuid)), nil)
+	defer iter.Release()
 
-	data, err := s.db.Get([]byte(uuid.String()), nil)
-	if err == store.NotFoundError {
-		return job, nil
+	if !iter.Last() {
+		return 0, errors.WithStack(iter.Error())
 	}
+	seq, err := strconv.Atoi(string(bytes.TrimPrefix(iter.Key(), linePrefix(uuid))))
 	if err != nil {
-		return nil, errors.WithStack(err)
-	}
-	if err := json.NewDecoder(bytes.NewReader(data)).Decode(job); err != nil {
-		return nil, errors.WithStack(err)
+		return 0, errors.WithStack(err)
 	}
-	return job, nil
+	return seq + 1, nil
 }
 
+// Get returns the job with all messages.
 func (s *storeServiceImpl) Get(uuid uuid.UUID) (*model.Job, error) {
-	data, err := s.db.Get([]byte(uuid.String()), nil)
+	return s.GetFrom(uuid, 0)
+}
+
+// GetFrom returns the job with messages from the offset.
+func (s *storeServiceImpl) GetFrom(uuid uuid.UUID, offset int) (*model.Job, error) {
+	job, err := s.metadata(uuid)
 	if err != nil {
 		return nil, errors.WithStack(err)
 	}
 
-	job := &model.Job{}
-	if err := json.NewDecoder(bytes.NewReader(data)).Decode(job); err != nil {
+	// stored in legacy format ( whole stream in the job )
+	if len(job.Stream) > 0 {
+		if offset > len(job.Stream) {
+			offset = len(job.Stream)
+		}
+		job.Stream = job.Stream[offset:]
+		return job, nil
+	}
+
+	iter := s.db.NewIterator(&store.Range{
+		Start: lineKey(uuid, offset),
+		Limit: store.Prefix(linePrefix(uuid)).Limit,
+	}, nil)
+	defer iter.Release()
+
+	job.Stream = []model.Message{}
+	for iter.Next() {
+		msg := model.Message{}
+		if err := json.NewDecoder(bytes.NewReader(iter.Value())).Decode(&msg); err != nil {
+			return nil, errors.WithStack(err)
+		}
+		job.Stream = append(job.Stream, msg)
+	}
+	if err := iter.Error(); err != nil {
 		return nil, errors.WithStack(err)
 	}
 	return job, nil
 }
 
-func (s *storeServiceImpl) Start(uuid uuid.UUID) error {
-	started, _ := json.Marshal(&model.Job{Finished: false})
-	if err := s.db.Put([]byte(uuid.String()), started, nil); err != nil {
+// Start stores metadata of the job as queued.
+// Indexes of the job stored before, e.g. queued job resumed, are replaced.
+func (s *storeServiceImpl) Start(job *model.Job) error {
+	if err := s.deleteIndexes(job.ID); err != nil {
 		return errors.WithStack(err)
 	}
-	return nil
+
+	job.State = model.QUEUED
+	job.QueuedAt = clock.Now()
+	job.Finished = false
+	job.Stream = nil
+	if err := s.put(job); err != nil {
+		return errors.WithStack(err)
+	}
+	return s.putIndexes(job)
 }
 
-func (s *storeServiceImpl) Finish(uuid uuid.UUID) error {
-	data, err := s.db.Get([]byte(uuid.String()), nil)
+// Running marks the job as running.
+func (s *storeServiceImpl) Running(uuid uuid.UUID) error {
+	return s.update(uuid, func(job *model.Job) {
+		job.State = model.RUNNING
+		job.StartedAt = clock.Now()
+	})
+}
+
+// Finish marks the job as finished with the state and exit code.
+func (s *storeServiceImpl) Finish(uuid uuid.UUID, state model.State, exitCode int64) error {
+	return s.update(uuid, func(job *model.Job) {
+		job.State = state
+		job.ExitCode = exitCode
+		job.FinishedAt = clock.Now()
+		job.Finished = true
+	})
+}
+
+func (s *storeServiceImpl) update(uuid uuid.UUID, update func(job *model.Job)) error {
+	job, err := s.metadata(uuid)
 	if err != nil {
 		return errors.WithStack(err)
 	}
 
-	job := &model.Job{}
-	if err := json.NewDecoder(bytes.NewReader(data)).Decode(job); err != nil {
+	// legacy data does not have its id
+	job.ID = uuid
+	state := job.State
+	update(job)
+	if err := s.put(job); err != nil {
+		return errors.WithStack(err)
+	}
+	if err := s.updateStateIndex(job, state); err != nil {
 		return errors.WithStack(err)
 	}
+	s.subscribers.publish(uuid)
+	return nil
+}
+
+// Subscribe returns a channel notified when messages are appended to the job or its state is changed,
+// and a function to unsubscribe.
+func (s *storeServiceImpl) Subscribe(uuid uuid.UUID) (<-chan struct{}, func()) {
+	return s.subscribers.subscribe(uuid)
+}
+
+// metadata returns the job stored under its uuid, which does not contain messages except legacy format.
+func (s *storeServiceImpl) metadata(uuid uuid.UUID) (*model.Job, error) {
+	data, err := s.db.Get(jobKey(uuid), nil)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
 
-	job.Finished = true
+	job := &model.Job{}
+	if err := json.NewDecoder(bytes.NewReader(data)).Decode(job); err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return job, nil
+}
 
-	finished, err := json.Marshal(job)
+func (s *storeServiceImpl) put(job *model.Job) error {
+	data, err := json.Marshal(job)
 	if err != nil {
 		return errors.WithStack(err)
 	}
-	if err := s.db.Put([]byte(uuid.String()), finished, nil); err != nil {
+	if err := s.db.Put(jobKey(job.ID), data, nil); err != nil {
 		return errors.WithStack(err)
 	}
 	return nil
@@ -117,3 +215,15 @@ func (s *storeServiceImpl) Close() error {
 	}
 	return nil
 }
+
+func jobKey(uuid uuid.UUID) []byte {
+	return []byte(uuid.String())
+}
+
+func linePrefix(uuid uuid.UUID) []byte {
+	return []byte(fmt.Sprintf("%s/", uuid.String()))
+}
+
+func lineKey(uuid uuid.UUID, seq int) []byte {
+	return []byte(fmt.Sprintf("%s/%010d", uuid.String(), seq))
+}
@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"github.com/duck8823/duci/data/model"
 	"github.com/duck8823/duci/infrastructure/clock"
 	"github.com/duck8823/duci/infrastructure/store"
@@ -11,171 +12,134 @@ import (
 	"github.com/golang/mock/gomock"
 	"github.com/google/go-cmp/cmp"
 	"github.com/google/uuid"
+	"github.com/syndtr/goleveldb/leveldb"
+	"github.com/syndtr/goleveldb/leveldb/iterator"
+	"github.com/syndtr/goleveldb/leveldb/storage"
+	"runtime"
+	"sync"
 	"testing"
 	"time"
 )
 
 func TestNewStoreService(t *testing.T) {
+	// setup
+	ctrl := gomock.NewController(t)
+	mockStore := mock_store.NewMockStore(ctrl)
+
 	// when
-	actual, err := New()
+	actual := New(mockStore)
 
 	// then
 	if _, ok := actual.(*storeServiceImpl); !ok {
 		t.Error("must be a Service, but not.")
 	}
-
-	if err != nil {
-		t.Errorf("error must not occur, but got %+v", err)
-	}
 }
 
 func TestStoreServiceImpl_Append(t *testing.T) {
-	// setup
-	ctrl := gomock.NewController(t)
-	mockStore := mock_store.NewMockStore(ctrl)
-
-	service := &storeServiceImpl{mockStore}
-	t.Run("when store returns correct data", func(t *testing.T) {
-		// given
-		jst, err := time.LoadLocation("Asia/Tokyo")
+	t.Run("when job started", func(t *testing.T) {
+		// setup
+		db, err := leveldb.Open(storage.NewMemStorage(), nil)
 		if err != nil {
 			t.Fatalf("error occurred: %+v", err)
 		}
+		defer db.Close()
 
-		date1 := time.Date(2020, time.April, 1, 12, 3, 00, 00, jst)
-		date2 := time.Date(1987, time.March, 27, 19, 19, 00, 00, jst)
-		job := &model.Job{
-			Finished: false,
-			Stream:   []model.Message{{Time: date1, Text: "Hello World."}},
-		}
-		storedData, err := json.Marshal(job)
-		if err != nil {
-			t.Fatalf("error occurred: %+v", err)
-		}
+		service := &storeServiceImpl{db: db}
 
-		// and
+		// given
 		id, err := uuid.NewRandom()
 		if err != nil {
 			t.Fatalf("error occurred: %+v", err)
 		}
-		storedId := []byte(id.String())
-
-		// and
-		clock.Now = func() time.Time {
-			return date2
-		}
-
-		// and
-		expected := &model.Job{
-			Finished: false,
-			Stream: []model.Message{
-				{Time: date1, Text: "Hello World."},
-				{Time: date2, Text: "Hello Testing."},
-			},
-		}
-		expectedData, err := json.Marshal(expected)
-		if err != nil {
+		if err := service.Start(&model.Job{ID: id}); err != nil {
 			t.Fatalf("error occurred: %+v", err)
 		}
 
 		// and
-		mockStore.EXPECT().
-			Get(gomock.Eq(storedId), gomock.Nil()).
-			Times(1).
-			Return(storedData, nil)
-		mockStore.EXPECT().
-			Put(gomock.Eq(storedId), gomock.Eq(expectedData), gomock.Nil()).
-			Times(1).
-			Return(nil)
-		mockStore.EXPECT().
-			Put(gomock.Eq(storedId), gomock.Not(expectedData), gomock.Nil()).
-			Do(func(_ interface{}, data []byte, _ interface{}) {
-				t.Logf("wont: %s", string(expectedData))
-				t.Logf("got:  %s", string(data))
-			}).
-			Return(errors.New("must not call this"))
+		date1 := time.Date(2020, time.April, 1, 12, 3, 00, 00, time.UTC)
+		date2 := time.Date(1987, time.March, 27, 19, 19, 00, 00, time.UTC)
 
-		// expect
-		if err := service.Append(id, model.Message{Time: date2, Text: "Hello Testing."}); err != nil {
-			t.Errorf("error must not occur, but got %+v", err)
+		expected := []model.Message{
+			{Time: date1, Text: "Hello World."},
+			{Time: date2, Text: "Hello Testing."},
 		}
 
-		// cleanup
-		clock.Adjust()
-	})
+		// when
+		for _, msg := range expected {
+			if err := service.Append(id, msg); err != nil {
+				t.Errorf("error must not occur, but got %+v", err)
+			}
+		}
 
-	t.Run("when value not found", func(t *testing.T) {
-		// given
-		id, err := uuid.NewRandom()
-		if err != nil {
-			t.Fatalf("error occurred: %+v", err)
+		// then
+		for i, msg := range expected {
+			data, err := db.Get([]byte(fmt.Sprintf("%s/%010d", id, i)), nil)
+			if err != nil {
+				t.Fatalf("error occurred: %+v", err)
+			}
+
+			actual := model.Message{}
+			if err := json.Unmarshal(data, &actual); err != nil {
+				t.Fatalf("error occurred: %+v", err)
+			}
+			if !cmp.Equal(actual, msg) {
+				t.Errorf("wont %+v, but got %+v", msg, actual)
+			}
 		}
-		storedId := []byte(id.String())
+	})
 
-		// and
-		jst, err := time.LoadLocation("Asia/Tokyo")
+	t.Run("when job not started", func(t *testing.T) {
+		// setup
+		db, err := leveldb.Open(storage.NewMemStorage(), nil)
 		if err != nil {
 			t.Fatalf("error occurred: %+v", err)
 		}
-		clock.Now = func() time.Time {
-			return time.Date(1987, time.March, 27, 19, 19, 00, 00, jst)
-		}
+		defer db.Close()
 
-		// and
-		clock.Now = func() time.Time {
-			return time.Time{}
-		}
+		service := &storeServiceImpl{db: db}
 
-		expected := &model.Job{
-			Finished: false,
-			Stream: []model.Message{
-				{Time: clock.Now(), Text: "Hello Testing."},
-			},
-		}
-		expectedData, err := json.Marshal(expected)
+		// given
+		id, err := uuid.NewRandom()
 		if err != nil {
 			t.Fatalf("error occurred: %+v", err)
 		}
 
-		// and
-		mockStore.EXPECT().
-			Get(gomock.Eq(storedId), gomock.Nil()).
-			Times(1).
-			Return(nil, store.NotFoundError)
-		mockStore.EXPECT().
-			Put(gomock.Eq(storedId), gomock.Eq(expectedData), gomock.Nil()).
-			Times(1).
-			Return(nil)
-		mockStore.EXPECT().
-			Put(gomock.Eq(storedId), gomock.Not(expectedData), gomock.Nil()).
-			Do(func(_ interface{}, data []byte, _ interface{}) {
-				t.Logf("wont: %s", string(expectedData))
-				t.Logf("got:  %s", string(data))
-			}).
-			Return(errors.New("must not call this"))
+		// when
+		err = service.Append(id, model.Message{Text: "Hello Testing."})
 
-		// expect
-		if err := service.Append(id, model.Message{Time: time.Time{}, Text: "Hello Testing."}); err != nil {
+		// then
+		if err != nil {
 			t.Errorf("error must not occur, but got %+v", err)
 		}
 
-		// cleanup
-		clock.Adjust()
+		job, err := service.Get(id)
+		if err != nil {
+			t.Fatalf("error occurred: %+v", err)
+		}
+		if job.Finished || len(job.Stream) != 1 {
+			t.Errorf("job must have a message and not be finished, but got %+v", job)
+		}
 	})
 
-	t.Run("when store.Get returns error", func(t *testing.T) {
+	t.Run("when store.Has returns error", func(t *testing.T) {
+		// setup
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockStore := mock_store.NewMockStore(ctrl)
+		service := &storeServiceImpl{db: mockStore}
+
 		// given
 		id, err := uuid.NewRandom()
 		if err != nil {
 			t.Fatalf("error occurred: %+v", err)
 		}
-		storedId := []byte(id.String())
 
 		// and
 		mockStore.EXPECT().
-			Get(gomock.Eq(storedId), gomock.Nil()).
+			Has(gomock.Eq([]byte(id.String())), gomock.Nil()).
 			Times(1).
-			Return(nil, errors.New("hello testing"))
+			Return(false, errors.New("hello testing"))
 
 		// expect
 		if err := service.Append(id, model.Message{Text: "Hello Testing."}); err == nil {
@@ -183,80 +147,90 @@ func TestStoreServiceImpl_Append(t *testing.T) {
 		}
 	})
 
-	t.Run("when store.Get returns invalid data", func(t *testing.T) {
-		// given
-		storedData := []byte("invalid data")
+	t.Run("when store.Put returns error", func(t *testing.T) {
+		// setup
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
 
-		// and
+		mockStore := mock_store.NewMockStore(ctrl)
+		service := &storeServiceImpl{db: mockStore}
+
+		// given
 		id, err := uuid.NewRandom()
 		if err != nil {
 			t.Fatalf("error occurred: %+v", err)
 		}
-		storedId := []byte(id.String())
 
 		// and
 		mockStore.EXPECT().
-			Get(gomock.Eq(storedId), gomock.Nil()).
+			Has(gomock.Eq([]byte(id.String())), gomock.Nil()).
 			Times(1).
-			Return(storedData, nil)
+			Return(true, nil)
 		mockStore.EXPECT().
-			Put(gomock.Eq(storedId), gomock.Any(), gomock.Nil()).
+			NewIterator(gomock.Any(), gomock.Nil()).
 			Times(1).
-			Do(func(_, _, _ interface{}) {
-				t.Fatalf("must not call this.")
-			})
+			Return(iterator.NewEmptyIterator(nil))
+		mockStore.EXPECT().
+			Put(gomock.Eq([]byte(fmt.Sprintf("%s/%010d", id, 0))), gomock.Any(), gomock.Nil()).
+			Times(1).
+			Return(errors.New("hello error"))
 
 		// expect
 		if err := service.Append(id, model.Message{Text: "Hello Testing."}); err == nil {
 			t.Error("error must occur, but got nil")
 		}
 	})
-
-	t.Run("when store.Put returns invalid data", func(t *testing.T) {
-		// given
-		job := &model.Job{
-			Finished: false,
-			Stream:   []model.Message{{Time: clock.Now(), Text: "Hello World."}},
-		}
-		storedData, err := json.Marshal(job)
+	t.Run("when appended in parallel", func(t *testing.T) {
+		// setup
+		db, err := leveldb.Open(storage.NewMemStorage(), nil)
 		if err != nil {
 			t.Fatalf("error occurred: %+v", err)
 		}
+		defer db.Close()
 
-		// and
+		// switch goroutines between reading sequence and putting message
+		service := &storeServiceImpl{db: &yieldingStore{db}}
+
+		// given
 		id, err := uuid.NewRandom()
 		if err != nil {
 			t.Fatalf("error occurred: %+v", err)
 		}
-		storedId := []byte(id.String())
 
-		// and
-		jst, err := time.LoadLocation("Asia/Tokyo")
+		// when
+		var wg sync.WaitGroup
+		for i := 0; i < 4; i++ {
+			wg.Add(1)
+			go func(step int) {
+				defer wg.Done()
+				for j := 0; j < 200; j++ {
+					if err := service.Append(id, model.Message{Text: fmt.Sprintf("step %d line %d", step, j)}); err != nil {
+						t.Errorf("error must not occur, but got %+v", err)
+					}
+				}
+			}(i)
+		}
+		wg.Wait()
+
+		// then
+		job, err := service.Get(id)
 		if err != nil {
 			t.Fatalf("error occurred: %+v", err)
 		}
-		clock.Now = func() time.Time {
-			return time.Date(1987, time.March, 27, 19, 19, 00, 00, jst)
+		if len(job.Stream) != 800 {
+			t.Errorf("all messages must be stored, but got %d", len(job.Stream))
 		}
+	})
+}
 
-		// and
-		mockStore.EXPECT().
-			Get(gomock.Eq(storedId), gomock.Nil()).
-			Times(1).
-			Return(storedData, nil)
-		mockStore.EXPECT().
-			Put(gomock.Eq(storedId), gomock.Any(), gomock.Nil()).
-			Times(1).
-			Return(errors.New("hello error"))
-
-		// expect
-		if err := service.Append(id, model.Message{Text: "Hello Testing."}); err == nil {
-			t.Error("error must occur, but got nil")
-		}
+// yieldingStore yields the processor to other goroutines before putting.
+type yieldingStore struct {
+	store.Store
+}
 
-		// cleanup
-		clock.Adjust()
-	})
+func (s *yieldingStore) Put(key []byte, value []byte, opts *store.WriteOptions) error {
+	runtime.Gosched()
+	return s.Store.Put(key, value, opts)
 }
 
 func TestStoreServiceImpl_Get(t *testing.T) {
@@ -264,7 +238,7 @@ func TestStoreServiceImpl_Get(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	mockStore := mock_store.NewMockStore(ctrl)
 
-	service := &storeServiceImpl{mockStore}
+	service := &storeServiceImpl{db: mockStore}
 	t.Run("with error", func(t *testing.T) {
 		// setup
 		id, err := uuid.NewRandom()
@@ -322,7 +296,7 @@ func TestStoreServiceImpl_Get(t *testing.T) {
 		}
 	})
 
-	t.Run("with stored data", func(t *testing.T) {
+	t.Run("with stored data in legacy format", func(t *testing.T) {
 		// given
 		clock.Now = func() time.Time {
 			return time.Unix(0, 0)
@@ -367,13 +341,75 @@ func TestStoreServiceImpl_Get(t *testing.T) {
 	})
 }
 
+func TestStoreServiceImpl_GetFrom(t *testing.T) {
+	// setup
+	db, err := leveldb.Open(storage.NewMemStorage(), nil)
+	if err != nil {
+		t.Fatalf("error occurred: %+v", err)
+	}
+	defer db.Close()
+
+	service := &storeServiceImpl{db: db}
+
+	// given
+	id, err := uuid.NewRandom()
+	if err != nil {
+		t.Fatalf("error occurred: %+v", err)
+	}
+
+	// and
+	var messages []model.Message
+	for i := 0; i < 12; i++ {
+		msg := model.Message{Time: time.Unix(int64(i), 0).UTC(), Text: fmt.Sprintf("line %d", i)}
+		if err := service.Append(id, msg); err != nil {
+			t.Fatalf("error occurred: %+v", err)
+		}
+		messages = append(messages, msg)
+	}
+	if err := service.Finish(id, model.SUCCESS, 0); err != nil {
+		t.Fatalf("error occurred: %+v", err)
+	}
+
+	for _, offset := range []int{0, 5, 11, 12, 20} {
+		t.Run(fmt.Sprintf("from %d", offset), func(t *testing.T) {
+			// given
+			expected := []model.Message{}
+			if offset < len(messages) {
+				expected = messages[offset:]
+			}
+
+			// when
+			actual, err := service.GetFrom(id, offset)
+
+			// then
+			if err != nil {
+				t.Fatalf("error must not occur, but got %+v", err)
+			}
+
+			if !actual.Finished {
+				t.Error("job must be finished")
+			}
+
+			if !cmp.Equal(actual.Stream, expected) {
+				t.Errorf("find differences: %+v", cmp.Diff(actual.Stream, expected))
+			}
+		})
+	}
+}
+
 func TestStoreServiceImpl_Start(t *testing.T) {
 	// setup
 	ctrl := gomock.NewController(t)
 	mockStore := mock_store.NewMockStore(ctrl)
 
-	service := &storeServiceImpl{mockStore}
+	service := &storeServiceImpl{db: mockStore}
 	t.Run("when put success", func(t *testing.T) {
+		// setup
+		clock.Now = func() time.Time {
+			return time.Unix(10, 0)
+		}
+		defer clock.Adjust()
+
 		// given
 		id, err := uuid.NewRandom()
 		if err != nil {
@@ -382,19 +418,38 @@ func TestStoreServiceImpl_Start(t *testing.T) {
 		storedId := []byte(id.String())
 
 		// and
-		expected, err := json.Marshal(&model.Job{Finished: false})
+		job := &model.Job{ID: id, Repository: "duck8823/duci", Ref: "refs/heads/master", Trigger: model.PUSH}
+
+		// and
+		expected, err := json.Marshal(&model.Job{
+			ID:         id,
+			Repository: "duck8823/duci",
+			Ref:        "refs/heads/master",
+			Trigger:    model.PUSH,
+			State:      model.QUEUED,
+			QueuedAt:   time.Unix(10, 0),
+		})
 		if err != nil {
 			t.Fatalf("error occurred: %+v", err)
 		}
 
 		// and
+		mockStore.EXPECT().
+			Get(gomock.Eq(storedId), gomock.Nil()).
+			Times(1).
+			Return(nil, store.NotFoundError)
 		mockStore.EXPECT().
 			Put(gomock.Eq(storedId), gomock.Eq(expected), gomock.Nil()).
 			Times(1).
 			Return(nil)
+		// indexes of time, state, repository and ref
+		mockStore.EXPECT().
+			Put(gomock.Not(gomock.Eq(storedId)), gomock.Eq([]byte{}), gomock.Nil()).
+			Times(4).
+			Return(nil)
 
 		// when
-		err = service.Start(id)
+		err = service.Start(job)
 
 		// then
 		if err != nil {
@@ -410,13 +465,17 @@ func TestStoreServiceImpl_Start(t *testing.T) {
 		}
 
 		// and
+		mockStore.EXPECT().
+			Get(gomock.Any(), gomock.Any()).
+			Times(1).
+			Return(nil, store.NotFoundError)
 		mockStore.EXPECT().
 			Put(gomock.Any(), gomock.Any(), gomock.Any()).
 			Times(1).
 			Return(errors.New("test error"))
 
 		// when
-		err = service.Start(id)
+		err = service.Start(&model.Job{ID: id})
 
 		// then
 		if err == nil {
@@ -425,12 +484,82 @@ func TestStoreServiceImpl_Start(t *testing.T) {
 	})
 }
 
+func TestStoreServiceImpl_Running(t *testing.T) {
+	t.Run("with started job", func(t *testing.T) {
+		// setup
+		db, err := leveldb.Open(storage.NewMemStorage(), nil)
+		if err != nil {
+			t.Fatalf("error occurred: %+v", err)
+		}
+		defer db.Close()
+
+		service := &storeServiceImpl{db: db}
+
+		// and
+		clock.Now = func() time.Time {
+			return time.Unix(30, 0).UTC()
+		}
+		defer clock.Adjust()
+
+		// given
+		id, err := uuid.NewRandom()
+		if err != nil {
+			t.Fatalf("error occurred: %+v", err)
+		}
+		if err := service.Start(&model.Job{ID: id, TaskName: "duci/push"}); err != nil {
+			t.Fatalf("error occurred: %+v", err)
+		}
+
+		// when
+		err = service.Running(id)
+
+		// then
+		if err != nil {
+			t.Fatalf("error must not occur, but got %+v", err)
+		}
+
+		// and
+		actual, err := service.Get(id)
+		if err != nil {
+			t.Fatalf("error occurred: %+v", err)
+		}
+
+		if actual.State != model.RUNNING {
+			t.Errorf("state must be %s, but got %s", model.RUNNING, actual.State)
+		}
+
+		if actual.TaskName != "duci/push" {
+			t.Errorf("task name must be duci/push, but got %s", actual.TaskName)
+		}
+
+		if !actual.StartedAt.Equal(time.Unix(30, 0)) {
+			t.Errorf("started at must be %s, but got %s", time.Unix(30, 0), actual.StartedAt)
+		}
+	})
+
+	t.Run("when job not found", func(t *testing.T) {
+		// setup
+		db, err := leveldb.Open(storage.NewMemStorage(), nil)
+		if err != nil {
+			t.Fatalf("error occurred: %+v", err)
+		}
+		defer db.Close()
+
+		service := &storeServiceImpl{db: db}
+
+		// expect
+		if err := service.Running(uuid.New()); err == nil {
+			t.Error("error must occur, but got nil")
+		}
+	})
+}
+
 func TestStoreServiceImpl_Finish(t *testing.T) {
 	// setup
 	ctrl := gomock.NewController(t)
 	mockStore := mock_store.NewMockStore(ctrl)
 
-	service := &storeServiceImpl{mockStore}
+	service := &storeServiceImpl{db: mockStore}
 	t.Run("with error", func(t *testing.T) {
 		// setup
 		id, err := uuid.NewRandom()
@@ -446,7 +575,7 @@ func TestStoreServiceImpl_Finish(t *testing.T) {
 			Return(nil, errors.New("hello testing"))
 
 		// expect
-		if err := service.Finish(id); err == nil {
+		if err := service.Finish(id, model.FAILURE, 1); err == nil {
 			t.Error("error must occur, but got nil")
 		}
 	})
@@ -469,14 +598,21 @@ func TestStoreServiceImpl_Finish(t *testing.T) {
 			Return(storedData, nil)
 
 		// expect
-		if err := service.Finish(id); err == nil {
+		if err := service.Finish(id, model.FAILURE, 1); err == nil {
 			t.Error("error must occur, but got nil")
 		}
 	})
 
 	t.Run("with stored data", func(t *testing.T) {
+		// setup
+		clock.Now = func() time.Time {
+			return time.Unix(20, 0)
+		}
+		defer clock.Adjust()
+
 		// given
 		given := &model.Job{
+			State:    model.RUNNING,
 			Finished: false,
 			Stream:   []model.Message{{Time: time.Unix(0, 0), Text: "Hello World."}},
 		}
@@ -486,21 +622,25 @@ func TestStoreServiceImpl_Finish(t *testing.T) {
 		}
 
 		// and
-		expected := &model.Job{
-			Finished: true,
-			Stream:   []model.Message{{Time: time.Unix(0, 0), Text: "Hello World."}},
-		}
-		expectedData, err := json.Marshal(expected)
+		id, err := uuid.NewRandom()
 		if err != nil {
 			t.Fatalf("error occurred: %+v", err)
 		}
+		storedId := []byte(id.String())
 
 		// and
-		id, err := uuid.NewRandom()
+		expected := &model.Job{
+			ID:         id,
+			State:      model.FAILURE,
+			ExitCode:   1,
+			FinishedAt: time.Unix(20, 0),
+			Finished:   true,
+			Stream:     []model.Message{{Time: time.Unix(0, 0), Text: "Hello World."}},
+		}
+		expectedData, err := json.Marshal(expected)
 		if err != nil {
 			t.Fatalf("error occurred: %+v", err)
 		}
-		storedId := []byte(id.String())
 
 		// and
 		mockStore.EXPECT().
@@ -519,7 +659,7 @@ func TestStoreServiceImpl_Finish(t *testing.T) {
 			})
 
 		// when
-		err = service.Finish(id)
+		err = service.Finish(id, model.FAILURE, 1)
 
 		// and
 		if err != nil {
@@ -556,18 +696,103 @@ func TestStoreServiceImpl_Finish(t *testing.T) {
 			Return(errors.New("hello testing"))
 
 		// expect
-		if err := service.Finish(id); err == nil {
+		if err := service.Finish(id, model.FAILURE, 1); err == nil {
 			t.Error("error must occur, but got nil")
 		}
 	})
 }
 
+func TestStoreServiceImpl_Subscribe(t *testing.T) {
+	// setup
+	db, err := leveldb.Open(storage.NewMemStorage(), nil)
+	if err != nil {
+		t.Fatalf("error occurred: %+v", err)
+	}
+	defer db.Close()
+
+	service := &storeServiceImpl{db: db}
+
+	// given
+	id, err := uuid.NewRandom()
+	if err != nil {
+		t.Fatalf("error occurred: %+v", err)
+	}
+	other, err := uuid.NewRandom()
+	if err != nil {
+		t.Fatalf("error occurred: %+v", err)
+	}
+
+	// and
+	changed, unsubscribe := service.Subscribe(id)
+
+	t.Run("when other job appended", func(t *testing.T) {
+		// when
+		if err := service.Append(other, model.Message{Text: "Hello World."}); err != nil {
+			t.Fatalf("error occurred: %+v", err)
+		}
+
+		// then
+		select {
+		case <-changed:
+			t.Error("must not be notified")
+		default:
+		}
+	})
+
+	t.Run("when appended", func(t *testing.T) {
+		// when
+		for i := 0; i < 3; i++ {
+			if err := service.Append(id, model.Message{Text: "Hello World."}); err != nil {
+				t.Fatalf("error occurred: %+v", err)
+			}
+		}
+
+		// then
+		select {
+		case <-changed:
+		default:
+			t.Error("must be notified")
+		}
+	})
+
+	t.Run("when finished", func(t *testing.T) {
+		// when
+		if err := service.Finish(id, model.SUCCESS, 0); err != nil {
+			t.Fatalf("error occurred: %+v", err)
+		}
+
+		// then
+		select {
+		case <-changed:
+		default:
+			t.Error("must be notified")
+		}
+	})
+
+	t.Run("when unsubscribed", func(t *testing.T) {
+		// given
+		unsubscribe()
+
+		// when
+		if err := service.Append(id, model.Message{Text: "Hello World."}); err != nil {
+			t.Fatalf("error occurred: %+v", err)
+		}
+
+		// then
+		select {
+		case <-changed:
+			t.Error("must not be notified")
+		default:
+		}
+	})
+}
+
 func TestStoreServiceImpl_Close(t *testing.T) {
 	// setup
 	ctrl := gomock.NewController(t)
 	mockStore := mock_store.NewMockStore(ctrl)
 
-	service := &storeServiceImpl{mockStore}
+	service := &storeServiceImpl{db: mockStore}
 	t.Run("with error", func(t *testing.T) {
 		// given
 		mockStore.EXPECT().
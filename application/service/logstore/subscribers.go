@@ -0,0 +1,50 @@
+package logstore
+
+import (
+	"github.com/google/uuid"
+	"sync"
+)
+
+// subscribers holds channels notified when the job is changed.
+type subscribers struct {
+	mu   sync.Mutex
+	subs map[uuid.UUID]map[chan struct{}]struct{}
+}
+
+func (s *subscribers) subscribe(id uuid.UUID) (<-chan struct{}, func()) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.subs == nil {
+		s.subs = make(map[uuid.UUID]map[chan struct{}]struct{})
+	}
+	if s.subs[id] == nil {
+		s.subs[id] = make(map[chan struct{}]struct{})
+	}
+
+	// buffered, so that successive notifications are coalesced without blocking publisher
+	ch := make(chan struct{}, 1)
+	s.subs[id][ch] = struct{}{}
+
+	return ch, func() {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+
+		delete(s.subs[id], ch)
+		if len(s.subs[id]) == 0 {
+			delete(s.subs, id)
+		}
+	}
+}
+
+func (s *subscribers) publish(id uuid.UUID) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for ch := range s.subs[id] {
+		select {
+		case ch <- struct{}{}:
+		default:
+		}
+	}
+}
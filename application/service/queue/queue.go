@@ -0,0 +1,100 @@
+package queue
+
+import (
+	"bytes"
+	"encoding/json"
+	"github.com/duck8823/duci/data/model"
+	"github.com/duck8823/duci/infrastructure/clock"
+	"github.com/duck8823/duci/infrastructure/store"
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+	"sort"
+)
+
+var keyPrefix = []byte("queue/")
+
+type Service interface {
+	Push(job *model.QueuedJob) error
+	Running(id uuid.UUID) error
+	Done(id uuid.UUID) error
+	All() ([]*model.QueuedJob, error)
+}
+
+type storeServiceImpl struct {
+	db store.Store
+}
+
+func New(database store.Store) Service {
+	return &storeServiceImpl{database}
+}
+
+// Push stores the job as waiting for execution.
+func (s *storeServiceImpl) Push(job *model.QueuedJob) error {
+	job.Running = false
+	if job.QueuedAt.IsZero() {
+		job.QueuedAt = clock.Now()
+	}
+	return s.put(job)
+}
+
+// Running marks the job as running.
+func (s *storeServiceImpl) Running(id uuid.UUID) error {
+	data, err := s.db.Get(key(id), nil)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	job := &model.QueuedJob{}
+	if err := json.NewDecoder(bytes.NewReader(data)).Decode(job); err != nil {
+		return errors.WithStack(err)
+	}
+
+	job.Running = true
+	return s.put(job)
+}
+
+// Done removes the job from queue.
+func (s *storeServiceImpl) Done(id uuid.UUID) error {
+	if err := s.db.Delete(key(id), nil); err != nil {
+		return errors.WithStack(err)
+	}
+	return nil
+}
+
+// All returns jobs remaining in queue, in order of queued time.
+func (s *storeServiceImpl) All() ([]*model.QueuedJob, error) {
+	iter := s.db.NewIterator(store.Prefix(keyPrefix), nil)
+	defer iter.Release()
+
+	var jobs []*model.QueuedJob
+	for iter.Next() {
+		job := &model.QueuedJob{}
+		if err := json.NewDecoder(bytes.NewReader(iter.Value())).Decode(job); err != nil {
+			return nil, errors.WithStack(err)
+		}
+		jobs = append(jobs, job)
+	}
+	if err := iter.Error(); err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	sort.SliceStable(jobs, func(i, j int) bool {
+		return jobs[i].QueuedAt.Before(jobs[j].QueuedAt)
+	})
+	return jobs, nil
+}
+
+func (s *storeServiceImpl) put(job *model.QueuedJob) error {
+	data, err := json.Marshal(job)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	if err := s.db.Put(key(job.ID), data, nil); err != nil {
+		return errors.WithStack(err)
+	}
+	return nil
+}
+
+func key(id uuid.UUID) []byte {
+	return append(append([]byte{}, keyPrefix...), []byte(id.String())...)
+}
@@ -0,0 +1,134 @@
+package queue_test
+
+import (
+	"github.com/duck8823/duci/application/service/queue"
+	"github.com/duck8823/duci/data/model"
+	"github.com/duck8823/duci/infrastructure/clock"
+	"github.com/google/uuid"
+	"github.com/syndtr/goleveldb/leveldb"
+	"github.com/syndtr/goleveldb/leveldb/storage"
+	"testing"
+	"time"
+)
+
+func TestStoreServiceImpl_Push(t *testing.T) {
+	// setup
+	db, err := leveldb.Open(storage.NewMemStorage(), nil)
+	if err != nil {
+		t.Fatalf("error occurred: %+v", err)
+	}
+	defer db.Close()
+
+	service := queue.New(db)
+
+	// given
+	date1 := time.Date(2020, time.April, 1, 12, 3, 00, 00, time.UTC)
+	date2 := time.Date(1987, time.March, 27, 19, 19, 00, 00, time.UTC)
+
+	first := &model.QueuedJob{ID: uuid.New(), Ref: "first"}
+	second := &model.QueuedJob{ID: uuid.New(), Ref: "second"}
+
+	// when
+	clock.Now = func() time.Time { return date2 }
+	if err := service.Push(second); err != nil {
+		t.Fatalf("error must not occur, but got %+v", err)
+	}
+	clock.Now = func() time.Time { return date1 }
+	if err := service.Push(first); err != nil {
+		t.Fatalf("error must not occur, but got %+v", err)
+	}
+	clock.Adjust()
+
+	// then
+	jobs, err := service.All()
+	if err != nil {
+		t.Fatalf("error must not occur, but got %+v", err)
+	}
+
+	if len(jobs) != 2 {
+		t.Fatalf("length must be 2, but got %d", len(jobs))
+	}
+
+	if jobs[0].Ref != "second" || jobs[1].Ref != "first" {
+		t.Errorf("jobs must be ordered by queued time, but got %+v, %+v", jobs[0], jobs[1])
+	}
+
+	if jobs[0].Running || jobs[1].Running {
+		t.Error("jobs must not be running")
+	}
+}
+
+func TestStoreServiceImpl_Running(t *testing.T) {
+	// setup
+	db, err := leveldb.Open(storage.NewMemStorage(), nil)
+	if err != nil {
+		t.Fatalf("error occurred: %+v", err)
+	}
+	defer db.Close()
+
+	service := queue.New(db)
+
+	t.Run("when job exists", func(t *testing.T) {
+		// given
+		job := &model.QueuedJob{ID: uuid.New()}
+		if err := service.Push(job); err != nil {
+			t.Fatalf("error occurred: %+v", err)
+		}
+
+		// when
+		err := service.Running(job.ID)
+
+		// then
+		if err != nil {
+			t.Errorf("error must not occur, but got %+v", err)
+		}
+
+		jobs, err := service.All()
+		if err != nil {
+			t.Fatalf("error occurred: %+v", err)
+		}
+		if len(jobs) != 1 || !jobs[0].Running {
+			t.Errorf("job must be running, but got %+v", jobs)
+		}
+	})
+
+	t.Run("when job not exists", func(t *testing.T) {
+		// expect
+		if err := service.Running(uuid.New()); err == nil {
+			t.Error("error must occur, but got nil")
+		}
+	})
+}
+
+func TestStoreServiceImpl_Done(t *testing.T) {
+	// setup
+	db, err := leveldb.Open(storage.NewMemStorage(), nil)
+	if err != nil {
+		t.Fatalf("error occurred: %+v", err)
+	}
+	defer db.Close()
+
+	service := queue.New(db)
+
+	// given
+	job := &model.QueuedJob{ID: uuid.New()}
+	if err := service.Push(job); err != nil {
+		t.Fatalf("error occurred: %+v", err)
+	}
+
+	// when
+	err = service.Done(job.ID)
+
+	// then
+	if err != nil {
+		t.Errorf("error must not occur, but got %+v", err)
+	}
+
+	jobs, err := service.All()
+	if err != nil {
+		t.Fatalf("error occurred: %+v", err)
+	}
+	if len(jobs) != 0 {
+		t.Errorf("queue must be empty, but got %+v", jobs)
+	}
+}
@@ -0,0 +1,116 @@
+package reporter
+
+import (
+	"fmt"
+	"github.com/duck8823/duci/application/service/github"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+const (
+	failureLevel = "failure"
+	warningLevel = "warning"
+)
+
+var (
+	ansiPattern = regexp.MustCompile(`\x1b\[[0-9;]*[A-Za-z]`)
+
+	// ./main.go:12:5: undefined: foo ( compiler, vet ) or     main_test.go:23: message ( test )
+	goPattern = regexp.MustCompile(`^\s*(\S+\.go):(\d+)(?::\d+)?: (.+)$`)
+	// src/Main.java:12: error: cannot find symbol
+	javacPattern = regexp.MustCompile(`^(\S+\.java):(\d+): (error|warning): (.+)$`)
+	// [ERROR] /src/Main.java:[12,5] cannot find symbol ( maven )
+	mavenPattern = regexp.MustCompile(`^\[(ERROR|WARNING)\] (\S+\.java):\[(\d+),\d+\] (.+)$`)
+	// /src/index.js: line 1, col 10, Error - message (rule) ( eslint compact )
+	eslintCompactPattern = regexp.MustCompile(`^(\S+\.(?:js|jsx|mjs|ts|tsx|vue)): line (\d+), col \d+, (Error|Warning) - (.+)$`)
+	// eslint stylish prints file name followed by problems
+	//   1:10  error  message  rule
+	eslintFilePattern    = regexp.MustCompile(`^(\S+\.(?:js|jsx|mjs|ts|tsx|vue))$`)
+	eslintProblemPattern = regexp.MustCompile(`^\s+(\d+):\d+\s+(error|warning)\s+(.+?)(?:\s{2,}([\w@/-]+))?\s*$`)
+)
+
+// annotationParser parses output of Go, javac and eslint into annotations.
+type annotationParser struct {
+	repository string
+	// current file of eslint stylish format
+	file string
+}
+
+func (p *annotationParser) parse(line string) (*github.Annotation, bool) {
+	line = stripANSI(line)
+
+	if m := eslintFilePattern.FindStringSubmatch(line); m != nil {
+		p.file = m[1]
+		return nil, false
+	}
+	if m := eslintProblemPattern.FindStringSubmatch(line); m != nil && len(p.file) > 0 {
+		message := m[3]
+		if len(m[4]) > 0 {
+			message = fmt.Sprintf("%s (%s)", m[3], m[4])
+		}
+		return p.annotation(p.file, m[1], level(m[2]), message)
+	}
+	if len(strings.TrimSpace(line)) == 0 {
+		p.file = ""
+	}
+
+	if m := eslintCompactPattern.FindStringSubmatch(line); m != nil {
+		return p.annotation(m[1], m[2], level(m[3]), m[4])
+	}
+	if m := javacPattern.FindStringSubmatch(line); m != nil {
+		return p.annotation(m[1], m[2], level(m[3]), m[4])
+	}
+	if m := mavenPattern.FindStringSubmatch(line); m != nil {
+		return p.annotation(m[2], m[3], level(m[1]), m[4])
+	}
+	if m := goPattern.FindStringSubmatch(line); m != nil {
+		return p.annotation(m[1], m[2], failureLevel, m[3])
+	}
+	return nil, false
+}
+
+func (p *annotationParser) annotation(file string, line string, level string, message string) (*github.Annotation, bool) {
+	name, ok := p.path(file)
+	if !ok {
+		return nil, false
+	}
+	num, err := strconv.Atoi(line)
+	if err != nil {
+		return nil, false
+	}
+	return &github.Annotation{
+		Path:            name,
+		StartLine:       num,
+		EndLine:         num,
+		AnnotationLevel: level,
+		Message:         message,
+	}, true
+}
+
+// path returns the path relative to root of the repository.
+// Absolute paths in containers are resolved by the directory named after the repository.
+func (p *annotationParser) path(name string) (string, bool) {
+	if !strings.HasPrefix(name, "/") {
+		name = path.Clean(name)
+		return name, !strings.HasPrefix(name, "..")
+	}
+
+	dir := "/" + path.Base(p.repository) + "/"
+	if i := strings.Index(name, dir); i >= 0 {
+		return path.Clean(name[i+len(dir):]), true
+	}
+	return "", false
+}
+
+func level(s string) string {
+	if strings.ToLower(s) == "warning" {
+		return warningLevel
+	}
+	return failureLevel
+}
+
+func stripANSI(s string) string {
+	return ansiPattern.ReplaceAllString(s, "")
+}
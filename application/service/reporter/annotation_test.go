@@ -0,0 +1,116 @@
+package reporter
+
+import (
+	"github.com/duck8823/duci/application/service/github"
+	"github.com/google/go-cmp/cmp"
+	"testing"
+)
+
+func TestAnnotationParser_Parse(t *testing.T) {
+	for _, tt := range []struct {
+		name     string
+		lines    []string
+		expected []*github.Annotation
+	}{
+		{
+			name: "go compiler",
+			lines: []string{
+				"# github.com/duck8823/duci",
+				"./main.go:12:5: undefined: foo",
+			},
+			expected: []*github.Annotation{
+				{Path: "main.go", StartLine: 12, EndLine: 12, AnnotationLevel: "failure", Message: "undefined: foo"},
+			},
+		},
+		{
+			name: "go test",
+			lines: []string{
+				"--- FAIL: TestFoo (0.00s)",
+				"    foo_test.go:23: must be 1, but got 2",
+				"FAIL",
+			},
+			expected: []*github.Annotation{
+				{Path: "foo_test.go", StartLine: 23, EndLine: 23, AnnotationLevel: "failure", Message: "must be 1, but got 2"},
+			},
+		},
+		{
+			name: "go with absolute path in container",
+			lines: []string{
+				"/go/src/github.com/duck8823/duci/application/config.go:3:1: syntax error",
+				"/usr/local/go/src/fmt/print.go:1:1: not in repository",
+			},
+			expected: []*github.Annotation{
+				{Path: "application/config.go", StartLine: 3, EndLine: 3, AnnotationLevel: "failure", Message: "syntax error"},
+			},
+		},
+		{
+			name: "javac",
+			lines: []string{
+				"src/main/java/Main.java:7: error: cannot find symbol",
+				"src/main/java/Main.java:9: warning: [deprecation] foo() has been deprecated",
+			},
+			expected: []*github.Annotation{
+				{Path: "src/main/java/Main.java", StartLine: 7, EndLine: 7, AnnotationLevel: "failure", Message: "cannot find symbol"},
+				{Path: "src/main/java/Main.java", StartLine: 9, EndLine: 9, AnnotationLevel: "warning", Message: "[deprecation] foo() has been deprecated"},
+			},
+		},
+		{
+			name: "maven",
+			lines: []string{
+				"[ERROR] /build/duci/src/main/java/Main.java:[7,5] cannot find symbol",
+			},
+			expected: []*github.Annotation{
+				{Path: "src/main/java/Main.java", StartLine: 7, EndLine: 7, AnnotationLevel: "failure", Message: "cannot find symbol"},
+			},
+		},
+		{
+			name: "eslint stylish",
+			lines: []string{
+				"",
+				"/app/duci/src/index.js",
+				"  1:10  error    'foo' is defined but never used  no-unused-vars",
+				"  3:1   warning  Unexpected console statement     no-console",
+				"",
+				"\x1b[31m\x1b[1m✖ 2 problems (1 error, 1 warning)\x1b[22m\x1b[39m",
+			},
+			expected: []*github.Annotation{
+				{Path: "src/index.js", StartLine: 1, EndLine: 1, AnnotationLevel: "failure", Message: "'foo' is defined but never used (no-unused-vars)"},
+				{Path: "src/index.js", StartLine: 3, EndLine: 3, AnnotationLevel: "warning", Message: "Unexpected console statement (no-console)"},
+			},
+		},
+		{
+			name: "eslint compact",
+			lines: []string{
+				"src/index.js: line 1, col 10, Error - 'foo' is defined but never used. (no-unused-vars)",
+			},
+			expected: []*github.Annotation{
+				{Path: "src/index.js", StartLine: 1, EndLine: 1, AnnotationLevel: "failure", Message: "'foo' is defined but never used. (no-unused-vars)"},
+			},
+		},
+		{
+			name: "out of repository",
+			lines: []string{
+				"../other/main.go:1:1: error",
+			},
+			expected: []*github.Annotation{},
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			// given
+			parser := &annotationParser{repository: "duck8823/duci"}
+
+			// when
+			actual := []*github.Annotation{}
+			for _, line := range tt.lines {
+				if annotation, ok := parser.parse(line); ok {
+					actual = append(actual, annotation)
+				}
+			}
+
+			// then
+			if !cmp.Equal(actual, tt.expected) {
+				t.Errorf("find differences: %+v", cmp.Diff(actual, tt.expected))
+			}
+		})
+	}
+}
@@ -0,0 +1,187 @@
+package reporter
+
+import (
+	"fmt"
+	"github.com/duck8823/duci/application/context"
+	"github.com/duck8823/duci/application/service/github"
+	"github.com/duck8823/duci/data/model"
+	"github.com/duck8823/duci/infrastructure/clock"
+	"github.com/pkg/errors"
+	"gopkg.in/src-d/go-git.v4/plumbing"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// limits of the Checks API
+	maxTextLength  = 65535
+	maxAnnotations = 50
+)
+
+// interval to update output of the check run with progress
+var progressInterval = 10 * time.Second
+
+// checksReporter reports with check runs, which have logs and annotations.
+type checksReporter struct {
+	github github.Service
+	mu     sync.Mutex
+	runs   map[string]*checkRun
+}
+
+// checkRun is a check run in progress, which belongs to a task of the job.
+type checkRun struct {
+	mu          sync.Mutex
+	id          int64
+	repo        github.Repository
+	lines       []string
+	length      int
+	parser      *annotationParser
+	annotations []*github.Annotation
+	sent        int
+	updatedAt   time.Time
+}
+
+func (r *checksReporter) Report(ctx context.Context, repo github.Repository, hash plumbing.Hash, state model.State, description string) error {
+	run := r.run(ctx, repo)
+	run.mu.Lock()
+	defer run.mu.Unlock()
+
+	req := &github.CheckRun{Name: ctx.TaskName()}
+	switch state {
+	case model.QUEUED:
+		req.Status = "queued"
+	case model.RUNNING:
+		now := clock.Now()
+		req.Status = "in_progress"
+		req.StartedAt = &now
+	default:
+		now := clock.Now()
+		req.Status = "completed"
+		req.Conclusion = conclusion(state)
+		req.CompletedAt = &now
+		defer r.remove(ctx)
+	}
+	req.Output = run.output(description)
+
+	if run.id == 0 {
+		req.HeadSHA = hash.String()
+		id, err := r.github.CreateCheckRun(ctx, repo, req)
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		run.id = id
+	} else if err := r.github.UpdateCheckRun(ctx, repo, run.id, req); err != nil {
+		return errors.WithStack(err)
+	}
+	run.sent += len(req.Output.Annotations)
+	run.updatedAt = clock.Now()
+	return nil
+}
+
+// Log collects the message for output and annotations, and updates the check run at intervals.
+// Messages of runs not reported yet or already completed ( e.g. logged while cancelled job stops ) are skipped.
+func (r *checksReporter) Log(ctx context.Context, message model.Message) {
+	run, ok := r.registered(ctx)
+	if !ok {
+		return
+	}
+	run.mu.Lock()
+	defer run.mu.Unlock()
+
+	for _, line := range strings.Split(strings.TrimRight(message.Text, "\n"), "\n") {
+		run.append(line)
+	}
+
+	if run.id == 0 || clock.Now().Sub(run.updatedAt) < progressInterval {
+		return
+	}
+	// failure is logged by the service, and retried at the next interval
+	run.updatedAt = clock.Now()
+	req := &github.CheckRun{Name: ctx.TaskName(), Output: run.output("running")}
+	if err := r.github.UpdateCheckRun(ctx, run.repo, run.id, req); err == nil {
+		run.sent += len(req.Output.Annotations)
+	}
+}
+
+func (r *checksReporter) run(ctx context.Context, repo github.Repository) *checkRun {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.runs == nil {
+		r.runs = make(map[string]*checkRun)
+	}
+	run, ok := r.runs[runKey(ctx)]
+	if !ok {
+		run = &checkRun{repo: repo, parser: &annotationParser{repository: repo.GetFullName()}}
+		r.runs[runKey(ctx)] = run
+	}
+	return run
+}
+
+// registered returns the run reported, without creating it.
+func (r *checksReporter) registered(ctx context.Context) (*checkRun, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	run, ok := r.runs[runKey(ctx)]
+	return run, ok
+}
+
+func (r *checksReporter) remove(ctx context.Context) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	delete(r.runs, runKey(ctx))
+}
+
+func runKey(ctx context.Context) string {
+	return fmt.Sprintf("%s/%s", ctx.UUID(), ctx.TaskName())
+}
+
+// append keeps the tail of logs within the limit of text, and parses annotations.
+func (c *checkRun) append(line string) {
+	line = stripANSI(line)
+	c.lines = append(c.lines, line)
+	c.length += len(line) + 1
+	for c.length > maxTextLength-1024 && len(c.lines) > 1 {
+		c.length -= len(c.lines[0]) + 1
+		c.lines = c.lines[1:]
+	}
+
+	if c.parser == nil || len(c.annotations) >= maxAnnotations {
+		return
+	}
+	if annotation, ok := c.parser.parse(line); ok {
+		c.annotations = append(c.annotations, annotation)
+	}
+}
+
+// output returns logs and annotations not sent yet, because annotations are appended on each update.
+func (c *checkRun) output(title string) *github.CheckRunOutput {
+	summary := fmt.Sprintf("%d annotation(s)", len(c.annotations))
+	if len(c.annotations) >= maxAnnotations {
+		summary = fmt.Sprintf("%d annotation(s), the rest are omitted", len(c.annotations))
+	}
+
+	output := &github.CheckRunOutput{
+		Title:       title,
+		Summary:     summary,
+		Annotations: c.annotations[c.sent:],
+	}
+	if len(c.lines) > 0 {
+		output.Text = fmt.Sprintf("```\n%s\n```", strings.Join(c.lines, "\n"))
+	}
+	return output
+}
+
+func conclusion(state model.State) string {
+	switch state {
+	case model.SUCCESS:
+		return "success"
+	case model.CANCELLED:
+		return "cancelled"
+	default:
+		return "failure"
+	}
+}
@@ -0,0 +1,196 @@
+package reporter_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/duck8823/duci/application"
+	"github.com/duck8823/duci/application/context"
+	"github.com/duck8823/duci/application/service/github"
+	"github.com/duck8823/duci/application/service/reporter"
+	"github.com/duck8823/duci/data/model"
+	"github.com/duck8823/duci/infrastructure/clock"
+	"github.com/google/uuid"
+	"gopkg.in/src-d/go-git.v4/plumbing"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeGitHub records requests of the Checks API.
+type fakeGitHub struct {
+	mu       sync.Mutex
+	requests []fakeRequest
+}
+
+type fakeRequest struct {
+	method string
+	path   string
+	accept string
+	body   *github.CheckRun
+}
+
+func (f *fakeGitHub) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	body := &github.CheckRun{}
+	if err := json.NewDecoder(r.Body).Decode(body); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	f.requests = append(f.requests, fakeRequest{method: r.Method, path: r.URL.Path, accept: r.Header.Get("Accept"), body: body})
+
+	w.Header().Set("Content-Type", "application/json")
+	fmt.Fprint(w, `{"id": 42}`)
+}
+
+func TestChecksReporter(t *testing.T) {
+	// setup
+	fake := &fakeGitHub{}
+	server := httptest.NewServer(fake)
+	defer server.Close()
+
+	reset := application.Config.GitHub.BaseURL
+	defer func() {
+		application.Config.GitHub.BaseURL = reset
+	}()
+	application.Config.GitHub.BaseURL = server.URL
+
+	githubService, err := github.New()
+	if err != nil {
+		t.Fatalf("error occurred: %+v", err)
+	}
+
+	reporter.SetProgressInterval(0)
+	defer reporter.SetProgressInterval(10 * time.Second)
+
+	defer clock.Adjust()
+	clock.Now = func() time.Time {
+		return time.Date(2018, time.October, 1, 0, 0, 0, 0, time.UTC)
+	}
+
+	// and
+	sut := reporter.NewChecksReporter(githubService)
+
+	// given
+	id := uuid.New()
+	ctx := context.New("duci/push", id, &url.URL{Scheme: "http", Host: "duci:8080"})
+	repo := &MockRepo{FullName: "duck8823/duci"}
+	hash := plumbing.NewHash("3c2a3c8a0f1a5b4e6a0b3a1f1e5d0c9b8a7f6e5d")
+
+	// when
+	if err := sut.Report(ctx, repo, hash, model.RUNNING, "started job"); err != nil {
+		t.Fatalf("error must not occur, but got %+v", err)
+	}
+	sut.Log(ctx, model.Message{Text: "\x1b[32mgo vet ./...\x1b[0m"})
+	sut.Log(ctx, model.Message{Text: "./main.go:12:5: undefined: foo\n"})
+	if err := sut.Report(ctx, repo, hash, model.FAILURE, "failure job"); err != nil {
+		t.Fatalf("error must not occur, but got %+v", err)
+	}
+
+	// then
+	if len(fake.requests) != 4 {
+		t.Fatalf("must request 4 times, but got %d", len(fake.requests))
+	}
+
+	for _, req := range fake.requests {
+		if req.accept != "application/vnd.github.antiope-preview+json" {
+			t.Errorf("must accept preview, but got %s", req.accept)
+		}
+	}
+
+	// and
+	created := fake.requests[0]
+	if created.method != "POST" || created.path != "/repos/duck8823/duci/check-runs" {
+		t.Errorf("must create check run, but got %s %s", created.method, created.path)
+	}
+	if created.body.Name != "duci/push" || created.body.HeadSHA != hash.String() || created.body.Status != "in_progress" {
+		t.Errorf("invalid check run: %+v", created.body)
+	}
+	if created.body.DetailsURL != fmt.Sprintf("http://duci:8080/logs/%s", id) {
+		t.Errorf("details url must be logs of the job, but got %s", created.body.DetailsURL)
+	}
+
+	// and
+	progress := fake.requests[2]
+	if progress.method != "PATCH" || progress.path != "/repos/duck8823/duci/check-runs/42" {
+		t.Errorf("must update check run, but got %s %s", progress.method, progress.path)
+	}
+	if !strings.Contains(progress.body.Output.Text, "go vet ./...\n./main.go:12:5: undefined: foo") {
+		t.Errorf("text must contain logs without escape sequences, but got %s", progress.body.Output.Text)
+	}
+	if len(progress.body.Output.Annotations) != 1 {
+		t.Errorf("must have an annotation, but got %+v", progress.body.Output.Annotations)
+	}
+
+	// and
+	completed := fake.requests[3]
+	if completed.body.Status != "completed" || completed.body.Conclusion != "failure" || completed.body.CompletedAt == nil {
+		t.Errorf("must be completed with failure, but got %+v", completed.body)
+	}
+	if completed.body.Output.Title != "failure job" {
+		t.Errorf("title must be description, but got %s", completed.body.Output.Title)
+	}
+	if len(completed.body.Output.Annotations) != 0 {
+		t.Errorf("annotations already sent must not be sent again, but got %+v", completed.body.Output.Annotations)
+	}
+}
+
+func TestChecksReporter_Log(t *testing.T) {
+	// setup
+	fake := &fakeGitHub{}
+	server := httptest.NewServer(fake)
+	defer server.Close()
+
+	reset := application.Config.GitHub.BaseURL
+	defer func() {
+		application.Config.GitHub.BaseURL = reset
+	}()
+	application.Config.GitHub.BaseURL = server.URL
+
+	githubService, err := github.New()
+	if err != nil {
+		t.Fatalf("error occurred: %+v", err)
+	}
+
+	// and
+	sut := reporter.NewChecksReporter(githubService)
+	repo := &MockRepo{FullName: "duck8823/duci"}
+	hash := plumbing.NewHash("3c2a3c8a0f1a5b4e6a0b3a1f1e5d0c9b8a7f6e5d")
+
+	t.Run("before reported", func(t *testing.T) {
+		// given
+		ctx := context.New("duci/push", uuid.New(), &url.URL{})
+
+		// when
+		sut.Log(ctx, model.Message{Text: "cloning"})
+
+		// then
+		if runs := reporter.Runs(sut); runs != 0 {
+			t.Errorf("run must not be kept, but got %d", runs)
+		}
+	})
+
+	t.Run("after completed", func(t *testing.T) {
+		// given
+		ctx := context.New("duci/push", uuid.New(), &url.URL{})
+		if err := sut.Report(ctx, repo, hash, model.RUNNING, "started job"); err != nil {
+			t.Fatalf("error occurred: %+v", err)
+		}
+		if err := sut.Report(ctx, repo, hash, model.CANCELLED, "cancelled"); err != nil {
+			t.Fatalf("error occurred: %+v", err)
+		}
+
+		// when
+		sut.Log(ctx, model.Message{Text: "stopping container"})
+
+		// then
+		if runs := reporter.Runs(sut); runs != 0 {
+			t.Errorf("run must not be kept, but got %d", runs)
+		}
+	})
+}
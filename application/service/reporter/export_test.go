@@ -0,0 +1,21 @@
+package reporter
+
+import (
+	"github.com/duck8823/duci/application/service/github"
+	"time"
+)
+
+func SetProgressInterval(interval time.Duration) {
+	progressInterval = interval
+}
+
+func NewChecksReporter(githubService github.Service) Reporter {
+	return &checksReporter{github: githubService}
+}
+
+func Runs(r Reporter) int {
+	checks := r.(*checksReporter)
+	checks.mu.Lock()
+	defer checks.mu.Unlock()
+	return len(checks.runs)
+}
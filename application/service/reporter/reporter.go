@@ -0,0 +1,56 @@
+package reporter
+
+import (
+	"github.com/duck8823/duci/application"
+	"github.com/duck8823/duci/application/context"
+	"github.com/duck8823/duci/application/service/github"
+	"github.com/duck8823/duci/data/model"
+	"github.com/pkg/errors"
+	"gopkg.in/src-d/go-git.v4/plumbing"
+)
+
+const (
+	Status = "status"
+	Checks = "checks"
+)
+
+// Reporter reports progress and result of jobs to GitHub.
+type Reporter interface {
+	Report(ctx context.Context, repo github.Repository, hash plumbing.Hash, state model.State, description string) error
+	Log(ctx context.Context, message model.Message)
+}
+
+// New returns the reporter selected in configuration.
+func New(githubService github.Service) (Reporter, error) {
+	switch application.Config.GitHub.Reporter {
+	case Status:
+		return &statusReporter{githubService}, nil
+	case Checks:
+		return &checksReporter{github: githubService}, nil
+	default:
+		return nil, errors.Errorf("unknown reporter: %s", application.Config.GitHub.Reporter)
+	}
+}
+
+// statusReporter reports with commit statuses.
+type statusReporter struct {
+	github github.Service
+}
+
+func (r *statusReporter) Report(ctx context.Context, repo github.Repository, hash plumbing.Hash, state model.State, description string) error {
+	var status github.State
+	switch state {
+	case model.QUEUED, model.RUNNING:
+		status = github.PENDING
+	case model.SUCCESS:
+		status = github.SUCCESS
+	case model.FAILURE:
+		status = github.FAILURE
+	default:
+		status = github.ERROR
+	}
+	return r.github.CreateCommitStatus(ctx, repo, hash, status, description)
+}
+
+// Log does nothing, because commit statuses have no room for logs.
+func (r *statusReporter) Log(_ context.Context, _ model.Message) {}
@@ -0,0 +1,117 @@
+package reporter_test
+
+import (
+	"github.com/duck8823/duci/application"
+	"github.com/duck8823/duci/application/context"
+	"github.com/duck8823/duci/application/service/github"
+	"github.com/duck8823/duci/application/service/github/mock_github"
+	"github.com/duck8823/duci/application/service/reporter"
+	"github.com/duck8823/duci/data/model"
+	"github.com/golang/mock/gomock"
+	"github.com/google/uuid"
+	"gopkg.in/src-d/go-git.v4/plumbing"
+	"net/url"
+	"testing"
+)
+
+type MockRepo struct {
+	FullName string
+	SSHURL   string
+	HTMLURL  string
+	CloneURL string
+}
+
+func (r *MockRepo) GetFullName() string {
+	return r.FullName
+}
+
+func (r *MockRepo) GetSSHURL() string {
+	return r.SSHURL
+}
+
+func (r *MockRepo) GetHTMLURL() string {
+	return r.HTMLURL
+}
+
+func (r *MockRepo) GetCloneURL() string {
+	return r.CloneURL
+}
+
+func TestNew(t *testing.T) {
+	// setup
+	reset := application.Config.GitHub.Reporter
+	defer func() {
+		application.Config.GitHub.Reporter = reset
+	}()
+
+	for _, tt := range []struct {
+		reporter string
+		err      bool
+	}{
+		{reporter: "status"},
+		{reporter: "checks"},
+		{reporter: "unknown", err: true},
+	} {
+		t.Run(tt.reporter, func(t *testing.T) {
+			// given
+			application.Config.GitHub.Reporter = tt.reporter
+
+			// when
+			_, err := reporter.New(nil)
+
+			// then
+			if tt.err && err == nil {
+				t.Error("error must occur, but got nil")
+			}
+			if !tt.err && err != nil {
+				t.Errorf("error must not occur, but got %+v", err)
+			}
+		})
+	}
+}
+
+func TestStatusReporter_Report(t *testing.T) {
+	// setup
+	reset := application.Config.GitHub.Reporter
+	defer func() {
+		application.Config.GitHub.Reporter = reset
+	}()
+	application.Config.GitHub.Reporter = "status"
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	// given
+	ctx := context.New("test/task", uuid.New(), &url.URL{})
+	repo := &MockRepo{FullName: "duck8823/duci"}
+
+	for _, tt := range []struct {
+		state    model.State
+		expected github.State
+	}{
+		{state: model.RUNNING, expected: github.PENDING},
+		{state: model.SUCCESS, expected: github.SUCCESS},
+		{state: model.FAILURE, expected: github.FAILURE},
+		{state: model.ERROR, expected: github.ERROR},
+		{state: model.CANCELLED, expected: github.ERROR},
+	} {
+		t.Run(tt.state, func(t *testing.T) {
+			// given
+			mockGitHub := mock_github.NewMockService(ctrl)
+			mockGitHub.EXPECT().
+				CreateCommitStatus(gomock.Eq(ctx), gomock.Eq(repo), gomock.Eq(plumbing.ZeroHash), gomock.Eq(tt.expected), gomock.Eq("description")).
+				Times(1).
+				Return(nil)
+
+			sut, err := reporter.New(mockGitHub)
+			if err != nil {
+				t.Fatalf("error occurred: %+v", err)
+			}
+
+			// expect
+			if err := sut.Report(ctx, repo, plumbing.ZeroHash, tt.state, "description"); err != nil {
+				t.Errorf("error must not occur, but got %+v", err)
+			}
+		})
+	}
+}
@@ -8,6 +8,7 @@ import (
 	context "github.com/duck8823/duci/application/context"
 	github "github.com/duck8823/duci/application/service/github"
 	gomock "github.com/golang/mock/gomock"
+	uuid "github.com/google/uuid"
 	plumbing "gopkg.in/src-d/go-git.v4/plumbing"
 	reflect "reflect"
 )
@@ -51,3 +52,27 @@ func (mr *MockRunnerMockRecorder) Run(ctx, repo, ref, sha interface{}, command .
 	varargs := append([]interface{}{ctx, repo, ref, sha}, command...)
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Run", reflect.TypeOf((*MockRunner)(nil).Run), varargs...)
 }
+
+// Cancel mocks base method
+func (m *MockRunner) Cancel(id uuid.UUID) error {
+	ret := m.ctrl.Call(m, "Cancel", id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Cancel indicates an expected call of Cancel
+func (mr *MockRunnerMockRecorder) Cancel(id interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Cancel", reflect.TypeOf((*MockRunner)(nil).Cancel), id)
+}
+
+// CancelRef mocks base method
+func (m *MockRunner) CancelRef(repo github.Repository, ref string) []uuid.UUID {
+	ret := m.ctrl.Call(m, "CancelRef", repo, ref)
+	ret0, _ := ret[0].([]uuid.UUID)
+	return ret0
+}
+
+// CancelRef indicates an expected call of CancelRef
+func (mr *MockRunnerMockRecorder) CancelRef(repo, ref interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CancelRef", reflect.TypeOf((*MockRunner)(nil).CancelRef), repo, ref)
+}
@@ -0,0 +1,282 @@
+package runner
+
+import (
+	"bytes"
+	"fmt"
+	"github.com/docker/go-units"
+	"github.com/duck8823/duci/application"
+	"github.com/duck8823/duci/application/context"
+	"github.com/duck8823/duci/application/service/git"
+	"github.com/duck8823/duci/infrastructure/docker"
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v2"
+	"io/ioutil"
+	"os"
+	"path"
+	"sort"
+	"strings"
+	"time"
+)
+
+// jobOptions is settings of the job in .duci/config.yml.
+type jobOptions struct {
+	docker.RuntimeOptions `yaml:",inline"`
+	Git                   git.Options              `yaml:"git"`
+	Steps                 []*step                  `yaml:"steps"`
+	Matrix                matrix                   `yaml:"matrix"`
+	Services              []*docker.ServiceOptions `yaml:"services"`
+	NetworkMode           string                   `yaml:"network"`
+	ResourceRequests      application.Resources    `yaml:"resources"`
+	// Secrets is names of secrets of the repository to inject as environments
+	Secrets []string `yaml:"secrets"`
+}
+
+// networkMode returns the network mode requested, or the default if not requested.
+// Only the modes allowed in the server configuration are available.
+func (o *jobOptions) networkMode() (string, error) {
+	allowed := application.Config.Job.Networks
+	if len(o.NetworkMode) == 0 {
+		if len(allowed) == 0 {
+			return "", errors.New("no network is allowed by the server")
+		}
+		return allowed[0], nil
+	}
+
+	switch o.NetworkMode {
+	case docker.NetworkNone, docker.NetworkIsolated, docker.NetworkBridge:
+	default:
+		return "", errors.Errorf("invalid .duci/config.yml: unknown network %s", o.NetworkMode)
+	}
+	for _, mode := range allowed {
+		if mode == o.NetworkMode {
+			return mode, nil
+		}
+	}
+	return "", errors.Errorf("network %s is not allowed by the server", o.NetworkMode)
+}
+
+// injectSecrets sets the secrets requested to environments of the job.
+func (o *jobOptions) injectSecrets(secrets map[string]string) error {
+	if len(o.Secrets) == 0 {
+		return nil
+	}
+	if o.Environments == nil {
+		o.Environments = docker.Environments{}
+	}
+	for _, name := range o.Secrets {
+		value, ok := secrets[name]
+		if !ok {
+			return errors.Errorf("secret %s is not registered for the repository", name)
+		}
+		o.Environments[name] = value
+	}
+	return nil
+}
+
+// step is a command run against the built image, in order of the steps.
+type step struct {
+	Name         string              `yaml:"name"`
+	Command      []string            `yaml:"command"`
+	Environments docker.Environments `yaml:"environments"`
+	// Timeout is timeout of the step in seconds ( 0 to use only timeout of the job )
+	Timeout int64 `yaml:"timeout"`
+}
+
+// resources returns limits of resources requested, or the default of the server if not requested.
+// Requests over the maximum of the server are rejected.
+func (o *jobOptions) resources() (docker.Resources, error) {
+	req := o.ResourceRequests
+	def := application.Config.Job.DefaultResources
+	if def == nil {
+		def = &application.Resources{}
+	}
+	maximum := application.Config.Job.MaxResources
+	if maximum == nil {
+		maximum = &application.Resources{}
+	}
+
+	var memories [3]int64
+	for i, memory := range []string{req.Memory, def.Memory, maximum.Memory} {
+		if len(memory) == 0 {
+			continue
+		}
+		size, err := units.RAMInBytes(memory)
+		if err != nil {
+			return docker.Resources{}, errors.Wrapf(err, "invalid memory %s", memory)
+		}
+		memories[i] = size
+	}
+
+	resources := docker.Resources{}
+	for _, l := range []struct {
+		name   string
+		value  *int64
+		limits [3]int64
+		max    interface{}
+	}{
+		{name: "memory", value: &resources.Memory, limits: memories, max: maximum.Memory},
+		{name: "cpu_shares", value: &resources.CPUShares, limits: [3]int64{req.CPUShares, def.CPUShares, maximum.CPUShares}, max: maximum.CPUShares},
+		{name: "cpu_quota", value: &resources.CPUQuota, limits: [3]int64{req.CPUQuota, def.CPUQuota, maximum.CPUQuota}, max: maximum.CPUQuota},
+		{name: "pids_limit", value: &resources.PidsLimit, limits: [3]int64{req.PidsLimit, def.PidsLimit, maximum.PidsLimit}, max: maximum.PidsLimit},
+	} {
+		if l.limits[0] < 0 {
+			return docker.Resources{}, errors.Errorf("resources: %s must not be negative", l.name)
+		}
+		value, ok := limit(l.limits[0], l.limits[1], l.limits[2])
+		if !ok {
+			return docker.Resources{}, errors.Errorf("resources: %s exceeds the maximum %v of the server", l.name, l.max)
+		}
+		*l.value = value
+	}
+
+	for _, ulimits := range []map[string]int64{req.Ulimits, def.Ulimits, maximum.Ulimits} {
+		for name := range ulimits {
+			if req.Ulimits[name] < 0 {
+				return docker.Resources{}, errors.Errorf("resources: ulimit %s must not be negative", name)
+			}
+			value, ok := limit(req.Ulimits[name], def.Ulimits[name], maximum.Ulimits[name])
+			if !ok {
+				return docker.Resources{}, errors.Errorf("resources: ulimit %s exceeds the maximum %d of the server", name, maximum.Ulimits[name])
+			}
+			if resources.Ulimits == nil {
+				resources.Ulimits = map[string]int64{}
+			}
+			resources.Ulimits[name] = value
+		}
+	}
+	return resources, nil
+}
+
+// limit returns the requested value or the default, within the maximum. Zero means unlimited.
+// It returns false if the request is negative ( unlimited for docker ) or over the maximum.
+func limit(requested, def, maximum int64) (int64, bool) {
+	if requested < 0 || (maximum > 0 && requested > maximum) {
+		return 0, false
+	}
+	value := requested
+	if value == 0 {
+		value = def
+	}
+	if maximum > 0 && (value == 0 || value > maximum) {
+		value = maximum
+	}
+	return value, true
+}
+
+// matrix is dockerfiles and build args, each combination of which is built and run in parallel.
+type matrix struct {
+	Dockerfiles []string           `yaml:"dockerfiles"`
+	Args        []docker.BuildArgs `yaml:"args"`
+}
+
+// cell is a combination of dockerfile and build args in the matrix.
+type cell struct {
+	name       string
+	dockerfile string
+	args       docker.BuildArgs
+}
+
+// cells returns all combinations of the matrix, or nothing if the matrix is empty.
+// Without dockerfiles, the default dockerfile is used in each cell.
+func (m *matrix) cells(dockerfile string) []*cell {
+	if len(m.Dockerfiles) == 0 && len(m.Args) == 0 {
+		return nil
+	}
+
+	dockerfiles := m.Dockerfiles
+	if len(dockerfiles) == 0 {
+		dockerfiles = []string{dockerfile}
+	}
+	argsList := m.Args
+	if len(argsList) == 0 {
+		argsList = []docker.BuildArgs{nil}
+	}
+
+	var cells []*cell
+	for _, dockerfile := range dockerfiles {
+		for _, args := range argsList {
+			var names []string
+			if len(m.Dockerfiles) > 0 {
+				names = append(names, dockerfile)
+			}
+			var pairs []string
+			for key, val := range args {
+				pairs = append(pairs, fmt.Sprintf("%s=%s", key, val))
+			}
+			sort.Strings(pairs)
+			names = append(names, pairs...)
+
+			cells = append(cells, &cell{name: strings.Join(names, ","), dockerfile: dockerfile, args: args})
+		}
+	}
+	return cells
+}
+
+// readOptions reads .duci/config.yml in the work directory, if exists.
+// ${NAME} is expanded with the secret of the repository, not with environments of the server.
+func readOptions(workDir string, secrets map[string]string) (*jobOptions, error) {
+	opts := &jobOptions{}
+	if !exists(path.Join(workDir, ".duci/config.yml")) {
+		return opts, nil
+	}
+
+	content, err := ioutil.ReadFile(path.Join(workDir, ".duci/config.yml"))
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	content = []byte(os.Expand(string(content), func(name string) string {
+		if value, ok := secrets[name]; ok {
+			return value
+		}
+		return fmt.Sprintf("${%s}", name)
+	}))
+	if err := yaml.NewDecoder(bytes.NewReader(content)).Decode(opts); err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	names := make(map[string]bool)
+	for _, step := range opts.Steps {
+		if len(step.Name) == 0 {
+			return nil, errors.New("invalid .duci/config.yml: name of step is required")
+		}
+		if names[step.Name] {
+			return nil, errors.Errorf("invalid .duci/config.yml: duplicate step %s", step.Name)
+		}
+		names[step.Name] = true
+	}
+
+	services := make(map[string]bool)
+	for _, service := range opts.Services {
+		if len(service.Name) == 0 || len(service.Image) == 0 {
+			return nil, errors.New("invalid .duci/config.yml: name and image of service are required")
+		}
+		if services[service.Name] {
+			return nil, errors.Errorf("invalid .duci/config.yml: duplicate service %s", service.Name)
+		}
+		services[service.Name] = true
+	}
+	return opts, nil
+}
+
+// context returns the context of the step, reported as <task name>/<step name>.
+func (s *step) context(ctx context.Context) (context.Context, func()) {
+	stepCtx := context.WithTaskName(ctx, fmt.Sprintf("%s/%s", ctx.TaskName(), s.Name))
+	if s.Timeout <= 0 {
+		return stepCtx, func() {}
+	}
+	return context.WithTimeout(stepCtx, time.Duration(s.Timeout)*time.Second)
+}
+
+// runtimeOptions returns the options of the job, with environments of the step taking precedence.
+func (s *step) runtimeOptions(base docker.RuntimeOptions) docker.RuntimeOptions {
+	env := docker.Environments{}
+	for key, val := range base.Environments {
+		env[key] = val
+	}
+	for key, val := range s.Environments {
+		env[key] = val
+	}
+	opts := base
+	opts.Environments = env
+	return opts
+}
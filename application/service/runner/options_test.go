@@ -0,0 +1,127 @@
+package runner
+
+import (
+	"github.com/duck8823/duci/application"
+	"github.com/duck8823/duci/infrastructure/docker"
+	"reflect"
+	"testing"
+)
+
+func TestJobOptions_Resources(t *testing.T) {
+	// setup
+	defaultResources := application.Config.Job.DefaultResources
+	maxResources := application.Config.Job.MaxResources
+	defer func() {
+		application.Config.Job.DefaultResources = defaultResources
+		application.Config.Job.MaxResources = maxResources
+	}()
+
+	for _, tt := range []struct {
+		name      string
+		requested application.Resources
+		def       *application.Resources
+		max       *application.Resources
+		expected  docker.Resources
+		err       bool
+	}{
+		{
+			name:     "without limits",
+			expected: docker.Resources{},
+		},
+		{
+			name:     "without request",
+			def:      &application.Resources{Memory: "1g", PidsLimit: 256},
+			max:      &application.Resources{Memory: "2g", CPUQuota: 100000},
+			expected: docker.Resources{Memory: 1 << 30, PidsLimit: 256, CPUQuota: 100000},
+		},
+		{
+			name:      "with request within the maximum",
+			requested: application.Resources{Memory: "2g", Ulimits: map[string]int64{"nofile": 2048}},
+			def:       &application.Resources{Memory: "1g", Ulimits: map[string]int64{"nofile": 1024, "nproc": 512}},
+			max:       &application.Resources{Memory: "2g", Ulimits: map[string]int64{"nofile": 4096}},
+			expected:  docker.Resources{Memory: 2 << 30, Ulimits: map[string]int64{"nofile": 2048, "nproc": 512}},
+		},
+		{
+			name:      "with request over the maximum",
+			requested: application.Resources{Memory: "4g"},
+			max:       &application.Resources{Memory: "2g"},
+			err:       true,
+		},
+		{
+			name:      "with ulimit over the maximum",
+			requested: application.Resources{Ulimits: map[string]int64{"nofile": 8192}},
+			max:       &application.Resources{Ulimits: map[string]int64{"nofile": 4096}},
+			err:       true,
+		},
+		{
+			name:      "with negative request",
+			requested: application.Resources{PidsLimit: -1},
+			max:       &application.Resources{PidsLimit: 256},
+			err:       true,
+		},
+		{
+			name:      "with negative ulimit",
+			requested: application.Resources{Ulimits: map[string]int64{"nofile": -1}},
+			max:       &application.Resources{Ulimits: map[string]int64{"nofile": 4096}},
+			err:       true,
+		},
+		{
+			name:      "with invalid memory",
+			requested: application.Resources{Memory: "a lot"},
+			err:       true,
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			// given
+			application.Config.Job.DefaultResources = tt.def
+			application.Config.Job.MaxResources = tt.max
+
+			// and
+			opts := &jobOptions{ResourceRequests: tt.requested}
+
+			// when
+			actual, err := opts.resources()
+
+			// then
+			if (err != nil) != tt.err {
+				t.Fatalf("error must be %t, but got %+v", tt.err, err)
+			}
+			if !reflect.DeepEqual(actual, tt.expected) {
+				t.Errorf("resources must be %+v, but got %+v", tt.expected, actual)
+			}
+		})
+	}
+}
+
+func TestJobOptions_InjectSecrets(t *testing.T) {
+	// given
+	secrets := map[string]string{"NPM_TOKEN": "s3cr3t", "DB_PASSWORD": "password"}
+
+	t.Run("with registered secret", func(t *testing.T) {
+		// given
+		opts := &jobOptions{Secrets: []string{"NPM_TOKEN"}}
+		opts.Environments = docker.Environments{"ENV": "test"}
+
+		// when
+		err := opts.injectSecrets(secrets)
+
+		// then
+		if err != nil {
+			t.Fatalf("error must not occur, but got %+v", err)
+		}
+		expected := docker.Environments{"ENV": "test", "NPM_TOKEN": "s3cr3t"}
+		if !reflect.DeepEqual(opts.Environments, expected) {
+			t.Errorf("environments must be %+v, but got %+v", expected, opts.Environments)
+		}
+	})
+
+	t.Run("with secret not registered", func(t *testing.T) {
+		// given
+		opts := &jobOptions{Secrets: []string{"GITHUB_API_TOKEN"}}
+
+		// expect
+		if err := opts.injectSecrets(secrets); err == nil {
+			t.Error("error must occur")
+		}
+	})
+}
@@ -0,0 +1,38 @@
+package runner
+
+import (
+	"github.com/duck8823/duci/application"
+	"github.com/duck8823/duci/application/context"
+	"github.com/duck8823/duci/infrastructure/docker"
+	"github.com/duck8823/duci/infrastructure/logger"
+	"github.com/pkg/errors"
+	"path"
+	"strings"
+)
+
+// checkVolumes checks that host paths of the volumes are allowed for the repository by the policy of the server.
+// Each decision is logged for the administrator.
+func checkVolumes(ctx context.Context, fullName string, volumes docker.Volumes) error {
+	allowed := application.Config.AllowedVolumes(fullName)
+	for _, volume := range volumes {
+		hostPath := strings.Split(volume, ":")[0]
+		if !underAny(hostPath, allowed) {
+			logger.Infof(ctx.UUID(), "deny volume %s for %s", volume, fullName)
+			return errors.Errorf("volume %s is not allowed for %s by the server", hostPath, fullName)
+		}
+		logger.Infof(ctx.UUID(), "allow volume %s for %s", volume, fullName)
+	}
+	return nil
+}
+
+// underAny returns whether the path is any of the prefixes or under them.
+func underAny(name string, prefixes []string) bool {
+	name = path.Clean(name)
+	for _, prefix := range prefixes {
+		prefix = path.Clean(prefix)
+		if name == prefix || strings.HasPrefix(name, strings.TrimSuffix(prefix, "/")+"/") {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,28 @@
+package runner
+
+import "testing"
+
+func TestUnderAny(t *testing.T) {
+	// given
+	prefixes := []string{"/var/cache/duci/", "cache"}
+
+	for _, tt := range []struct {
+		name     string
+		expected bool
+	}{
+		{name: "/var/cache/duci", expected: true},
+		{name: "/var/cache/duci/maven", expected: true},
+		{name: "/var/cache/duci-other", expected: false},
+		{name: "/var/cache/duci/../../run/docker.sock", expected: false},
+		{name: "/", expected: false},
+		{name: "cache", expected: true},
+		{name: "other", expected: false},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			// expect
+			if actual := underAny(tt.name, prefixes); actual != tt.expected {
+				t.Errorf("must be %t, but got %t", tt.expected, actual)
+			}
+		})
+	}
+}
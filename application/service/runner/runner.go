@@ -1,170 +1,643 @@
 package runner
 
 import (
-	"bytes"
+	"fmt"
+	"github.com/docker/go-units"
 	"github.com/duck8823/duci/application"
 	"github.com/duck8823/duci/application/context"
 	"github.com/duck8823/duci/application/semaphore"
 	"github.com/duck8823/duci/application/service/git"
 	"github.com/duck8823/duci/application/service/github"
 	"github.com/duck8823/duci/application/service/logstore"
+	"github.com/duck8823/duci/application/service/queue"
+	"github.com/duck8823/duci/application/service/reporter"
+	"github.com/duck8823/duci/application/service/secret"
 	"github.com/duck8823/duci/data/model"
 	"github.com/duck8823/duci/infrastructure/archive/tar"
 	"github.com/duck8823/duci/infrastructure/clock"
 	"github.com/duck8823/duci/infrastructure/docker"
 	"github.com/duck8823/duci/infrastructure/logger"
+	"github.com/google/uuid"
 	"github.com/pkg/errors"
 	"gopkg.in/src-d/go-git.v4/plumbing"
-	"gopkg.in/yaml.v2"
 	"io"
-	"io/ioutil"
+	"net/url"
 	"os"
 	"path"
-	"strconv"
+	"strings"
+	"sync"
 )
 
 var Failure = errors.New("Task Failure")
 
+var JobNotFound = errors.New("job not found")
+
+var OutOfMemory = errors.New("out of memory")
+
 type Runner interface {
 	Run(ctx context.Context, repo github.Repository, ref string, sha plumbing.Hash, command ...string) error
+	Cancel(id uuid.UUID) error
+	CancelRef(repo github.Repository, ref string) []uuid.UUID
 }
 
 type DockerRunner struct {
 	Git         git.Service
-	GitHub      github.Service
+	Reporter    reporter.Reporter
 	Docker      docker.Client
 	LogStore    logstore.Service
+	Queue       queue.Service
+	Secrets     secret.Service
 	Name        string
 	BaseWorkDir string
+	running     runningJobs
 }
 
 func (r *DockerRunner) Run(ctx context.Context, repo github.Repository, ref string, sha plumbing.Hash, command ...string) error {
-	if err := r.LogStore.Start(ctx.UUID()); err != nil {
-		r.GitHub.CreateCommitStatus(ctx, repo, sha, github.ERROR, err.Error())
+	if err := r.Queue.Push(&model.QueuedJob{
+		ID:         ctx.UUID(),
+		TaskName:   ctx.TaskName(),
+		TargetURL:  ctx.Url().String(),
+		Repository: model.Repository{FullName: repo.GetFullName(), SSHURL: repo.GetSSHURL(), HTMLURL: repo.GetHTMLURL(), CloneURL: repo.GetCloneURL()},
+		Ref:        ref,
+		SHA:        sha.String(),
+		Command:    command,
+		Trigger:    model.Trigger(ctx.Trigger()),
+	}); err != nil {
+		r.Reporter.Report(ctx, repo, sha, model.ERROR, err.Error())
+		return errors.WithStack(err)
+	}
+	defer r.Queue.Done(ctx.UUID())
+
+	if err := r.LogStore.Start(&model.Job{
+		ID:            ctx.UUID(),
+		Repository:    repo.GetFullName(),
+		RepositoryURL: repo.GetHTMLURL(),
+		Ref:           ref,
+		SHA:           sha.String(),
+		TaskName:      ctx.TaskName(),
+		Command:       command,
+		Trigger:       model.Trigger(ctx.Trigger()),
+	}); err != nil {
+		r.Reporter.Report(ctx, repo, sha, model.ERROR, err.Error())
 		return errors.WithStack(err)
 	}
 
-	errs := make(chan error, 1)
+	results := make(chan result, 1)
 
 	timeout, cancel := context.WithTimeout(ctx, application.Config.Timeout())
 	defer cancel()
 
+	r.running.add(ctx, repo.GetFullName(), ref, cancel)
+	defer r.running.remove(ctx.UUID())
+
+	if application.Config.Job.CancelPrevious {
+		for _, id := range r.running.cancelRef(repo.GetFullName(), ref, ctx.TaskName(), ctx.UUID()) {
+			logger.Infof(ctx.UUID(), "cancel previous job: %s", id)
+		}
+	}
+
 	go func() {
+		// keep masking secrets while the job runs, even after cancelled or timed out
+		defer r.running.removeSecrets(ctx.UUID())
+
 		semaphore.Acquire()
-		errs <- r.run(timeout, repo, ref, sha, command...)
-		semaphore.Release()
+		slot := &slot{}
+		defer slot.release()
+
+		// cancelled while waiting
+		if timeout.Err() != nil {
+			results <- result{exitCode: -1, err: timeout.Err()}
+			return
+		}
+
+		if err := r.Queue.Running(ctx.UUID()); err != nil {
+			logger.Errorf(ctx.UUID(), "Failed to mark job as running: %+v", err)
+		}
+		if err := r.LogStore.Running(ctx.UUID()); err != nil {
+			logger.Errorf(ctx.UUID(), "Failed to mark job as running: %+v", err)
+		}
+		code, err := r.run(timeout, slot, repo, ref, sha, command...)
+		results <- result{exitCode: code, err: err}
 	}()
 
 	select {
 	case <-timeout.Done():
-		if timeout.Err() != nil {
+		state := model.ERROR
+		if timeout.Err() == context.Canceled {
+			logger.Info(ctx.UUID(), "job cancelled")
+			r.Reporter.Report(ctx, repo, sha, model.CANCELLED, "cancelled")
+			state = model.CANCELLED
+		} else if timeout.Err() != nil {
 			logger.Errorf(ctx.UUID(), "%+v", timeout.Err())
-			r.GitHub.CreateCommitStatus(ctx, repo, sha, github.ERROR, timeout.Err().Error())
+			r.Reporter.Report(ctx, repo, sha, model.ERROR, timeout.Err().Error())
 		}
-		r.LogStore.Finish(ctx.UUID())
+		r.finish(ctx, state, -1)
 		return timeout.Err()
-	case err := <-errs:
+	case res := <-results:
+		err := res.err
+		state := model.SUCCESS
 		if err == Failure {
 			logger.Error(ctx.UUID(), err.Error())
-			r.GitHub.CreateCommitStatus(ctx, repo, sha, github.FAILURE, "failure job")
+			r.Reporter.Report(ctx, repo, sha, model.FAILURE, "failure job")
+			state = model.FAILURE
 		} else if err != nil {
 			logger.Errorf(ctx.UUID(), "%+v", err)
-			r.GitHub.CreateCommitStatus(ctx, repo, sha, github.ERROR, err.Error())
+			r.Reporter.Report(ctx, repo, sha, model.ERROR, err.Error())
+			state = model.ERROR
 		} else {
-			r.GitHub.CreateCommitStatus(ctx, repo, sha, github.SUCCESS, "success")
+			r.Reporter.Report(ctx, repo, sha, model.SUCCESS, "success")
 		}
-		r.LogStore.Finish(ctx.UUID())
+		r.finish(ctx, state, res.exitCode)
 		return err
 	}
 }
 
-func (r *DockerRunner) run(ctx context.Context, repo github.Repository, ref string, sha plumbing.Hash, command ...string) error {
-	workDir := path.Join(r.BaseWorkDir, strconv.FormatInt(clock.Now().Unix(), 10))
-	tagName := repo.GetFullName()
+// secretsAvailable returns whether secrets of the repository may be given to the job.
+// Pull requests ( and comments on them ) may build code from forks, so they get secrets only if allowed by the server.
+func secretsAvailable(ctx context.Context) bool {
+	switch model.Trigger(ctx.Trigger()) {
+	case model.PULL_REQUEST, model.COMMENT:
+		return application.Config.Job.SecretsForPullRequests
+	default:
+		return true
+	}
+}
+
+// slot is the place of the job in the semaphore, which is released once even if given to cells of matrix.
+type slot struct {
+	once sync.Once
+}
+
+func (s *slot) release() {
+	s.once.Do(semaphore.Release)
+}
+
+// result is an outcome of the job.
+type result struct {
+	exitCode int64
+	err      error
+}
+
+func (r *DockerRunner) finish(ctx context.Context, state model.State, exitCode int64) {
+	if err := r.LogStore.Finish(ctx.UUID(), state, exitCode); err != nil {
+		logger.Errorf(ctx.UUID(), "Failed to finish job: %+v", err)
+	}
+}
+
+// Cancel cancels the job in flight.
+func (r *DockerRunner) Cancel(id uuid.UUID) error {
+	if !r.running.cancel(id) {
+		return JobNotFound
+	}
+	return nil
+}
 
-	if err := r.Git.Clone(ctx, workDir, repo.GetSSHURL(), ref, sha); err != nil {
+// CancelRef cancels all jobs in flight for the ref and returns their ids.
+func (r *DockerRunner) CancelRef(repo github.Repository, ref string) []uuid.UUID {
+	return r.running.cancelRef(repo.GetFullName(), ref, "", uuid.Nil)
+}
+
+// Resume re-enqueues jobs remaining in queue since the last server stop.
+// Jobs which were running at that time are marked as error.
+func (r *DockerRunner) Resume() error {
+	jobs, err := r.Queue.All()
+	if err != nil {
 		return errors.WithStack(err)
 	}
 
-	r.GitHub.CreateCommitStatus(ctx, repo, sha, github.PENDING, "started job")
+	for _, job := range jobs {
+		targetUrl, err := url.Parse(job.TargetURL)
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		ctx := context.WithTrigger(context.New(job.TaskName, job.ID, targetUrl), string(job.Trigger))
+		repo := &job.Repository
+		sha := plumbing.NewHash(job.SHA)
+
+		if job.Running {
+			logger.Error(job.ID, "job was interrupted by server stop")
+			r.Reporter.Report(ctx, repo, sha, model.ERROR, "interrupted by server stop")
+			r.finish(ctx, model.ERROR, -1)
+			if err := r.Queue.Done(job.ID); err != nil {
+				return errors.WithStack(err)
+			}
+			continue
+		}
+
+		logger.Info(job.ID, "resume queued job")
+		go r.Run(ctx, repo, job.Ref, sha, job.Command...)
+	}
+	return nil
+}
+
+func (r *DockerRunner) run(ctx context.Context, slot *slot, repo github.Repository, ref string, sha plumbing.Hash, command ...string) (exitCode int64, err error) {
+	exitCode = -1
+	workDir := path.Join(r.BaseWorkDir, fmt.Sprintf("%d_%s", clock.Now().Unix(), ctx.UUID()))
+	// tagged per job, not to remove the image of other jobs of the repository
+	tagName := fmt.Sprintf("%s:%s", repo.GetFullName(), ctx.UUID())
+	defer func() {
+		r.removeWorkDir(ctx, workDir, err)
+	}()
+
+	if err := r.Git.Clone(ctx, workDir, repo, ref, sha); err != nil {
+		return exitCode, errors.WithStack(err)
+	}
+
+	secrets := map[string]string{}
+	if secretsAvailable(ctx) {
+		if secrets, err = r.Secrets.All(repo.GetFullName()); err != nil {
+			return exitCode, errors.WithStack(err)
+		}
+	}
+	r.running.setSecrets(ctx.UUID(), secrets)
+
+	opts, err := readOptions(workDir, secrets)
+	if err != nil {
+		r.logMessage(ctx, err.Error())
+		return exitCode, errors.WithStack(err)
+	}
+
+	if !secretsAvailable(ctx) && len(opts.Secrets) > 0 {
+		r.logMessage(ctx, "secrets are not given to builds of pull requests")
+	} else if err := opts.injectSecrets(secrets); err != nil {
+		r.logMessage(ctx, err.Error())
+		return exitCode, errors.WithStack(err)
+	}
+
+	if err := checkVolumes(ctx, repo.GetFullName(), opts.Volumes); err != nil {
+		r.logMessage(ctx, err.Error())
+		return exitCode, errors.WithStack(err)
+	}
+
+	if opts.Git.Submodules || opts.Git.LFS {
+		if err := r.Git.Update(ctx, workDir, repo, opts.Git); err != nil {
+			r.logMessage(ctx, err.Error())
+			return exitCode, errors.WithStack(err)
+		}
+	}
+
+	r.Reporter.Report(ctx, repo, sha, model.RUNNING, "started job")
 
 	tarFilePath := path.Join(workDir, "duci.tar")
 	writeFile, err := os.OpenFile(tarFilePath, os.O_RDWR|os.O_CREATE, 0600)
 	if err != nil {
-		return errors.WithStack(err)
+		return exitCode, errors.WithStack(err)
 	}
 	defer writeFile.Close()
 
 	if err := tar.Create(workDir, writeFile); err != nil {
-		return errors.WithStack(err)
+		return exitCode, errors.WithStack(err)
 	}
 
-	readFile, _ := os.Open(tarFilePath)
-	defer readFile.Close()
+	mode, err := opts.networkMode()
+	if err != nil {
+		r.logMessage(ctx, err.Error())
+		return exitCode, errors.WithStack(err)
+	}
+	if mode == docker.NetworkNone && len(opts.Services) > 0 {
+		err := errors.New("services are not available without network")
+		r.logMessage(ctx, err.Error())
+		return exitCode, err
+	}
+
+	resources, err := opts.resources()
+	if err != nil {
+		r.logMessage(ctx, err.Error())
+		return exitCode, errors.WithStack(err)
+	}
+	opts.Resources = resources
+
+	networkId, err := r.createNetwork(ctx, mode)
+	if err != nil {
+		return exitCode, errors.WithStack(err)
+	}
+	defer r.removeNetwork(ctx, networkId)
+	opts.Network = networkId
+
+	if len(opts.Services) > 0 {
+		containerIds, err := r.startServices(ctx, opts.Services, networkId, opts.Resources)
+		defer r.removeServices(ctx, containerIds)
+		if err != nil {
+			r.logMessage(ctx, err.Error())
+			return exitCode, errors.WithStack(err)
+		}
+	}
 
 	dockerfile := "./Dockerfile"
 	if exists(path.Join(workDir, ".duci/Dockerfile")) {
 		dockerfile = ".duci/Dockerfile"
 	}
-	buildLog, err := r.Docker.Build(ctx, readFile, tagName, dockerfile)
+
+	if cells := opts.Matrix.cells(dockerfile); len(cells) > 0 {
+		return r.runMatrix(ctx, slot, repo, sha, opts, tarFilePath, tagName, cells, command...)
+	}
+	return r.buildAndRun(ctx, repo, sha, opts, tarFilePath, tagName, &cell{dockerfile: dockerfile}, command...)
+}
+
+// runMatrix builds and runs each cell of the matrix in parallel, and returns the first error of them.
+// Each cell is reported separately as <task name>/<cell name>, and its logs are prefixed with the name.
+func (r *DockerRunner) runMatrix(ctx context.Context, slot *slot, repo github.Repository, sha plumbing.Hash, opts *jobOptions, tarFilePath string, tagName string, cells []*cell, command ...string) (int64, error) {
+	// give the slot of the job to cells, not to deadlock with other jobs waiting for slots
+	slot.release()
+
+	results := make([]result, len(cells))
+	var wg sync.WaitGroup
+	for i, c := range cells {
+		wg.Add(1)
+		go func(i int, c *cell) {
+			defer wg.Done()
+			semaphore.Acquire()
+			defer semaphore.Release()
+
+			cellCtx := context.WithTaskName(ctx, fmt.Sprintf("%s/%s", ctx.TaskName(), c.name))
+			r.Reporter.Report(cellCtx, repo, sha, model.RUNNING, "started cell")
+			cellTag := fmt.Sprintf("%s-matrix-%d", tagName, i)
+			code, err := r.buildAndRun(cellCtx, repo, sha, opts, tarFilePath, cellTag, c, command...)
+			r.reportResult(cellCtx, repo, sha, err)
+			results[i] = result{exitCode: code, err: err}
+		}(i, c)
+	}
+	wg.Wait()
+
+	// errors other than failure take precedence, same as state of the job
+	var failure *result
+	for i := range results {
+		if results[i].err != nil && results[i].err != Failure {
+			return results[i].exitCode, results[i].err
+		}
+		if results[i].err == Failure && failure == nil {
+			failure = &results[i]
+		}
+	}
+	if failure != nil {
+		return failure.exitCode, failure.err
+	}
+	return 0, nil
+}
+
+// buildAndRun builds the image of the cell, and runs the command or steps in it.
+func (r *DockerRunner) buildAndRun(ctx context.Context, repo github.Repository, sha plumbing.Hash, opts *jobOptions, tarFilePath string, tagName string, c *cell, command ...string) (exitCode int64, err error) {
+	readFile, err := os.Open(tarFilePath)
 	if err != nil {
-		return errors.WithStack(err)
+		return -1, errors.WithStack(err)
 	}
-	if err := r.logAppend(ctx, buildLog); err != nil {
-		return errors.WithStack(err)
+	defer readFile.Close()
+
+	buildLog, err := r.Docker.Build(ctx, readFile, tagName, c.dockerfile, c.args)
+	if err != nil {
+		return -1, errors.WithStack(err)
+	}
+	if application.Config.Job.RemoveImage {
+		defer func() {
+			r.removeImage(ctx, tagName, err)
+		}()
+	}
+	if err := r.logAppend(ctx, c.name, buildLog); err != nil {
+		return -1, errors.WithStack(err)
+	}
+
+	if len(command) == 0 && len(opts.Steps) > 0 {
+		return r.runSteps(ctx, repo, sha, opts, tagName, c)
 	}
+	return r.runContainer(ctx, opts.RuntimeOptions, tagName, c, command...)
+}
+
+// runSteps runs the steps in order against the image, and stops on the first failure.
+// Each step is reported separately as <task name>/<step name>.
+func (r *DockerRunner) runSteps(ctx context.Context, repo github.Repository, sha plumbing.Hash, opts *jobOptions, tagName string, c *cell) (exitCode int64, err error) {
+	for _, step := range opts.Steps {
+		stepCtx, cancel := step.context(ctx)
+		r.Reporter.Report(stepCtx, repo, sha, model.RUNNING, "started step")
+		r.logCellMessage(stepCtx, c.name, fmt.Sprintf("==> step %s: %s", step.Name, strings.Join(step.Command, " ")))
 
-	var opts docker.RuntimeOptions
-	if exists(path.Join(workDir, ".duci/config.yml")) {
-		content, err := ioutil.ReadFile(path.Join(workDir, ".duci/config.yml"))
+		exitCode, err = r.runContainer(stepCtx, step.runtimeOptions(opts.RuntimeOptions), tagName, c, step.Command...)
+		r.reportResult(stepCtx, repo, sha, err)
+		cancel()
 		if err != nil {
-			return errors.WithStack(err)
-		}
-		content = []byte(os.ExpandEnv(string(content)))
-		if err := yaml.NewDecoder(bytes.NewReader(content)).Decode(&opts); err != nil {
-			return errors.WithStack(err)
+			return exitCode, err
 		}
 	}
+	return exitCode, nil
+}
+
+// reportResult reports result of the step or the cell.
+func (r *DockerRunner) reportResult(ctx context.Context, repo github.Repository, sha plumbing.Hash, err error) {
+	switch {
+	case err == nil:
+		r.Reporter.Report(ctx, repo, sha, model.SUCCESS, "success")
+	case err == Failure:
+		r.Reporter.Report(ctx, repo, sha, model.FAILURE, "failure")
+	case ctx.Err() == context.Canceled:
+		r.Reporter.Report(ctx, repo, sha, model.CANCELLED, "cancelled")
+	case ctx.Err() == context.DeadlineExceeded:
+		r.Reporter.Report(ctx, repo, sha, model.ERROR, "timeout")
+	default:
+		r.Reporter.Report(ctx, repo, sha, model.ERROR, err.Error())
+	}
+}
 
+// runContainer runs the command in a container of the image, and returns its exit code.
+func (r *DockerRunner) runContainer(ctx context.Context, opts docker.RuntimeOptions, tagName string, c *cell, command ...string) (exitCode int64, err error) {
 	containerId, runLog, err := r.Docker.Run(ctx, opts, tagName, command...)
+	if len(containerId) > 0 {
+		defer func() {
+			if rmErr := r.removeContainer(ctx, containerId, err); rmErr != nil && err == nil {
+				err = errors.WithStack(rmErr)
+			}
+		}()
+	}
 	if err != nil {
-		return errors.WithStack(err)
+		return -1, errors.WithStack(err)
 	}
-	if err := r.logAppend(ctx, runLog); err != nil {
-		return errors.WithStack(err)
+	if err := r.logAppend(ctx, c.name, runLog); err != nil {
+		return -1, errors.WithStack(err)
 	}
 
-	code, err := r.Docker.ExitCode(ctx, containerId)
+	exitCode, err = r.Docker.ExitCode(ctx, containerId)
 	if err != nil {
-		return errors.WithStack(err)
+		return -1, errors.WithStack(err)
+	}
+	if exitCode != 0 {
+		if oom, err := r.Docker.OOMKilled(ctx, containerId); err != nil {
+			logger.Errorf(ctx.UUID(), "Failed to inspect container: %+v", err)
+		} else if oom {
+			r.logCellMessage(ctx, c.name, oomMessage(opts.Resources, exitCode))
+			return exitCode, OutOfMemory
+		}
+		return exitCode, Failure
+	}
+
+	return exitCode, nil
+}
+
+// createNetwork creates a network of the job, and returns its id.
+// Network of the job is not shared with other jobs.
+func (r *DockerRunner) createNetwork(ctx context.Context, mode string) (string, error) {
+	if mode == docker.NetworkNone {
+		return docker.NetworkNone, nil
+	}
+	networkId, err := r.Docker.CreateNetwork(ctx, fmt.Sprintf("duci-%s", ctx.UUID()), mode == docker.NetworkIsolated)
+	if err != nil {
+		return "", errors.WithStack(err)
+	}
+	return networkId, nil
+}
+
+// removeNetwork removes the network of the job.
+func (r *DockerRunner) removeNetwork(ctx context.Context, networkId string) {
+	if networkId == docker.NetworkNone {
+		return
+	}
+	background := context.New(ctx.TaskName(), ctx.UUID(), ctx.Url())
+	if err := r.Docker.RemoveNetwork(background, networkId); err != nil {
+		logger.Errorf(ctx.UUID(), "Failed to remove network: %+v", err)
 	}
-	if err := r.Docker.Rm(ctx, containerId); err != nil {
+}
+
+// startServices starts the services on the network, and waits until they become healthy.
+// Services are limited with the same resources as the job.
+// It returns containers of the services started, even if failed to start others.
+func (r *DockerRunner) startServices(ctx context.Context, services []*docker.ServiceOptions, networkId string, resources docker.Resources) ([]string, error) {
+	var containerIds []string
+	for _, service := range services {
+		r.logMessage(ctx, fmt.Sprintf("==> service %s: %s", service.Name, service.Image))
+		opts := *service
+		opts.Resources = resources
+		containerId, err := r.Docker.RunService(ctx, opts, networkId)
+		if len(containerId) > 0 {
+			containerIds = append(containerIds, containerId)
+		}
+		if err != nil {
+			return containerIds, errors.Wrapf(err, "failed to start service %s", service.Name)
+		}
+	}
+
+	for i, containerId := range containerIds {
+		if err := r.Docker.WaitHealthy(ctx, containerId); err != nil {
+			return containerIds, errors.Wrapf(err, "service %s is not ready", services[i].Name)
+		}
+	}
+	return containerIds, nil
+}
+
+// removeServices kills and removes containers of the services, whatever the result of the job.
+func (r *DockerRunner) removeServices(ctx context.Context, containerIds []string) {
+	background := context.New(ctx.TaskName(), ctx.UUID(), ctx.Url())
+	for _, containerId := range containerIds {
+		if err := r.Docker.Kill(background, containerId); err != nil {
+			logger.Debugf(ctx.UUID(), "skip kill service with error: %s", err.Error())
+		}
+		if err := r.Docker.Rm(background, containerId); err != nil {
+			logger.Errorf(ctx.UUID(), "Failed to remove service: %+v", err)
+		}
+	}
+}
+
+// removeContainer kills the container if the job was stopped, and removes it.
+// The container is kept when the job failed and configured to keep on failure.
+func (r *DockerRunner) removeContainer(ctx context.Context, containerId string, jobErr error) error {
+	background := context.New(ctx.TaskName(), ctx.UUID(), ctx.Url())
+	if ctx.Err() != nil {
+		if err := r.Docker.Kill(background, containerId); err != nil {
+			logger.Errorf(ctx.UUID(), "Failed to kill container: %+v", err)
+		}
+	}
+
+	if keepOnFailure(jobErr) {
+		logger.Infof(ctx.UUID(), "keep container for debugging: %s", containerId)
+		return nil
+	}
+	if err := r.Docker.Rm(background, containerId); err != nil {
+		logger.Errorf(ctx.UUID(), "Failed to remove container: %+v", err)
 		return errors.WithStack(err)
 	}
-	if code != 0 {
-		return Failure
+	return nil
+}
+
+// removeImage removes the built image, unless kept for debugging.
+func (r *DockerRunner) removeImage(ctx context.Context, tagName string, jobErr error) {
+	if keepOnFailure(jobErr) {
+		logger.Infof(ctx.UUID(), "keep image for debugging: %s", tagName)
+		return
+	}
+	background := context.New(ctx.TaskName(), ctx.UUID(), ctx.Url())
+	if err := r.Docker.Rmi(background, tagName); err != nil {
+		logger.Errorf(ctx.UUID(), "Failed to remove image: %+v", err)
 	}
+}
 
-	return err
+// removeWorkDir removes cloned repository and archive, unless kept for debugging.
+func (r *DockerRunner) removeWorkDir(ctx context.Context, workDir string, jobErr error) {
+	if keepOnFailure(jobErr) {
+		logger.Infof(ctx.UUID(), "keep work directory for debugging: %s", workDir)
+		return
+	}
+	if err := os.RemoveAll(workDir); err != nil {
+		logger.Errorf(ctx.UUID(), "Failed to remove work directory: %+v", err)
+	}
 }
 
-func (r *DockerRunner) logAppend(ctx context.Context, log docker.Log) error {
+func (r *DockerRunner) logAppend(ctx context.Context, prefix string, log docker.Log) error {
 	for {
+		if ctx.Err() != nil {
+			return errors.WithStack(ctx.Err())
+		}
+
 		line, err := log.ReadLine()
 		if err != nil && err != io.EOF {
 			logger.Debugf(ctx.UUID(), "skip read line with error: %s", err.Error())
 			continue
 		}
-		logger.Info(ctx.UUID(), string(line.Message))
-		if err := r.LogStore.Append(ctx.UUID(), model.Message{Time: line.Timestamp, Text: string(line.Message)}); err != nil {
+		text := r.running.mask(ctx.UUID(), string(line.Message))
+		logger.Info(ctx.UUID(), text)
+		if err := r.LogStore.Append(ctx.UUID(), model.Message{Time: line.Timestamp, Text: prefixed(prefix, text)}); err != nil {
 			return errors.WithStack(err)
 		}
+		// the cell has its own report, where the prefix would hide annotations
+		r.Reporter.Log(ctx, model.Message{Time: line.Timestamp, Text: text})
 		if err == io.EOF {
 			return nil
 		}
 	}
 }
 
+// logMessage appends the text to log of the job, e.g. to tell why the job could not start.
+func (r *DockerRunner) logMessage(ctx context.Context, text string) {
+	r.logCellMessage(ctx, "", text)
+}
+
+// logCellMessage appends the text prefixed with name of the cell to log of the job.
+// The report of the cell gets the text without the prefix.
+func (r *DockerRunner) logCellMessage(ctx context.Context, name string, text string) {
+	now := clock.Now()
+	text = r.running.mask(ctx.UUID(), text)
+	if err := r.LogStore.Append(ctx.UUID(), model.Message{Time: now, Text: prefixed(name, text)}); err != nil {
+		logger.Errorf(ctx.UUID(), "Failed to append log: %+v", err)
+		return
+	}
+	r.Reporter.Log(ctx, model.Message{Time: now, Text: text})
+}
+
+// oomMessage tells that the container was killed by out of memory, with its limit.
+func oomMessage(resources docker.Resources, exitCode int64) string {
+	if resources.Memory == 0 {
+		return fmt.Sprintf("killed by out of memory ( exit code %d )", exitCode)
+	}
+	return fmt.Sprintf("killed by out of memory, over the limit %s ( exit code %d )", units.BytesSize(float64(resources.Memory)), exitCode)
+}
+
+// prefixed prefixes the text with name of the cell, if any.
+func prefixed(name string, text string) string {
+	if len(name) == 0 {
+		return text
+	}
+	return fmt.Sprintf("[%s] %s", name, text)
+}
+
+func keepOnFailure(err error) bool {
+	return err != nil && application.Config.Job.KeepOnFailure
+}
+
 func exists(name string) bool {
 	_, err := os.Stat(name)
 	return !os.IsNotExist(err)
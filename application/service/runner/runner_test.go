@@ -1,12 +1,18 @@
 package runner_test
 
 import (
+	"fmt"
 	"github.com/duck8823/duci/application"
 	"github.com/duck8823/duci/application/context"
+	"github.com/duck8823/duci/application/semaphore"
+	"github.com/duck8823/duci/application/service/git"
 	"github.com/duck8823/duci/application/service/git/mock_git"
-	"github.com/duck8823/duci/application/service/github/mock_github"
 	"github.com/duck8823/duci/application/service/logstore/mock_logstore"
+	"github.com/duck8823/duci/application/service/queue/mock_queue"
+	"github.com/duck8823/duci/application/service/reporter/mock_reporter"
 	"github.com/duck8823/duci/application/service/runner"
+	"github.com/duck8823/duci/application/service/secret/mock_secret"
+	"github.com/duck8823/duci/data/model"
 	"github.com/duck8823/duci/infrastructure/clock"
 	"github.com/duck8823/duci/infrastructure/docker"
 	"github.com/duck8823/duci/infrastructure/docker/mock_docker"
@@ -15,22 +21,45 @@ import (
 	"github.com/pkg/errors"
 	"gopkg.in/src-d/go-git.v4/plumbing"
 	"io"
+	"io/ioutil"
 	"net/url"
 	"os"
 	"path"
+	"reflect"
+	"strings"
+	"sync"
 	"testing"
 	"time"
 )
 
+func TestMain(m *testing.M) {
+	// one job at a time, to test jobs waiting for others
+	application.Config.Job.Concurrency = 1
+	if err := semaphore.Make(); err != nil {
+		fmt.Fprintf(os.Stderr, "error occurred: %+v", err)
+		os.Exit(1)
+	}
+	os.Exit(m.Run())
+}
+
 func TestRunnerImpl_Run(t *testing.T) {
 	// setup
 	ctrl := gomock.NewController(t)
 
+	mockQueue := mock_queue.NewMockService(ctrl)
+	mockQueue.EXPECT().Push(gomock.Any()).AnyTimes().Return(nil)
+	mockQueue.EXPECT().Running(gomock.Any()).AnyTimes().Return(nil)
+	mockQueue.EXPECT().Done(gomock.Any()).AnyTimes().Return(nil)
+
+	mockSecret := mock_secret.NewMockService(ctrl)
+	mockSecret.EXPECT().All(gomock.Any()).AnyTimes().Return(map[string]string{}, nil)
+
 	t.Run("with correct return values", func(t *testing.T) {
 		t.Run("when Dockerfile in proj root", func(t *testing.T) {
 			// given
-			mockGitHub := mock_github.NewMockService(ctrl)
-			mockGitHub.EXPECT().CreateCommitStatus(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+			mockReporter := mock_reporter.NewMockReporter(ctrl)
+			mockReporter.EXPECT().Log(gomock.Any(), gomock.Any()).AnyTimes()
+			mockReporter.EXPECT().Report(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
 				Times(2).
 				Return(nil)
 
@@ -57,11 +86,23 @@ func TestRunnerImpl_Run(t *testing.T) {
 			// and
 			mockDocker := mock_docker.NewMockClient(ctrl)
 			mockDocker.EXPECT().
-				Build(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Eq("./Dockerfile")).
+				CreateNetwork(gomock.Any(), gomock.Any(), gomock.Any()).
+				AnyTimes().
+				Return("network_id", nil)
+			mockDocker.EXPECT().
+				RemoveNetwork(gomock.Any(), gomock.Eq("network_id")).
+				AnyTimes().
+				Return(nil)
+			mockDocker.EXPECT().
+				OOMKilled(gomock.Any(), gomock.Any()).
+				AnyTimes().
+				Return(false, nil)
+			mockDocker.EXPECT().
+				Build(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Eq("./Dockerfile"), gomock.Any()).
 				Times(1).
 				Return(&MockBuildLog{}, nil)
 			mockDocker.EXPECT().
-				Build(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Not("./Dockerfile")).
+				Build(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Not("./Dockerfile"), gomock.Any()).
 				Return(nil, errors.New("must not call this"))
 			mockDocker.EXPECT().
 				Run(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
@@ -87,21 +128,27 @@ func TestRunnerImpl_Run(t *testing.T) {
 				AnyTimes().
 				Return(nil)
 			mockLogStore.EXPECT().
-				Finish(gomock.Any()).
+				Running(gomock.Any()).
 				AnyTimes().
 				Return(nil)
+			mockLogStore.EXPECT().
+				Finish(gomock.Any(), gomock.Eq(model.SUCCESS), gomock.Eq(int64(0))).
+				Times(1).
+				Return(nil)
 
 			r := &runner.DockerRunner{
 				Name:        "test-runner",
 				BaseWorkDir: path.Join(os.TempDir(), "test-runner"),
 				Git:         mockGit,
-				GitHub:      mockGitHub,
+				Reporter:    mockReporter,
 				Docker:      mockDocker,
 				LogStore:    mockLogStore,
+				Queue:       mockQueue,
+				Secrets:     mockSecret,
 			}
 
 			// and
-			repo := &MockRepo{"duck8823/duci", "git@github.com:duck8823/duci.git"}
+			repo := &MockRepo{"duck8823/duci", "git@github.com:duck8823/duci.git", "https://github.com/duck8823/duci", "https://github.com/duck8823/duci.git"}
 
 			// when
 			err := r.Run(context.New("test/task", uuid.New(), &url.URL{}), repo, "master", plumbing.ZeroHash, "Hello World.")
@@ -114,8 +161,9 @@ func TestRunnerImpl_Run(t *testing.T) {
 
 		t.Run("when Dockerfile in sub directory", func(t *testing.T) {
 			// given
-			mockGitHub := mock_github.NewMockService(ctrl)
-			mockGitHub.EXPECT().CreateCommitStatus(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+			mockReporter := mock_reporter.NewMockReporter(ctrl)
+			mockReporter.EXPECT().Log(gomock.Any(), gomock.Any()).AnyTimes()
+			mockReporter.EXPECT().Report(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
 				Times(2).
 				Return(nil)
 
@@ -142,10 +190,22 @@ func TestRunnerImpl_Run(t *testing.T) {
 			// and
 			mockDocker := mock_docker.NewMockClient(ctrl)
 			mockDocker.EXPECT().
-				Build(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Eq(".duci/Dockerfile")).
+				CreateNetwork(gomock.Any(), gomock.Any(), gomock.Any()).
+				AnyTimes().
+				Return("network_id", nil)
+			mockDocker.EXPECT().
+				RemoveNetwork(gomock.Any(), gomock.Eq("network_id")).
+				AnyTimes().
+				Return(nil)
+			mockDocker.EXPECT().
+				OOMKilled(gomock.Any(), gomock.Any()).
+				AnyTimes().
+				Return(false, nil)
+			mockDocker.EXPECT().
+				Build(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Eq(".duci/Dockerfile"), gomock.Any()).
 				Return(&MockBuildLog{}, nil)
 			mockDocker.EXPECT().
-				Build(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Not(".duci/Dockerfile")).
+				Build(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Not(".duci/Dockerfile"), gomock.Any()).
 				Return(nil, errors.New("must not call this"))
 			mockDocker.EXPECT().
 				Run(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
@@ -171,7 +231,11 @@ func TestRunnerImpl_Run(t *testing.T) {
 				AnyTimes().
 				Return(nil)
 			mockLogStore.EXPECT().
-				Finish(gomock.Any()).
+				Running(gomock.Any()).
+				AnyTimes().
+				Return(nil)
+			mockLogStore.EXPECT().
+				Finish(gomock.Any(), gomock.Any(), gomock.Any()).
 				AnyTimes().
 				Return(nil)
 
@@ -179,13 +243,15 @@ func TestRunnerImpl_Run(t *testing.T) {
 				Name:        "test-runner",
 				BaseWorkDir: path.Join(os.TempDir(), "test-runner"),
 				Git:         mockGit,
-				GitHub:      mockGitHub,
+				Reporter:    mockReporter,
 				Docker:      mockDocker,
 				LogStore:    mockLogStore,
+				Queue:       mockQueue,
+				Secrets:     mockSecret,
 			}
 
 			// and
-			repo := &MockRepo{"duck8823/duci", "git@github.com:duck8823/duci.git"}
+			repo := &MockRepo{"duck8823/duci", "git@github.com:duck8823/duci.git", "https://github.com/duck8823/duci", "https://github.com/duck8823/duci.git"}
 
 			// when
 			err := r.Run(context.New("test/task", uuid.New(), &url.URL{}), repo, "master", plumbing.ZeroHash, "Hello World.")
@@ -198,9 +264,16 @@ func TestRunnerImpl_Run(t *testing.T) {
 	})
 
 	t.Run("with config file", func(t *testing.T) {
+		// setup
+		application.Config.Policy = &application.Policy{Volumes: map[string][]string{"duck8823": {"/hello"}}}
+		defer func() {
+			application.Config.Policy = nil
+		}()
+
 		// given
-		mockGitHub := mock_github.NewMockService(ctrl)
-		mockGitHub.EXPECT().CreateCommitStatus(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+		mockReporter := mock_reporter.NewMockReporter(ctrl)
+		mockReporter.EXPECT().Log(gomock.Any(), gomock.Any()).AnyTimes()
+		mockReporter.EXPECT().Report(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
 			Times(2).
 			Return(nil)
 
@@ -227,14 +300,26 @@ func TestRunnerImpl_Run(t *testing.T) {
 		// and
 		mockDocker := mock_docker.NewMockClient(ctrl)
 		mockDocker.EXPECT().
-			Build(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+			CreateNetwork(gomock.Any(), gomock.Any(), gomock.Any()).
+			AnyTimes().
+			Return("network_id", nil)
+		mockDocker.EXPECT().
+			RemoveNetwork(gomock.Any(), gomock.Eq("network_id")).
+			AnyTimes().
+			Return(nil)
+		mockDocker.EXPECT().
+			OOMKilled(gomock.Any(), gomock.Any()).
+			AnyTimes().
+			Return(false, nil)
+		mockDocker.EXPECT().
+			Build(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
 			Return(&MockBuildLog{}, nil)
 		mockDocker.EXPECT().
-			Run(gomock.Any(), gomock.Eq(docker.RuntimeOptions{Volumes: []string{"/hello:/hello"}}), gomock.Any(), gomock.Any()).
+			Run(gomock.Any(), gomock.Eq(docker.RuntimeOptions{Volumes: []string{"/hello:/hello"}, Network: "network_id"}), gomock.Any(), gomock.Any()).
 			Times(1).
 			Return("", &MockJobLog{}, nil)
 		mockDocker.EXPECT().
-			Run(gomock.Any(), gomock.Not(docker.RuntimeOptions{Volumes: []string{"/hello:/hello"}}), gomock.Any(), gomock.Any()).
+			Run(gomock.Any(), gomock.Not(docker.RuntimeOptions{Volumes: []string{"/hello:/hello"}, Network: "network_id"}), gomock.Any(), gomock.Any()).
 			Return("", nil, errors.New("must not call this"))
 		mockDocker.EXPECT().
 			ExitCode(gomock.Any(), gomock.Any()).
@@ -256,7 +341,11 @@ func TestRunnerImpl_Run(t *testing.T) {
 			AnyTimes().
 			Return(nil)
 		mockLogStore.EXPECT().
-			Finish(gomock.Any()).
+			Running(gomock.Any()).
+			AnyTimes().
+			Return(nil)
+		mockLogStore.EXPECT().
+			Finish(gomock.Any(), gomock.Any(), gomock.Any()).
 			AnyTimes().
 			Return(nil)
 
@@ -264,13 +353,15 @@ func TestRunnerImpl_Run(t *testing.T) {
 			Name:        "test-runner",
 			BaseWorkDir: path.Join(os.TempDir(), "test-runner"),
 			Git:         mockGit,
-			GitHub:      mockGitHub,
+			Reporter:    mockReporter,
 			Docker:      mockDocker,
 			LogStore:    mockLogStore,
+			Queue:       mockQueue,
+			Secrets:     mockSecret,
 		}
 
 		// and
-		repo := &MockRepo{"duck8823/duci", "git@github.com:duck8823/duci.git"}
+		repo := &MockRepo{"duck8823/duci", "git@github.com:duck8823/duci.git", "https://github.com/duck8823/duci", "https://github.com/duck8823/duci.git"}
 
 		// when
 		err := r.Run(context.New("test/task", uuid.New(), &url.URL{}), repo, "master", plumbing.ZeroHash, "Hello World.")
@@ -281,109 +372,102 @@ func TestRunnerImpl_Run(t *testing.T) {
 		}
 	})
 
-	t.Run("when failed to git clone", func(t *testing.T) {
+	t.Run("with volumes not allowed", func(t *testing.T) {
+		// setup
+		application.Config.Policy = &application.Policy{Volumes: map[string][]string{"duck8823": {"/var/cache"}}}
+		defer func() {
+			application.Config.Policy = nil
+		}()
+
 		// given
-		mockGitHub := mock_github.NewMockService(ctrl)
-		mockGitHub.EXPECT().CreateCommitStatus(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
-			Times(2).
+		mockReporter := mock_reporter.NewMockReporter(ctrl)
+		mockReporter.EXPECT().Log(gomock.Any(), gomock.Any()).AnyTimes()
+		mockReporter.EXPECT().Report(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Eq(model.ERROR), gomock.Any()).
+			Times(1).
 			Return(nil)
 
 		// and
 		mockGit := mock_git.NewMockService(ctrl)
 		mockGit.EXPECT().Clone(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
 			Times(1).
-			Return(errors.New("error"))
+			DoAndReturn(func(_ interface{}, dir string, _, _, _ interface{}) error {
+				if err := os.MkdirAll(path.Join(dir, ".duci"), 0700); err != nil {
+					return err
+				}
+				return ioutil.WriteFile(path.Join(dir, ".duci/config.yml"), []byte("---\nvolumes:\n  - /var/cache/duci:/cache\n  - /var/run/docker.sock:/var/run/docker.sock"), 0600)
+			})
 
 		// and
 		mockDocker := mock_docker.NewMockClient(ctrl)
 		mockDocker.EXPECT().
-			Build(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
-			Times(0)
-		mockDocker.EXPECT().
-			Run(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+			Build(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
 			Times(0)
-		mockDocker.EXPECT().
-			ExitCode(gomock.Any(), gomock.Any()).
-			AnyTimes().
-			Return(int64(0), nil)
-		mockDocker.EXPECT().
-			Rm(gomock.Any(), gomock.Any()).
-			AnyTimes().
-			Return(nil)
 
 		// and
 		mockLogStore := mock_logstore.NewMockService(ctrl)
 		mockLogStore.EXPECT().
 			Append(gomock.Any(), gomock.Any()).
-			AnyTimes().
-			Return(nil)
+			Times(1).
+			DoAndReturn(func(_ uuid.UUID, message model.Message) error {
+				if message.Text != "volume /var/run/docker.sock is not allowed for duck8823/duci by the server" {
+					t.Errorf("error must be appended to log, but got %s", message.Text)
+				}
+				return nil
+			})
 		mockLogStore.EXPECT().
 			Start(gomock.Any()).
 			AnyTimes().
 			Return(nil)
 		mockLogStore.EXPECT().
-			Finish(gomock.Any()).
+			Running(gomock.Any()).
 			AnyTimes().
 			Return(nil)
+		mockLogStore.EXPECT().
+			Finish(gomock.Any(), gomock.Eq(model.ERROR), gomock.Any()).
+			Times(1).
+			Return(nil)
 
 		r := &runner.DockerRunner{
 			Name:        "test-runner",
 			BaseWorkDir: path.Join(os.TempDir(), "test-runner"),
 			Git:         mockGit,
-			GitHub:      mockGitHub,
+			Reporter:    mockReporter,
 			Docker:      mockDocker,
 			LogStore:    mockLogStore,
+			Queue:       mockQueue,
+			Secrets:     mockSecret,
 		}
 
 		// and
-		repo := &MockRepo{"duck8823/duci", "git@github.com:duck8823/duci.git"}
+		repo := &MockRepo{"duck8823/duci", "git@github.com:duck8823/duci.git", "https://github.com/duck8823/duci", "https://github.com/duck8823/duci.git"}
 
 		// when
 		err := r.Run(context.New("test/task", uuid.New(), &url.URL{}), repo, "master", plumbing.ZeroHash, "Hello World.")
 
 		// then
 		if err == nil {
-			t.Error("must occur error")
+			t.Error("error must occur")
 		}
 	})
 
-	t.Run("when failed store#$tart", func(t *testing.T) {
+	t.Run("with secrets in config file", func(t *testing.T) {
 		// given
-		mockGitHub := mock_github.NewMockService(ctrl)
-		mockGitHub.EXPECT().CreateCommitStatus(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
-			Times(2).
-			Return(nil)
+		mockSecret := mock_secret.NewMockService(ctrl)
+		mockSecret.EXPECT().
+			All(gomock.Eq("duck8823/duci")).
+			Times(1).
+			Return(map[string]string{"NPM_TOKEN": "s3cr3t"}, nil)
 
 		// and
-		mockLogStore := mock_logstore.NewMockService(ctrl)
-		mockLogStore.EXPECT().
-			Start(gomock.Any()).
+		mockReporter := mock_reporter.NewMockReporter(ctrl)
+		mockReporter.EXPECT().Log(gomock.Any(), gomock.Any()).
 			AnyTimes().
-			Return(errors.New("test error"))
-
-		r := &runner.DockerRunner{
-			Name:        "test-runner",
-			BaseWorkDir: path.Join(os.TempDir(), "test-runner"),
-			GitHub:      mockGitHub,
-			LogStore:    mockLogStore,
-		}
-
-		// and
-		repo := &MockRepo{"duck8823/duci", "git@github.com:duck8823/duci.git"}
-
-		// when
-		err := r.Run(context.New("test/task", uuid.New(), &url.URL{}), repo, "master", plumbing.ZeroHash, "Hello World.")
-
-		// then
-		if err == nil {
-			t.Error("must occur error")
-		}
-	})
-
-	t.Run("when workdir not exists", func(t *testing.T) {
-		// given
-		mockGitHub := mock_github.NewMockService(ctrl)
-		mockGitHub.EXPECT().CreateCommitStatus(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+			Do(func(_ context.Context, message model.Message) {
+				if strings.Contains(message.Text, "s3cr3t") {
+					t.Errorf("secret must be masked, but got %s", message.Text)
+				}
+			})
+		mockReporter.EXPECT().Report(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
 			Times(2).
 			Return(nil)
 
@@ -391,87 +475,288 @@ func TestRunnerImpl_Run(t *testing.T) {
 		mockGit := mock_git.NewMockService(ctrl)
 		mockGit.EXPECT().Clone(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
 			Times(1).
-			Return(nil)
+			DoAndReturn(func(_ interface{}, dir string, _, _, _ interface{}) error {
+				if err := os.MkdirAll(path.Join(dir, ".duci"), 0700); err != nil {
+					return err
+				}
+				return ioutil.WriteFile(path.Join(dir, ".duci/config.yml"), []byte("---\nenvironments:\n  TOKEN: ${NPM_TOKEN}\n  HOME_DIR: ${HOME}\nsecrets:\n  - NPM_TOKEN"), 0600)
+			})
 
 		// and
+		expected := docker.RuntimeOptions{
+			Environments: docker.Environments{"TOKEN": "s3cr3t", "HOME_DIR": "${HOME}", "NPM_TOKEN": "s3cr3t"},
+			Network:      "network_id",
+		}
 		mockDocker := mock_docker.NewMockClient(ctrl)
 		mockDocker.EXPECT().
-			Build(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
-			Times(0)
+			CreateNetwork(gomock.Any(), gomock.Any(), gomock.Any()).
+			AnyTimes().
+			Return("network_id", nil)
 		mockDocker.EXPECT().
-			Run(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
-			Times(0)
+			RemoveNetwork(gomock.Any(), gomock.Eq("network_id")).
+			AnyTimes().
+			Return(nil)
 		mockDocker.EXPECT().
-			ExitCode(gomock.Any(), gomock.Any()).
+			OOMKilled(gomock.Any(), gomock.Any()).
 			AnyTimes().
-			Return(int64(0), nil)
+			Return(false, nil)
 		mockDocker.EXPECT().
-			Rm(gomock.Any(), gomock.Any()).
+			Build(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+			Return(&MockBuildLog{}, nil)
+		mockDocker.EXPECT().
+			Run(gomock.Any(), gomock.Eq(expected), gomock.Any(), gomock.Any()).
+			Times(1).
+			Return("", &MockTextLog{Text: "token is s3cr3t"}, nil)
+		mockDocker.EXPECT().
+			ExitCode(gomock.Any(), gomock.Any()).
 			AnyTimes().
-			Return(nil)
+			Return(int64(0), nil)
 
 		// and
+		var logs []string
 		mockLogStore := mock_logstore.NewMockService(ctrl)
 		mockLogStore.EXPECT().
 			Append(gomock.Any(), gomock.Any()).
 			AnyTimes().
-			Return(nil)
+			DoAndReturn(func(_ uuid.UUID, message model.Message) error {
+				logs = append(logs, message.Text)
+				return nil
+			})
 		mockLogStore.EXPECT().
 			Start(gomock.Any()).
 			AnyTimes().
 			Return(nil)
 		mockLogStore.EXPECT().
-			Finish(gomock.Any()).
+			Running(gomock.Any()).
+			AnyTimes().
+			Return(nil)
+		mockLogStore.EXPECT().
+			Finish(gomock.Any(), gomock.Any(), gomock.Any()).
 			AnyTimes().
 			Return(nil)
 
 		r := &runner.DockerRunner{
 			Name:        "test-runner",
-			BaseWorkDir: "/path/to/not/exists/dir",
+			BaseWorkDir: path.Join(os.TempDir(), "test-runner"),
 			Git:         mockGit,
-			GitHub:      mockGitHub,
+			Reporter:    mockReporter,
 			Docker:      mockDocker,
 			LogStore:    mockLogStore,
+			Queue:       mockQueue,
+			Secrets:     mockSecret,
 		}
 
 		// and
-		repo := &MockRepo{"duck8823/duci", "git@github.com:duck8823/duci.git"}
+		repo := &MockRepo{"duck8823/duci", "git@github.com:duck8823/duci.git", "https://github.com/duck8823/duci", "https://github.com/duck8823/duci.git"}
 
 		// when
 		err := r.Run(context.New("test/task", uuid.New(), &url.URL{}), repo, "master", plumbing.ZeroHash, "Hello World.")
 
 		// then
-		if err == nil {
-			t.Error("must occur error")
+		if err != nil {
+			t.Errorf("must not error. but: %+v", err)
+		}
+
+		// and
+		if !reflect.DeepEqual(logs, []string{"{\"stream\":\"Hello World,\"}", "token is ***"}) {
+			t.Errorf("secret must be masked in logs, but got %+v", logs)
 		}
 	})
 
-	t.Run("when docker build failure", func(t *testing.T) {
+	t.Run("with secrets in config file for pull requests", func(t *testing.T) {
+		// setup
+		allowed := application.Config.Job.SecretsForPullRequests
+		defer func() {
+			application.Config.Job.SecretsForPullRequests = allowed
+		}()
+
+		for _, tt := range []struct {
+			trigger  model.Trigger
+			allowed  bool
+			expected docker.Environments
+		}{
+			{trigger: model.PULL_REQUEST, expected: docker.Environments{"TOKEN": "${NPM_TOKEN}"}},
+			{trigger: model.COMMENT, expected: docker.Environments{"TOKEN": "${NPM_TOKEN}"}},
+			{trigger: model.PULL_REQUEST, allowed: true, expected: docker.Environments{"TOKEN": "s3cr3t", "NPM_TOKEN": "s3cr3t"}},
+		} {
+			t.Run(fmt.Sprintf("%s when allowed %t", tt.trigger, tt.allowed), func(t *testing.T) {
+				// given
+				application.Config.Job.SecretsForPullRequests = tt.allowed
+
+				mockSecret := mock_secret.NewMockService(ctrl)
+				if tt.allowed {
+					mockSecret.EXPECT().
+						All(gomock.Eq("duck8823/duci")).
+						Times(1).
+						Return(map[string]string{"NPM_TOKEN": "s3cr3t"}, nil)
+				}
+
+				// and
+				mockReporter := mock_reporter.NewMockReporter(ctrl)
+				mockReporter.EXPECT().Log(gomock.Any(), gomock.Any()).AnyTimes()
+				mockReporter.EXPECT().Report(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+					AnyTimes().
+					Return(nil)
+
+				// and
+				mockGit := mock_git.NewMockService(ctrl)
+				mockGit.EXPECT().Clone(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(_ interface{}, dir string, _, _, _ interface{}) error {
+						if err := os.MkdirAll(path.Join(dir, ".duci"), 0700); err != nil {
+							return err
+						}
+						return ioutil.WriteFile(path.Join(dir, ".duci/config.yml"), []byte("---\nenvironments:\n  TOKEN: ${NPM_TOKEN}\nsecrets:\n  - NPM_TOKEN"), 0600)
+					})
+
+				// and
+				expected := docker.RuntimeOptions{
+					Environments: tt.expected,
+					Network:      "network_id",
+				}
+				mockDocker := mock_docker.NewMockClient(ctrl)
+				mockDocker.EXPECT().
+					CreateNetwork(gomock.Any(), gomock.Any(), gomock.Any()).
+					AnyTimes().
+					Return("network_id", nil)
+				mockDocker.EXPECT().
+					RemoveNetwork(gomock.Any(), gomock.Eq("network_id")).
+					AnyTimes().
+					Return(nil)
+				mockDocker.EXPECT().
+					OOMKilled(gomock.Any(), gomock.Any()).
+					AnyTimes().
+					Return(false, nil)
+				mockDocker.EXPECT().
+					Build(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+					Return(&MockBuildLog{}, nil)
+				mockDocker.EXPECT().
+					Run(gomock.Any(), gomock.Eq(expected), gomock.Any(), gomock.Any()).
+					Times(1).
+					Return("", &MockJobLog{}, nil)
+				mockDocker.EXPECT().
+					ExitCode(gomock.Any(), gomock.Any()).
+					AnyTimes().
+					Return(int64(0), nil)
+
+				// and
+				mockLogStore := mock_logstore.NewMockService(ctrl)
+				mockLogStore.EXPECT().
+					Append(gomock.Any(), gomock.Any()).
+					AnyTimes().
+					Return(nil)
+				mockLogStore.EXPECT().
+					Start(gomock.Any()).
+					AnyTimes().
+					Return(nil)
+				mockLogStore.EXPECT().
+					Running(gomock.Any()).
+					AnyTimes().
+					Return(nil)
+				mockLogStore.EXPECT().
+					Finish(gomock.Any(), gomock.Any(), gomock.Any()).
+					AnyTimes().
+					Return(nil)
+
+				r := &runner.DockerRunner{
+					Name:        "test-runner",
+					BaseWorkDir: path.Join(os.TempDir(), "test-runner"),
+					Git:         mockGit,
+					Reporter:    mockReporter,
+					Docker:      mockDocker,
+					LogStore:    mockLogStore,
+					Queue:       mockQueue,
+					Secrets:     mockSecret,
+				}
+
+				// and
+				repo := &MockRepo{"duck8823/duci", "git@github.com:duck8823/duci.git", "https://github.com/duck8823/duci", "https://github.com/duck8823/duci.git"}
+				ctx := context.WithTrigger(context.New("test/task", uuid.New(), &url.URL{}), string(tt.trigger))
+
+				// when
+				err := r.Run(ctx, repo, "master", plumbing.ZeroHash, "Hello World.")
+
+				// then
+				if err != nil {
+					t.Errorf("must not error. but: %+v", err)
+				}
+			})
+		}
+	})
+
+	t.Run("with steps in config file", func(t *testing.T) {
 		// given
-		mockGitHub := mock_github.NewMockService(ctrl)
-		mockGitHub.EXPECT().CreateCommitStatus(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
-			Times(2).
-			Return(nil)
+		var reports []string
+		mockReporter := mock_reporter.NewMockReporter(ctrl)
+		mockReporter.EXPECT().Log(gomock.Any(), gomock.Any()).AnyTimes()
+		mockReporter.EXPECT().Report(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+			AnyTimes().
+			Do(func(ctx context.Context, _ interface{}, _ interface{}, state model.State, _ string) {
+				reports = append(reports, fmt.Sprintf("%s:%s", ctx.TaskName(), state))
+			})
 
 		// and
 		mockGit := mock_git.NewMockService(ctrl)
 		mockGit.EXPECT().Clone(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
 			Times(1).
-			Return(nil)
+			DoAndReturn(func(_ interface{}, dir string, _, _, _ interface{}) error {
+				if err := os.MkdirAll(path.Join(dir, ".duci"), 0700); err != nil {
+					return err
+				}
+				return ioutil.WriteFile(path.Join(dir, ".duci/config.yml"), []byte(`---
+environments:
+  GO111MODULE: "on"
+  STAGE: default
+steps:
+  - name: build
+    command: [make, build]
+  - name: test
+    command: [make, test]
+    environments:
+      STAGE: test
+    timeout: 60
+  - name: integration
+    command: [make, integration]
+`), 0600)
+			})
 
 		// and
+		var commands [][]string
 		mockDocker := mock_docker.NewMockClient(ctrl)
 		mockDocker.EXPECT().
-			Build(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
-			Times(1).
-			Return(nil, errors.New("test"))
+			CreateNetwork(gomock.Any(), gomock.Any(), gomock.Any()).
+			AnyTimes().
+			Return("network_id", nil)
 		mockDocker.EXPECT().
-			Run(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
-			Times(0)
+			RemoveNetwork(gomock.Any(), gomock.Eq("network_id")).
+			AnyTimes().
+			Return(nil)
 		mockDocker.EXPECT().
-			ExitCode(gomock.Any(), gomock.Any()).
+			OOMKilled(gomock.Any(), gomock.Any()).
 			AnyTimes().
+			Return(false, nil)
+		mockDocker.EXPECT().
+			Build(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+			Return(&MockBuildLog{}, nil)
+		mockDocker.EXPECT().
+			Run(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+			Times(2).
+			DoAndReturn(func(ctx context.Context, opts docker.RuntimeOptions, tag string, cmd ...string) (string, docker.Log, error) {
+				commands = append(commands, cmd)
+				if opts.Environments["GO111MODULE"] != "on" {
+					t.Errorf("environments of job must be inherited, but got %+v", opts.Environments)
+				}
+				if stage := map[string]string{"build": "default", "test": "test"}[cmd[1]]; opts.Environments["STAGE"] != stage {
+					t.Errorf("environments of step must take precedence, but got %+v", opts.Environments)
+				}
+				return cmd[1], &MockJobLog{}, nil
+			})
+		mockDocker.EXPECT().
+			ExitCode(gomock.Any(), gomock.Eq("build")).
 			Return(int64(0), nil)
+		mockDocker.EXPECT().
+			ExitCode(gomock.Any(), gomock.Eq("test")).
+			Return(int64(2), nil)
 		mockDocker.EXPECT().
 			Rm(gomock.Any(), gomock.Any()).
 			AnyTimes().
@@ -488,176 +773,1265 @@ func TestRunnerImpl_Run(t *testing.T) {
 			AnyTimes().
 			Return(nil)
 		mockLogStore.EXPECT().
-			Finish(gomock.Any()).
+			Running(gomock.Any()).
 			AnyTimes().
 			Return(nil)
+		mockLogStore.EXPECT().
+			Finish(gomock.Any(), gomock.Eq(model.FAILURE), gomock.Eq(int64(2))).
+			Times(1).
+			Return(nil)
 
 		r := &runner.DockerRunner{
 			Name:        "test-runner",
 			BaseWorkDir: path.Join(os.TempDir(), "test-runner"),
 			Git:         mockGit,
-			GitHub:      mockGitHub,
+			Reporter:    mockReporter,
 			Docker:      mockDocker,
 			LogStore:    mockLogStore,
+			Queue:       mockQueue,
+			Secrets:     mockSecret,
 		}
 
 		// and
-		repo := &MockRepo{"duck8823/duci", "git@github.com:duck8823/duci.git"}
+		repo := &MockRepo{"duck8823/duci", "git@github.com:duck8823/duci.git", "https://github.com/duck8823/duci", "https://github.com/duck8823/duci.git"}
 
 		// when
-		err := r.Run(context.New("test/task", uuid.New(), &url.URL{}), repo, "master", plumbing.ZeroHash, "Hello World.")
+		err := r.Run(context.New("duci/push", uuid.New(), &url.URL{}), repo, "master", plumbing.ZeroHash)
 
 		// then
-		if err == nil {
-			t.Error("must occur error")
+		if err != runner.Failure {
+			t.Errorf("error must be %+v, but got %+v", runner.Failure, err)
+		}
+
+		// and
+		expectedCommands := [][]string{{"make", "build"}, {"make", "test"}}
+		if !reflect.DeepEqual(commands, expectedCommands) {
+			t.Errorf("commands must be %+v, but got %+v", expectedCommands, commands)
+		}
+
+		// and
+		expectedReports := []string{
+			"duci/push:running",
+			"duci/push/build:running",
+			"duci/push/build:success",
+			"duci/push/test:running",
+			"duci/push/test:failure",
+			"duci/push:failure",
+		}
+		if !reflect.DeepEqual(reports, expectedReports) {
+			t.Errorf("reports must be %+v, but got %+v", expectedReports, reports)
 		}
 	})
 
-	t.Run("when docker run error", func(t *testing.T) {
+	t.Run("with matrix in config file", func(t *testing.T) {
 		// given
-		mockGitHub := mock_github.NewMockService(ctrl)
-		mockGitHub.EXPECT().CreateCommitStatus(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
-			Times(2).
-			Return(nil)
+		id := uuid.New()
+		var mu sync.Mutex
+		reports := map[string]model.State{}
+		var reportedLogs []string
+		mockReporter := mock_reporter.NewMockReporter(ctrl)
+		mockReporter.EXPECT().Log(gomock.Any(), gomock.Any()).
+			AnyTimes().
+			Do(func(_ context.Context, message model.Message) {
+				mu.Lock()
+				defer mu.Unlock()
+				reportedLogs = append(reportedLogs, message.Text)
+			})
+		mockReporter.EXPECT().Report(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+			AnyTimes().
+			Do(func(ctx context.Context, _ interface{}, _ interface{}, state model.State, _ string) {
+				mu.Lock()
+				defer mu.Unlock()
+				reports[ctx.TaskName()] = state
+			})
 
 		// and
 		mockGit := mock_git.NewMockService(ctrl)
 		mockGit.EXPECT().Clone(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
 			Times(1).
-			Return(nil)
+			DoAndReturn(func(_ interface{}, dir string, _, _, _ interface{}) error {
+				if err := os.MkdirAll(path.Join(dir, ".duci"), 0700); err != nil {
+					return err
+				}
+				return ioutil.WriteFile(path.Join(dir, ".duci/config.yml"), []byte(`---
+matrix:
+  args:
+    - GO_VERSION: "1.10"
+    - GO_VERSION: "1.11"
+`), 0600)
+			})
 
 		// and
+		builds := map[string]string{}
 		mockDocker := mock_docker.NewMockClient(ctrl)
 		mockDocker.EXPECT().
-			Build(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
-			Times(1).
-			Return(&MockBuildLog{}, nil)
+			CreateNetwork(gomock.Any(), gomock.Any(), gomock.Any()).
+			AnyTimes().
+			Return("network_id", nil)
 		mockDocker.EXPECT().
-			Run(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
-			Times(1).
-			Return("", nil, errors.New("test"))
+			RemoveNetwork(gomock.Any(), gomock.Eq("network_id")).
+			AnyTimes().
+			Return(nil)
 		mockDocker.EXPECT().
-			ExitCode(gomock.Any(), gomock.Any()).
+			OOMKilled(gomock.Any(), gomock.Any()).
 			AnyTimes().
+			Return(false, nil)
+		mockDocker.EXPECT().
+			Build(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Eq("./Dockerfile"), gomock.Any()).
+			Times(2).
+			DoAndReturn(func(_ interface{}, _ io.Reader, tag string, _ string, args docker.BuildArgs) (docker.Log, error) {
+				mu.Lock()
+				defer mu.Unlock()
+				builds[tag] = args["GO_VERSION"]
+				return &MockBuildLog{}, nil
+			})
+		mockDocker.EXPECT().
+			Run(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+			Times(2).
+			DoAndReturn(func(_ interface{}, _ docker.RuntimeOptions, tag string, _ ...string) (string, docker.Log, error) {
+				return tag, &MockJobLog{}, nil
+			})
+		mockDocker.EXPECT().
+			ExitCode(gomock.Any(), gomock.Eq(fmt.Sprintf("duck8823/duci:%s-matrix-0", id))).
 			Return(int64(0), nil)
+		mockDocker.EXPECT().
+			ExitCode(gomock.Any(), gomock.Eq(fmt.Sprintf("duck8823/duci:%s-matrix-1", id))).
+			Return(int64(1), nil)
 		mockDocker.EXPECT().
 			Rm(gomock.Any(), gomock.Any()).
 			AnyTimes().
 			Return(nil)
 
 		// and
+		var logs []string
 		mockLogStore := mock_logstore.NewMockService(ctrl)
 		mockLogStore.EXPECT().
 			Append(gomock.Any(), gomock.Any()).
 			AnyTimes().
+			Do(func(_ uuid.UUID, message model.Message) {
+				mu.Lock()
+				defer mu.Unlock()
+				logs = append(logs, message.Text)
+			}).
 			Return(nil)
 		mockLogStore.EXPECT().
 			Start(gomock.Any()).
 			AnyTimes().
 			Return(nil)
 		mockLogStore.EXPECT().
-			Finish(gomock.Any()).
+			Running(gomock.Any()).
 			AnyTimes().
 			Return(nil)
+		mockLogStore.EXPECT().
+			Finish(gomock.Any(), gomock.Eq(model.FAILURE), gomock.Eq(int64(1))).
+			Times(1).
+			Return(nil)
 
 		r := &runner.DockerRunner{
 			Name:        "test-runner",
 			BaseWorkDir: path.Join(os.TempDir(), "test-runner"),
 			Git:         mockGit,
-			GitHub:      mockGitHub,
+			Reporter:    mockReporter,
 			Docker:      mockDocker,
 			LogStore:    mockLogStore,
+			Queue:       mockQueue,
+			Secrets:     mockSecret,
 		}
 
 		// and
-		repo := &MockRepo{"duck8823/duci", "git@github.com:duck8823/duci.git"}
+		repo := &MockRepo{"duck8823/duci", "git@github.com:duck8823/duci.git", "https://github.com/duck8823/duci", "https://github.com/duck8823/duci.git"}
 
 		// when
-		err := r.Run(context.New("test/task", uuid.New(), &url.URL{}), repo, "master", plumbing.ZeroHash, "Hello World.")
+		err := r.Run(context.New("duci/push", id, &url.URL{}), repo, "master", plumbing.ZeroHash)
 
 		// then
-		if err == nil {
-			t.Error("must occur error")
+		if err != runner.Failure {
+			t.Errorf("error must be %+v, but got %+v", runner.Failure, err)
 		}
-	})
 
-	t.Run("when fail to remove container", func(t *testing.T) {
-		// given
-		expected := errors.New("test")
+		// and
+		expectedBuilds := map[string]string{
+			fmt.Sprintf("duck8823/duci:%s-matrix-0", id): "1.10",
+			fmt.Sprintf("duck8823/duci:%s-matrix-1", id): "1.11",
+		}
+		if !reflect.DeepEqual(builds, expectedBuilds) {
+			t.Errorf("builds must be %+v, but got %+v", expectedBuilds, builds)
+		}
 
 		// and
-		mockGitHub := mock_github.NewMockService(ctrl)
-		mockGitHub.EXPECT().CreateCommitStatus(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
-			Times(2).
-			Return(nil)
+		expectedReports := map[string]model.State{
+			"duci/push":                 model.FAILURE,
+			"duci/push/GO_VERSION=1.10": model.SUCCESS,
+			"duci/push/GO_VERSION=1.11": model.FAILURE,
+		}
+		if !reflect.DeepEqual(reports, expectedReports) {
+			t.Errorf("reports must be %+v, but got %+v", expectedReports, reports)
+		}
 
 		// and
-		mockGit := mock_git.NewMockService(ctrl)
-		mockGit.EXPECT().Clone(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
-			Times(1).
-			Return(nil)
+		for _, prefix := range []string{"[GO_VERSION=1.10] ", "[GO_VERSION=1.11] "} {
+			found := false
+			for _, log := range logs {
+				if strings.HasPrefix(log, prefix) {
+					found = true
+				}
+			}
+			if !found {
+				t.Errorf("logs must be prefixed with %s, but got %+v", prefix, logs)
+			}
+		}
 
 		// and
-		mockDocker := mock_docker.NewMockClient(ctrl)
-		mockDocker.EXPECT().
-			Build(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Eq(".duci/Dockerfile")).
-			Return(&MockBuildLog{}, nil)
-		mockDocker.EXPECT().
-			Build(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Not(".duci/Dockerfile")).
-			Return(nil, errors.New("must not call this"))
-		mockDocker.EXPECT().
-			Run(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
-			Times(1).
+		if len(reportedLogs) == 0 {
+			t.Error("logs must be reported")
+		}
+		for _, log := range reportedLogs {
+			if strings.HasPrefix(log, "[GO_VERSION=") {
+				t.Errorf("reported logs must not be prefixed, but got %s", log)
+			}
+		}
+	})
+
+	t.Run("with services in config file", func(t *testing.T) {
+		// setup
+		config := []byte(`---
+resources:
+  memory: 512m
+services:
+  - name: postgres
+    image: postgres:10
+    environments:
+      POSTGRES_PASSWORD: secret
+    healthcheck:
+      test: [CMD, pg_isready]
+      interval: 1
+      retries: 10
+`)
+		expectedService := docker.ServiceOptions{
+			Name:         "postgres",
+			Image:        "postgres:10",
+			Environments: docker.Environments{"POSTGRES_PASSWORD": "secret"},
+			Healthcheck:  &docker.Healthcheck{Test: []string{"CMD", "pg_isready"}, Interval: 1, Retries: 10},
+			Resources:    docker.Resources{Memory: 512 << 20},
+		}
+
+		for _, tt := range []struct {
+			name    string
+			healthy error
+			runs    int
+			state   model.State
+		}{
+			{name: "when services are healthy", healthy: nil, runs: 1, state: model.SUCCESS},
+			{name: "when services are unhealthy", healthy: errors.New("service is unhealthy"), runs: 0, state: model.ERROR},
+		} {
+			t.Run(tt.name, func(t *testing.T) {
+				// given
+				mockReporter := mock_reporter.NewMockReporter(ctrl)
+				mockReporter.EXPECT().Log(gomock.Any(), gomock.Any()).AnyTimes()
+				mockReporter.EXPECT().Report(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+					AnyTimes().
+					Return(nil)
+
+				// and
+				mockGit := mock_git.NewMockService(ctrl)
+				mockGit.EXPECT().Clone(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(_ interface{}, dir string, _, _, _ interface{}) error {
+						if err := os.MkdirAll(path.Join(dir, ".duci"), 0700); err != nil {
+							return err
+						}
+						return ioutil.WriteFile(path.Join(dir, ".duci/config.yml"), config, 0600)
+					})
+
+				// and
+				id := uuid.New()
+				mockDocker := mock_docker.NewMockClient(ctrl)
+				mockDocker.EXPECT().
+					OOMKilled(gomock.Any(), gomock.Any()).
+					AnyTimes().
+					Return(false, nil)
+				mockDocker.EXPECT().
+					CreateNetwork(gomock.Any(), gomock.Eq(fmt.Sprintf("duci-%s", id)), gomock.Eq(false)).
+					Times(1).
+					Return("network_id", nil)
+				mockDocker.EXPECT().
+					RunService(gomock.Any(), gomock.Eq(expectedService), gomock.Eq("network_id")).
+					Times(1).
+					Return("service_id", nil)
+				mockDocker.EXPECT().
+					WaitHealthy(gomock.Any(), gomock.Eq("service_id")).
+					Times(1).
+					Return(tt.healthy)
+				mockDocker.EXPECT().
+					Build(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+					Times(tt.runs).
+					Return(&MockBuildLog{}, nil)
+				mockDocker.EXPECT().
+					Run(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+					Times(tt.runs).
+					DoAndReturn(func(_ interface{}, opts docker.RuntimeOptions, _ string, _ ...string) (string, docker.Log, error) {
+						if opts.Network != "network_id" {
+							t.Errorf("container must join network of the job, but got %s", opts.Network)
+						}
+						return "container_id", &MockJobLog{}, nil
+					})
+				mockDocker.EXPECT().
+					ExitCode(gomock.Any(), gomock.Eq("container_id")).
+					Times(tt.runs).
+					Return(int64(0), nil)
+				mockDocker.EXPECT().
+					Rm(gomock.Any(), gomock.Eq("container_id")).
+					Times(tt.runs).
+					Return(nil)
+
+				// and
+				mockDocker.EXPECT().
+					Kill(gomock.Any(), gomock.Eq("service_id")).
+					Times(1).
+					Return(nil)
+				mockDocker.EXPECT().
+					Rm(gomock.Any(), gomock.Eq("service_id")).
+					Times(1).
+					Return(nil)
+				mockDocker.EXPECT().
+					RemoveNetwork(gomock.Any(), gomock.Eq("network_id")).
+					Times(1).
+					Return(nil)
+
+				// and
+				mockLogStore := mock_logstore.NewMockService(ctrl)
+				mockLogStore.EXPECT().
+					Append(gomock.Any(), gomock.Any()).
+					AnyTimes().
+					Return(nil)
+				mockLogStore.EXPECT().
+					Start(gomock.Any()).
+					AnyTimes().
+					Return(nil)
+				mockLogStore.EXPECT().
+					Running(gomock.Any()).
+					AnyTimes().
+					Return(nil)
+				mockLogStore.EXPECT().
+					Finish(gomock.Any(), gomock.Eq(tt.state), gomock.Any()).
+					Times(1).
+					Return(nil)
+
+				r := &runner.DockerRunner{
+					Name:        "test-runner",
+					BaseWorkDir: path.Join(os.TempDir(), "test-runner"),
+					Git:         mockGit,
+					Reporter:    mockReporter,
+					Docker:      mockDocker,
+					LogStore:    mockLogStore,
+					Queue:       mockQueue,
+					Secrets:     mockSecret,
+				}
+
+				// and
+				repo := &MockRepo{"duck8823/duci", "git@github.com:duck8823/duci.git", "https://github.com/duck8823/duci", "https://github.com/duck8823/duci.git"}
+
+				// when
+				err := r.Run(context.New("test/task", id, &url.URL{}), repo, "master", plumbing.ZeroHash)
+
+				// then
+				if (err == nil) != (tt.healthy == nil) {
+					t.Errorf("error must be %+v, but got %+v", tt.healthy, err)
+				}
+			})
+		}
+	})
+
+	t.Run("with network in config file", func(t *testing.T) {
+		// setup
+		defaultNetworks := application.Config.Job.Networks
+		defer func() {
+			application.Config.Job.Networks = defaultNetworks
+		}()
+
+		for _, tt := range []struct {
+			name     string
+			config   string
+			allowed  []string
+			internal bool
+			creates  int
+			network  string
+			runs     int
+			state    model.State
+		}{
+			{name: "without network", config: "---", allowed: defaultNetworks, internal: false, creates: 1, network: "network_id", runs: 1, state: model.SUCCESS},
+			{name: "with isolated", config: "---\nnetwork: isolated", allowed: defaultNetworks, internal: true, creates: 1, network: "network_id", runs: 1, state: model.SUCCESS},
+			{name: "with none", config: "---\nnetwork: none", allowed: defaultNetworks, creates: 0, network: "none", runs: 1, state: model.SUCCESS},
+			{name: "with not allowed", config: "---\nnetwork: bridge", allowed: []string{"isolated"}, creates: 0, runs: 0, state: model.ERROR},
+			{name: "with unknown", config: "---\nnetwork: host", allowed: []string{"host"}, creates: 0, runs: 0, state: model.ERROR},
+		} {
+			t.Run(tt.name, func(t *testing.T) {
+				// given
+				application.Config.Job.Networks = tt.allowed
+
+				// and
+				mockReporter := mock_reporter.NewMockReporter(ctrl)
+				mockReporter.EXPECT().Log(gomock.Any(), gomock.Any()).AnyTimes()
+				mockReporter.EXPECT().Report(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+					AnyTimes().
+					Return(nil)
+
+				// and
+				mockGit := mock_git.NewMockService(ctrl)
+				mockGit.EXPECT().Clone(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(_ interface{}, dir string, _, _, _ interface{}) error {
+						if err := os.MkdirAll(path.Join(dir, ".duci"), 0700); err != nil {
+							return err
+						}
+						return ioutil.WriteFile(path.Join(dir, ".duci/config.yml"), []byte(tt.config), 0600)
+					})
+
+				// and
+				mockDocker := mock_docker.NewMockClient(ctrl)
+				mockDocker.EXPECT().
+					OOMKilled(gomock.Any(), gomock.Any()).
+					AnyTimes().
+					Return(false, nil)
+				mockDocker.EXPECT().
+					CreateNetwork(gomock.Any(), gomock.Any(), gomock.Eq(tt.internal)).
+					Times(tt.creates).
+					Return("network_id", nil)
+				mockDocker.EXPECT().
+					RemoveNetwork(gomock.Any(), gomock.Eq("network_id")).
+					Times(tt.creates).
+					Return(nil)
+				mockDocker.EXPECT().
+					Build(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+					Times(tt.runs).
+					Return(&MockBuildLog{}, nil)
+				mockDocker.EXPECT().
+					Run(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+					Times(tt.runs).
+					DoAndReturn(func(_ interface{}, opts docker.RuntimeOptions, _ string, _ ...string) (string, docker.Log, error) {
+						if opts.Network != tt.network {
+							t.Errorf("network must be %s, but got %s", tt.network, opts.Network)
+						}
+						return "container_id", &MockJobLog{}, nil
+					})
+				mockDocker.EXPECT().
+					ExitCode(gomock.Any(), gomock.Any()).
+					Times(tt.runs).
+					Return(int64(0), nil)
+				mockDocker.EXPECT().
+					Rm(gomock.Any(), gomock.Any()).
+					Times(tt.runs).
+					Return(nil)
+
+				// and
+				mockLogStore := mock_logstore.NewMockService(ctrl)
+				mockLogStore.EXPECT().
+					Append(gomock.Any(), gomock.Any()).
+					AnyTimes().
+					Return(nil)
+				mockLogStore.EXPECT().
+					Start(gomock.Any()).
+					AnyTimes().
+					Return(nil)
+				mockLogStore.EXPECT().
+					Running(gomock.Any()).
+					AnyTimes().
+					Return(nil)
+				mockLogStore.EXPECT().
+					Finish(gomock.Any(), gomock.Eq(tt.state), gomock.Any()).
+					Times(1).
+					Return(nil)
+
+				r := &runner.DockerRunner{
+					Name:        "test-runner",
+					BaseWorkDir: path.Join(os.TempDir(), "test-runner"),
+					Git:         mockGit,
+					Reporter:    mockReporter,
+					Docker:      mockDocker,
+					LogStore:    mockLogStore,
+					Queue:       mockQueue,
+					Secrets:     mockSecret,
+				}
+
+				// and
+				repo := &MockRepo{"duck8823/duci", "git@github.com:duck8823/duci.git", "https://github.com/duck8823/duci", "https://github.com/duck8823/duci.git"}
+
+				// when
+				err := r.Run(context.New("test/task", uuid.New(), &url.URL{}), repo, "master", plumbing.ZeroHash)
+
+				// then
+				if (err == nil) != (tt.state == model.SUCCESS) {
+					t.Errorf("unexpected error: %+v", err)
+				}
+			})
+		}
+	})
+
+	t.Run("with git options in config file", func(t *testing.T) {
+		// given
+		mockReporter := mock_reporter.NewMockReporter(ctrl)
+		mockReporter.EXPECT().Log(gomock.Any(), gomock.Any()).AnyTimes()
+		mockReporter.EXPECT().Report(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+			Times(2).
+			Return(nil)
+
+		// and
+		mockGit := mock_git.NewMockService(ctrl)
+		mockGit.EXPECT().Clone(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+			Times(1).
+			DoAndReturn(func(_ interface{}, dir string, _, _, _ interface{}) error {
+				if err := os.MkdirAll(path.Join(dir, ".duci"), 0700); err != nil {
+					return err
+				}
+				return ioutil.WriteFile(path.Join(dir, ".duci/config.yml"), []byte("---\ngit:\n  submodules: true\n  lfs: true"), 0600)
+			})
+		mockGit.EXPECT().Update(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Eq(git.Options{Submodules: true, LFS: true})).
+			Times(1).
+			Return(errors.New("failed to update submodules"))
+
+		// and
+		mockDocker := mock_docker.NewMockClient(ctrl)
+		mockDocker.EXPECT().
+			CreateNetwork(gomock.Any(), gomock.Any(), gomock.Any()).
+			AnyTimes().
+			Return("network_id", nil)
+		mockDocker.EXPECT().
+			RemoveNetwork(gomock.Any(), gomock.Eq("network_id")).
+			AnyTimes().
+			Return(nil)
+		mockDocker.EXPECT().
+			OOMKilled(gomock.Any(), gomock.Any()).
+			AnyTimes().
+			Return(false, nil)
+		mockDocker.EXPECT().
+			Build(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+			Times(0)
+
+		// and
+		mockLogStore := mock_logstore.NewMockService(ctrl)
+		mockLogStore.EXPECT().
+			Append(gomock.Any(), gomock.Any()).
+			Times(1).
+			DoAndReturn(func(_ uuid.UUID, message model.Message) error {
+				if message.Text != "failed to update submodules" {
+					t.Errorf("error must be appended to log, but got %s", message.Text)
+				}
+				return nil
+			})
+		mockLogStore.EXPECT().
+			Start(gomock.Any()).
+			AnyTimes().
+			Return(nil)
+		mockLogStore.EXPECT().
+			Running(gomock.Any()).
+			AnyTimes().
+			Return(nil)
+		mockLogStore.EXPECT().
+			Finish(gomock.Any(), gomock.Eq(model.ERROR), gomock.Any()).
+			Times(1).
+			Return(nil)
+
+		r := &runner.DockerRunner{
+			Name:        "test-runner",
+			BaseWorkDir: path.Join(os.TempDir(), "test-runner"),
+			Git:         mockGit,
+			Reporter:    mockReporter,
+			Docker:      mockDocker,
+			LogStore:    mockLogStore,
+			Queue:       mockQueue,
+			Secrets:     mockSecret,
+		}
+
+		// and
+		repo := &MockRepo{"duck8823/duci", "git@github.com:duck8823/duci.git", "https://github.com/duck8823/duci", "https://github.com/duck8823/duci.git"}
+
+		// when
+		err := r.Run(context.New("test/task", uuid.New(), &url.URL{}), repo, "master", plumbing.ZeroHash, "Hello World.")
+
+		// then
+		if err == nil {
+			t.Error("error must occur")
+		}
+	})
+
+	t.Run("when failed to git clone", func(t *testing.T) {
+		// given
+		mockReporter := mock_reporter.NewMockReporter(ctrl)
+		mockReporter.EXPECT().Log(gomock.Any(), gomock.Any()).AnyTimes()
+		mockReporter.EXPECT().Report(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+			Times(2).
+			Return(nil)
+
+		// and
+		mockGit := mock_git.NewMockService(ctrl)
+		mockGit.EXPECT().Clone(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+			Times(1).
+			Return(errors.New("error"))
+
+		// and
+		mockDocker := mock_docker.NewMockClient(ctrl)
+		mockDocker.EXPECT().
+			CreateNetwork(gomock.Any(), gomock.Any(), gomock.Any()).
+			AnyTimes().
+			Return("network_id", nil)
+		mockDocker.EXPECT().
+			RemoveNetwork(gomock.Any(), gomock.Eq("network_id")).
+			AnyTimes().
+			Return(nil)
+		mockDocker.EXPECT().
+			OOMKilled(gomock.Any(), gomock.Any()).
+			AnyTimes().
+			Return(false, nil)
+		mockDocker.EXPECT().
+			Build(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+			Times(0)
+		mockDocker.EXPECT().
+			Run(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+			Times(0)
+		mockDocker.EXPECT().
+			ExitCode(gomock.Any(), gomock.Any()).
+			AnyTimes().
+			Return(int64(0), nil)
+		mockDocker.EXPECT().
+			Rm(gomock.Any(), gomock.Any()).
+			AnyTimes().
+			Return(nil)
+
+		// and
+		mockLogStore := mock_logstore.NewMockService(ctrl)
+		mockLogStore.EXPECT().
+			Append(gomock.Any(), gomock.Any()).
+			AnyTimes().
+			Return(nil)
+		mockLogStore.EXPECT().
+			Start(gomock.Any()).
+			AnyTimes().
+			Return(nil)
+		mockLogStore.EXPECT().
+			Running(gomock.Any()).
+			AnyTimes().
+			Return(nil)
+		mockLogStore.EXPECT().
+			Finish(gomock.Any(), gomock.Any(), gomock.Any()).
+			AnyTimes().
+			Return(nil)
+
+		r := &runner.DockerRunner{
+			Name:        "test-runner",
+			BaseWorkDir: path.Join(os.TempDir(), "test-runner"),
+			Git:         mockGit,
+			Reporter:    mockReporter,
+			Docker:      mockDocker,
+			LogStore:    mockLogStore,
+			Queue:       mockQueue,
+			Secrets:     mockSecret,
+		}
+
+		// and
+		repo := &MockRepo{"duck8823/duci", "git@github.com:duck8823/duci.git", "https://github.com/duck8823/duci", "https://github.com/duck8823/duci.git"}
+
+		// when
+		err := r.Run(context.New("test/task", uuid.New(), &url.URL{}), repo, "master", plumbing.ZeroHash, "Hello World.")
+
+		// then
+		if err == nil {
+			t.Error("must occur error")
+		}
+	})
+
+	t.Run("when failed store#$tart", func(t *testing.T) {
+		// given
+		mockReporter := mock_reporter.NewMockReporter(ctrl)
+		mockReporter.EXPECT().Log(gomock.Any(), gomock.Any()).AnyTimes()
+		mockReporter.EXPECT().Report(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+			Times(2).
+			Return(nil)
+
+		// and
+		mockLogStore := mock_logstore.NewMockService(ctrl)
+		mockLogStore.EXPECT().
+			Start(gomock.Any()).
+			AnyTimes().
+			Return(errors.New("test error"))
+		mockLogStore.EXPECT().
+			Running(gomock.Any()).
+			AnyTimes().
+			Return(nil)
+
+		r := &runner.DockerRunner{
+			Name:        "test-runner",
+			BaseWorkDir: path.Join(os.TempDir(), "test-runner"),
+			Reporter:    mockReporter,
+			LogStore:    mockLogStore,
+			Queue:       mockQueue,
+			Secrets:     mockSecret,
+		}
+
+		// and
+		repo := &MockRepo{"duck8823/duci", "git@github.com:duck8823/duci.git", "https://github.com/duck8823/duci", "https://github.com/duck8823/duci.git"}
+
+		// when
+		err := r.Run(context.New("test/task", uuid.New(), &url.URL{}), repo, "master", plumbing.ZeroHash, "Hello World.")
+
+		// then
+		if err == nil {
+			t.Error("must occur error")
+		}
+	})
+
+	t.Run("when workdir not exists", func(t *testing.T) {
+		// given
+		mockReporter := mock_reporter.NewMockReporter(ctrl)
+		mockReporter.EXPECT().Log(gomock.Any(), gomock.Any()).AnyTimes()
+		mockReporter.EXPECT().Report(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+			Times(2).
+			Return(nil)
+
+		// and
+		mockGit := mock_git.NewMockService(ctrl)
+		mockGit.EXPECT().Clone(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+			Times(1).
+			Return(nil)
+
+		// and
+		mockDocker := mock_docker.NewMockClient(ctrl)
+		mockDocker.EXPECT().
+			CreateNetwork(gomock.Any(), gomock.Any(), gomock.Any()).
+			AnyTimes().
+			Return("network_id", nil)
+		mockDocker.EXPECT().
+			RemoveNetwork(gomock.Any(), gomock.Eq("network_id")).
+			AnyTimes().
+			Return(nil)
+		mockDocker.EXPECT().
+			OOMKilled(gomock.Any(), gomock.Any()).
+			AnyTimes().
+			Return(false, nil)
+		mockDocker.EXPECT().
+			Build(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+			Times(0)
+		mockDocker.EXPECT().
+			Run(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+			Times(0)
+		mockDocker.EXPECT().
+			ExitCode(gomock.Any(), gomock.Any()).
+			AnyTimes().
+			Return(int64(0), nil)
+		mockDocker.EXPECT().
+			Rm(gomock.Any(), gomock.Any()).
+			AnyTimes().
+			Return(nil)
+
+		// and
+		mockLogStore := mock_logstore.NewMockService(ctrl)
+		mockLogStore.EXPECT().
+			Append(gomock.Any(), gomock.Any()).
+			AnyTimes().
+			Return(nil)
+		mockLogStore.EXPECT().
+			Start(gomock.Any()).
+			AnyTimes().
+			Return(nil)
+		mockLogStore.EXPECT().
+			Running(gomock.Any()).
+			AnyTimes().
+			Return(nil)
+		mockLogStore.EXPECT().
+			Finish(gomock.Any(), gomock.Any(), gomock.Any()).
+			AnyTimes().
+			Return(nil)
+
+		r := &runner.DockerRunner{
+			Name:        "test-runner",
+			BaseWorkDir: "/path/to/not/exists/dir",
+			Git:         mockGit,
+			Reporter:    mockReporter,
+			Docker:      mockDocker,
+			LogStore:    mockLogStore,
+			Queue:       mockQueue,
+			Secrets:     mockSecret,
+		}
+
+		// and
+		repo := &MockRepo{"duck8823/duci", "git@github.com:duck8823/duci.git", "https://github.com/duck8823/duci", "https://github.com/duck8823/duci.git"}
+
+		// when
+		err := r.Run(context.New("test/task", uuid.New(), &url.URL{}), repo, "master", plumbing.ZeroHash, "Hello World.")
+
+		// then
+		if err == nil {
+			t.Error("must occur error")
+		}
+	})
+
+	t.Run("when docker build failure", func(t *testing.T) {
+		// given
+		mockReporter := mock_reporter.NewMockReporter(ctrl)
+		mockReporter.EXPECT().Log(gomock.Any(), gomock.Any()).AnyTimes()
+		mockReporter.EXPECT().Report(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+			Times(2).
+			Return(nil)
+
+		// and
+		mockGit := mock_git.NewMockService(ctrl)
+		mockGit.EXPECT().Clone(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+			Times(1).
+			DoAndReturn(func(_ interface{}, dir string, _, _, _ interface{}) error {
+				return os.MkdirAll(dir, 0700)
+			})
+
+		// and
+		mockDocker := mock_docker.NewMockClient(ctrl)
+		mockDocker.EXPECT().
+			CreateNetwork(gomock.Any(), gomock.Any(), gomock.Any()).
+			AnyTimes().
+			Return("network_id", nil)
+		mockDocker.EXPECT().
+			RemoveNetwork(gomock.Any(), gomock.Eq("network_id")).
+			AnyTimes().
+			Return(nil)
+		mockDocker.EXPECT().
+			OOMKilled(gomock.Any(), gomock.Any()).
+			AnyTimes().
+			Return(false, nil)
+		mockDocker.EXPECT().
+			Build(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+			Times(1).
+			Return(nil, errors.New("test"))
+		mockDocker.EXPECT().
+			Run(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+			Times(0)
+		mockDocker.EXPECT().
+			ExitCode(gomock.Any(), gomock.Any()).
+			AnyTimes().
+			Return(int64(0), nil)
+		mockDocker.EXPECT().
+			Rm(gomock.Any(), gomock.Any()).
+			AnyTimes().
+			Return(nil)
+
+		// and
+		mockLogStore := mock_logstore.NewMockService(ctrl)
+		mockLogStore.EXPECT().
+			Append(gomock.Any(), gomock.Any()).
+			AnyTimes().
+			Return(nil)
+		mockLogStore.EXPECT().
+			Start(gomock.Any()).
+			AnyTimes().
+			Return(nil)
+		mockLogStore.EXPECT().
+			Running(gomock.Any()).
+			AnyTimes().
+			Return(nil)
+		mockLogStore.EXPECT().
+			Finish(gomock.Any(), gomock.Any(), gomock.Any()).
+			AnyTimes().
+			Return(nil)
+
+		r := &runner.DockerRunner{
+			Name:        "test-runner",
+			BaseWorkDir: path.Join(os.TempDir(), "test-runner"),
+			Git:         mockGit,
+			Reporter:    mockReporter,
+			Docker:      mockDocker,
+			LogStore:    mockLogStore,
+			Queue:       mockQueue,
+			Secrets:     mockSecret,
+		}
+
+		// and
+		repo := &MockRepo{"duck8823/duci", "git@github.com:duck8823/duci.git", "https://github.com/duck8823/duci", "https://github.com/duck8823/duci.git"}
+
+		// when
+		err := r.Run(context.New("test/task", uuid.New(), &url.URL{}), repo, "master", plumbing.ZeroHash, "Hello World.")
+
+		// then
+		if err == nil {
+			t.Error("must occur error")
+		}
+	})
+
+	t.Run("when docker run error", func(t *testing.T) {
+		// given
+		mockReporter := mock_reporter.NewMockReporter(ctrl)
+		mockReporter.EXPECT().Log(gomock.Any(), gomock.Any()).AnyTimes()
+		mockReporter.EXPECT().Report(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+			Times(2).
+			Return(nil)
+
+		// and
+		mockGit := mock_git.NewMockService(ctrl)
+		mockGit.EXPECT().Clone(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+			Times(1).
+			DoAndReturn(func(_ interface{}, dir string, _, _, _ interface{}) error {
+				return os.MkdirAll(dir, 0700)
+			})
+
+		// and
+		mockDocker := mock_docker.NewMockClient(ctrl)
+		mockDocker.EXPECT().
+			CreateNetwork(gomock.Any(), gomock.Any(), gomock.Any()).
+			AnyTimes().
+			Return("network_id", nil)
+		mockDocker.EXPECT().
+			RemoveNetwork(gomock.Any(), gomock.Eq("network_id")).
+			AnyTimes().
+			Return(nil)
+		mockDocker.EXPECT().
+			OOMKilled(gomock.Any(), gomock.Any()).
+			AnyTimes().
+			Return(false, nil)
+		mockDocker.EXPECT().
+			Build(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+			Times(1).
+			Return(&MockBuildLog{}, nil)
+		mockDocker.EXPECT().
+			Run(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+			Times(1).
+			Return("", nil, errors.New("test"))
+		mockDocker.EXPECT().
+			ExitCode(gomock.Any(), gomock.Any()).
+			AnyTimes().
+			Return(int64(0), nil)
+		mockDocker.EXPECT().
+			Rm(gomock.Any(), gomock.Any()).
+			AnyTimes().
+			Return(nil)
+
+		// and
+		mockLogStore := mock_logstore.NewMockService(ctrl)
+		mockLogStore.EXPECT().
+			Append(gomock.Any(), gomock.Any()).
+			AnyTimes().
+			Return(nil)
+		mockLogStore.EXPECT().
+			Start(gomock.Any()).
+			AnyTimes().
+			Return(nil)
+		mockLogStore.EXPECT().
+			Running(gomock.Any()).
+			AnyTimes().
+			Return(nil)
+		mockLogStore.EXPECT().
+			Finish(gomock.Any(), gomock.Any(), gomock.Any()).
+			AnyTimes().
+			Return(nil)
+
+		r := &runner.DockerRunner{
+			Name:        "test-runner",
+			BaseWorkDir: path.Join(os.TempDir(), "test-runner"),
+			Git:         mockGit,
+			Reporter:    mockReporter,
+			Docker:      mockDocker,
+			LogStore:    mockLogStore,
+			Queue:       mockQueue,
+			Secrets:     mockSecret,
+		}
+
+		// and
+		repo := &MockRepo{"duck8823/duci", "git@github.com:duck8823/duci.git", "https://github.com/duck8823/duci", "https://github.com/duck8823/duci.git"}
+
+		// when
+		err := r.Run(context.New("test/task", uuid.New(), &url.URL{}), repo, "master", plumbing.ZeroHash, "Hello World.")
+
+		// then
+		if err == nil {
+			t.Error("must occur error")
+		}
+	})
+
+	t.Run("when fail to remove container", func(t *testing.T) {
+		// given
+		expected := errors.New("test")
+
+		// and
+		mockReporter := mock_reporter.NewMockReporter(ctrl)
+		mockReporter.EXPECT().Log(gomock.Any(), gomock.Any()).AnyTimes()
+		mockReporter.EXPECT().Report(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+			Times(2).
+			Return(nil)
+
+		// and
+		mockGit := mock_git.NewMockService(ctrl)
+		mockGit.EXPECT().Clone(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+			Times(1).
+			DoAndReturn(func(_ interface{}, dir string, _, _, _ interface{}) error {
+				return os.MkdirAll(dir, 0700)
+			})
+
+		// and
+		mockDocker := mock_docker.NewMockClient(ctrl)
+		mockDocker.EXPECT().
+			CreateNetwork(gomock.Any(), gomock.Any(), gomock.Any()).
+			AnyTimes().
+			Return("network_id", nil)
+		mockDocker.EXPECT().
+			RemoveNetwork(gomock.Any(), gomock.Eq("network_id")).
+			AnyTimes().
+			Return(nil)
+		mockDocker.EXPECT().
+			OOMKilled(gomock.Any(), gomock.Any()).
+			AnyTimes().
+			Return(false, nil)
+		mockDocker.EXPECT().
+			Build(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+			Return(&MockBuildLog{}, nil)
+		mockDocker.EXPECT().
+			Run(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+			Times(1).
+			Return("container_id", &MockJobLog{}, nil)
+		mockDocker.EXPECT().
+			ExitCode(gomock.Any(), gomock.Any()).
+			AnyTimes().
+			Return(int64(0), nil)
+		mockDocker.EXPECT().
+			Rm(gomock.Any(), gomock.Any()).
+			AnyTimes().
+			Return(expected)
+
+		// and
+		mockLogStore := mock_logstore.NewMockService(ctrl)
+		mockLogStore.EXPECT().
+			Append(gomock.Any(), gomock.Any()).
+			AnyTimes().
+			Return(nil)
+		mockLogStore.EXPECT().
+			Start(gomock.Any()).
+			AnyTimes().
+			Return(nil)
+		mockLogStore.EXPECT().
+			Running(gomock.Any()).
+			AnyTimes().
+			Return(nil)
+		mockLogStore.EXPECT().
+			Finish(gomock.Any(), gomock.Any(), gomock.Any()).
+			AnyTimes().
+			Return(nil)
+
+		r := &runner.DockerRunner{
+			Name:        "test-runner",
+			BaseWorkDir: path.Join(os.TempDir(), "test-runner"),
+			Git:         mockGit,
+			Reporter:    mockReporter,
+			Docker:      mockDocker,
+			LogStore:    mockLogStore,
+			Queue:       mockQueue,
+			Secrets:     mockSecret,
+		}
+
+		// and
+		repo := &MockRepo{"duck8823/duci", "git@github.com:duck8823/duci.git", "https://github.com/duck8823/duci", "https://github.com/duck8823/duci.git"}
+
+		// when
+		err := r.Run(context.New("test/task", uuid.New(), &url.URL{}), repo, "master", plumbing.ZeroHash, "Hello World.")
+
+		// then
+		if err.Error() != expected.Error() {
+			t.Errorf("err must be %+v, but got %+v", expected, err)
+		}
+	})
+
+	t.Run("when docker run failure ( with exit code 1 )", func(t *testing.T) {
+		// given
+		mockReporter := mock_reporter.NewMockReporter(ctrl)
+		mockReporter.EXPECT().Log(gomock.Any(), gomock.Any()).AnyTimes()
+		mockReporter.EXPECT().Report(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+			Times(2).
+			Return(nil)
+
+		// and
+		mockGit := mock_git.NewMockService(ctrl)
+		mockGit.EXPECT().Clone(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+			Times(1).
+			DoAndReturn(func(_ interface{}, dir string, _, _, _ interface{}) error {
+				return os.MkdirAll(dir, 0700)
+			})
+
+		// and
+		mockDocker := mock_docker.NewMockClient(ctrl)
+		mockDocker.EXPECT().
+			CreateNetwork(gomock.Any(), gomock.Any(), gomock.Any()).
+			AnyTimes().
+			Return("network_id", nil)
+		mockDocker.EXPECT().
+			RemoveNetwork(gomock.Any(), gomock.Eq("network_id")).
+			AnyTimes().
+			Return(nil)
+		mockDocker.EXPECT().
+			OOMKilled(gomock.Any(), gomock.Any()).
+			AnyTimes().
+			Return(false, nil)
+		mockDocker.EXPECT().
+			Build(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+			Times(1).
+			Return(&MockBuildLog{}, nil)
+		mockDocker.EXPECT().
+			Run(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+			Times(1).
 			Return("", &MockJobLog{}, nil)
 		mockDocker.EXPECT().
-			ExitCode(gomock.Any(), gomock.Any()).
-			AnyTimes().
-			Return(int64(0), nil)
+			ExitCode(gomock.Any(), gomock.Any()).
+			AnyTimes().
+			Return(int64(1), nil)
+		mockDocker.EXPECT().
+			Rm(gomock.Any(), gomock.Any()).
+			AnyTimes().
+			Return(nil)
+
+		// and
+		mockLogStore := mock_logstore.NewMockService(ctrl)
+		mockLogStore.EXPECT().
+			Append(gomock.Any(), gomock.Any()).
+			AnyTimes().
+			Return(nil)
+		mockLogStore.EXPECT().
+			Start(gomock.Any()).
+			AnyTimes().
+			Return(nil)
+		mockLogStore.EXPECT().
+			Running(gomock.Any()).
+			AnyTimes().
+			Return(nil)
+		mockLogStore.EXPECT().
+			Finish(gomock.Any(), gomock.Eq(model.FAILURE), gomock.Eq(int64(1))).
+			Times(1).
+			Return(nil)
+
+		r := &runner.DockerRunner{
+			Name:        "test-runner",
+			BaseWorkDir: path.Join(os.TempDir(), "test-runner"),
+			Git:         mockGit,
+			Reporter:    mockReporter,
+			Docker:      mockDocker,
+			LogStore:    mockLogStore,
+			Queue:       mockQueue,
+			Secrets:     mockSecret,
+		}
+
+		// and
+		repo := &MockRepo{"duck8823/duci", "git@github.com:duck8823/duci.git", "https://github.com/duck8823/duci", "https://github.com/duck8823/duci.git"}
+
+		// when
+		err := r.Run(context.New("test/task", uuid.New(), &url.URL{}), repo, "master", plumbing.ZeroHash, "Hello World.")
+
+		// then
+		if err != runner.Failure {
+			t.Errorf("error must be %s, but got %s", runner.Failure, err)
+		}
+	})
+
+	t.Run("when killed by out of memory", func(t *testing.T) {
+		// given
+		var reports []string
+		mockReporter := mock_reporter.NewMockReporter(ctrl)
+		mockReporter.EXPECT().Log(gomock.Any(), gomock.Any()).AnyTimes()
+		mockReporter.EXPECT().Report(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+			Times(2).
+			Do(func(_ context.Context, _ interface{}, _ interface{}, state model.State, description string) {
+				reports = append(reports, fmt.Sprintf("%s:%s", state, description))
+			})
+
+		// and
+		mockGit := mock_git.NewMockService(ctrl)
+		mockGit.EXPECT().Clone(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+			Times(1).
+			DoAndReturn(func(_ interface{}, dir string, _, _, _ interface{}) error {
+				return os.MkdirAll(dir, 0700)
+			})
+
+		// and
+		mockDocker := mock_docker.NewMockClient(ctrl)
+		mockDocker.EXPECT().
+			CreateNetwork(gomock.Any(), gomock.Any(), gomock.Any()).
+			AnyTimes().
+			Return("network_id", nil)
+		mockDocker.EXPECT().
+			RemoveNetwork(gomock.Any(), gomock.Eq("network_id")).
+			AnyTimes().
+			Return(nil)
+		mockDocker.EXPECT().
+			Build(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+			Times(1).
+			Return(&MockBuildLog{}, nil)
+		mockDocker.EXPECT().
+			Run(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+			Times(1).
+			Return("container_id", &MockJobLog{}, nil)
+		mockDocker.EXPECT().
+			ExitCode(gomock.Any(), gomock.Eq("container_id")).
+			Times(1).
+			Return(int64(137), nil)
+		mockDocker.EXPECT().
+			OOMKilled(gomock.Any(), gomock.Eq("container_id")).
+			Times(1).
+			Return(true, nil)
 		mockDocker.EXPECT().
 			Rm(gomock.Any(), gomock.Any()).
 			AnyTimes().
-			Return(expected)
+			Return(nil)
 
 		// and
+		var logs []string
 		mockLogStore := mock_logstore.NewMockService(ctrl)
 		mockLogStore.EXPECT().
 			Append(gomock.Any(), gomock.Any()).
 			AnyTimes().
+			Do(func(_ uuid.UUID, message model.Message) {
+				logs = append(logs, message.Text)
+			}).
 			Return(nil)
 		mockLogStore.EXPECT().
 			Start(gomock.Any()).
 			AnyTimes().
 			Return(nil)
 		mockLogStore.EXPECT().
-			Finish(gomock.Any()).
+			Running(gomock.Any()).
 			AnyTimes().
 			Return(nil)
+		mockLogStore.EXPECT().
+			Finish(gomock.Any(), gomock.Eq(model.ERROR), gomock.Eq(int64(137))).
+			Times(1).
+			Return(nil)
 
 		r := &runner.DockerRunner{
 			Name:        "test-runner",
 			BaseWorkDir: path.Join(os.TempDir(), "test-runner"),
 			Git:         mockGit,
-			GitHub:      mockGitHub,
+			Reporter:    mockReporter,
 			Docker:      mockDocker,
 			LogStore:    mockLogStore,
+			Queue:       mockQueue,
+			Secrets:     mockSecret,
 		}
 
 		// and
-		repo := &MockRepo{"duck8823/duci", "git@github.com:duck8823/duci.git"}
+		repo := &MockRepo{"duck8823/duci", "git@github.com:duck8823/duci.git", "https://github.com/duck8823/duci", "https://github.com/duck8823/duci.git"}
 
 		// when
 		err := r.Run(context.New("test/task", uuid.New(), &url.URL{}), repo, "master", plumbing.ZeroHash, "Hello World.")
 
 		// then
-		if err.Error() != expected.Error() {
-			t.Errorf("err must be %+v, but got %+v", expected, err)
+		if err != runner.OutOfMemory {
+			t.Errorf("error must be %s, but got %s", runner.OutOfMemory, err)
+		}
+
+		// and
+		if last := reports[len(reports)-1]; last != "error:out of memory" {
+			t.Errorf("status must tell out of memory, but got %s", last)
+		}
+
+		// and
+		if last := logs[len(logs)-1]; !strings.Contains(last, "killed by out of memory") {
+			t.Errorf("log must tell out of memory, but got %s", last)
 		}
 	})
 
-	t.Run("when docker run failure ( with exit code 1 )", func(t *testing.T) {
+	t.Run("when runner timeout", func(t *testing.T) {
 		// given
-		mockGitHub := mock_github.NewMockService(ctrl)
-		mockGitHub.EXPECT().CreateCommitStatus(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+		mockReporter := mock_reporter.NewMockReporter(ctrl)
+		mockReporter.EXPECT().Log(gomock.Any(), gomock.Any()).AnyTimes()
+		mockReporter.EXPECT().Report(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
 			Times(2).
 			Return(nil)
 
@@ -665,22 +2039,45 @@ func TestRunnerImpl_Run(t *testing.T) {
 		mockGit := mock_git.NewMockService(ctrl)
 		mockGit.EXPECT().Clone(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
 			Times(1).
-			Return(nil)
+			DoAndReturn(func(_ interface{}, dir string, _, _, _ interface{}) error {
+				return os.MkdirAll(dir, 0700)
+			})
 
 		// and
+		application.Config.Job.Timeout = 1
+
 		mockDocker := mock_docker.NewMockClient(ctrl)
 		mockDocker.EXPECT().
-			Build(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+			CreateNetwork(gomock.Any(), gomock.Any(), gomock.Any()).
+			AnyTimes().
+			Return("network_id", nil)
+		mockDocker.EXPECT().
+			RemoveNetwork(gomock.Any(), gomock.Eq("network_id")).
+			AnyTimes().
+			Return(nil)
+		mockDocker.EXPECT().
+			OOMKilled(gomock.Any(), gomock.Any()).
+			AnyTimes().
+			Return(false, nil)
+		mockDocker.EXPECT().
+			Build(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
 			Times(1).
 			Return(&MockBuildLog{}, nil)
 		mockDocker.EXPECT().
 			Run(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
 			Times(1).
-			Return("", &MockJobLog{}, nil)
+			DoAndReturn(func(ctx context.Context, opts docker.RuntimeOptions, tag string, cmd ...string) (string, docker.Log, error) {
+				time.Sleep(3 * time.Second)
+				return "container_id", &MockJobLog{}, nil
+			})
 		mockDocker.EXPECT().
 			ExitCode(gomock.Any(), gomock.Any()).
 			AnyTimes().
-			Return(int64(1), nil)
+			Return(int64(0), nil)
+		mockDocker.EXPECT().
+			Kill(gomock.Any(), gomock.Any()).
+			AnyTimes().
+			Return(nil)
 		mockDocker.EXPECT().
 			Rm(gomock.Any(), gomock.Any()).
 			AnyTimes().
@@ -697,7 +2094,11 @@ func TestRunnerImpl_Run(t *testing.T) {
 			AnyTimes().
 			Return(nil)
 		mockLogStore.EXPECT().
-			Finish(gomock.Any()).
+			Running(gomock.Any()).
+			AnyTimes().
+			Return(nil)
+		mockLogStore.EXPECT().
+			Finish(gomock.Any(), gomock.Any(), gomock.Any()).
 			AnyTimes().
 			Return(nil)
 
@@ -705,57 +2106,83 @@ func TestRunnerImpl_Run(t *testing.T) {
 			Name:        "test-runner",
 			BaseWorkDir: path.Join(os.TempDir(), "test-runner"),
 			Git:         mockGit,
-			GitHub:      mockGitHub,
+			Reporter:    mockReporter,
 			Docker:      mockDocker,
 			LogStore:    mockLogStore,
+			Queue:       mockQueue,
+			Secrets:     mockSecret,
 		}
 
 		// and
-		repo := &MockRepo{"duck8823/duci", "git@github.com:duck8823/duci.git"}
+		repo := &MockRepo{"duck8823/duci", "git@github.com:duck8823/duci.git", "https://github.com/duck8823/duci", "https://github.com/duck8823/duci.git"}
 
 		// when
 		err := r.Run(context.New("test/task", uuid.New(), &url.URL{}), repo, "master", plumbing.ZeroHash, "Hello World.")
 
 		// then
-		if err != runner.Failure {
-			t.Errorf("error must be %s, but got %s", runner.Failure, err)
+		if err.Error() != "context deadline exceeded" {
+			t.Errorf("error must be runner.Failure, but got %+v", err)
 		}
 	})
 
-	t.Run("when runner timeout", func(t *testing.T) {
+	t.Run("when cancelled", func(t *testing.T) {
 		// given
-		mockGitHub := mock_github.NewMockService(ctrl)
-		mockGitHub.EXPECT().CreateCommitStatus(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
-			Times(2).
+		mockReporter := mock_reporter.NewMockReporter(ctrl)
+		mockReporter.EXPECT().Log(gomock.Any(), gomock.Any()).AnyTimes()
+		mockReporter.EXPECT().Report(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Eq(model.RUNNING), gomock.Any()).
+			AnyTimes().
+			Return(nil)
+		mockReporter.EXPECT().Report(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Eq(model.CANCELLED), gomock.Eq("cancelled")).
+			Times(1).
 			Return(nil)
 
 		// and
 		mockGit := mock_git.NewMockService(ctrl)
 		mockGit.EXPECT().Clone(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
 			Times(1).
-			Return(nil)
+			DoAndReturn(func(_ interface{}, dir string, _, _, _ interface{}) error {
+				return os.MkdirAll(dir, 0700)
+			})
 
 		// and
-		application.Config.Job.Timeout = 1
+		application.Config.Job.Timeout = 10
 
+		started := make(chan struct{})
+		killed := make(chan struct{})
 		mockDocker := mock_docker.NewMockClient(ctrl)
 		mockDocker.EXPECT().
-			Build(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+			CreateNetwork(gomock.Any(), gomock.Any(), gomock.Any()).
+			AnyTimes().
+			Return("network_id", nil)
+		mockDocker.EXPECT().
+			RemoveNetwork(gomock.Any(), gomock.Eq("network_id")).
+			AnyTimes().
+			Return(nil)
+		mockDocker.EXPECT().
+			OOMKilled(gomock.Any(), gomock.Any()).
+			AnyTimes().
+			Return(false, nil)
+		mockDocker.EXPECT().
+			Build(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
 			Times(1).
 			Return(&MockBuildLog{}, nil)
 		mockDocker.EXPECT().
 			Run(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
 			Times(1).
 			DoAndReturn(func(ctx context.Context, opts docker.RuntimeOptions, tag string, cmd ...string) (string, docker.Log, error) {
-				time.Sleep(3 * time.Second)
-				return "container_id", &MockJobLog{}, nil
+				close(started)
+				<-ctx.Done()
+				return "container_id", nil, ctx.Err()
 			})
 		mockDocker.EXPECT().
-			ExitCode(gomock.Any(), gomock.Any()).
-			AnyTimes().
-			Return(int64(0), nil)
+			Kill(gomock.Any(), gomock.Eq("container_id")).
+			Times(1).
+			DoAndReturn(func(_ interface{}, _ string) error {
+				close(killed)
+				return nil
+			})
 		mockDocker.EXPECT().
-			Rm(gomock.Any(), gomock.Any()).
+			Rm(gomock.Any(), gomock.Eq("container_id")).
 			AnyTimes().
 			Return(nil)
 
@@ -770,28 +2197,509 @@ func TestRunnerImpl_Run(t *testing.T) {
 			AnyTimes().
 			Return(nil)
 		mockLogStore.EXPECT().
-			Finish(gomock.Any()).
+			Running(gomock.Any()).
 			AnyTimes().
 			Return(nil)
+		mockLogStore.EXPECT().
+			Finish(gomock.Any(), gomock.Eq(model.CANCELLED), gomock.Eq(int64(-1))).
+			Times(1).
+			Return(nil)
 
 		r := &runner.DockerRunner{
 			Name:        "test-runner",
 			BaseWorkDir: path.Join(os.TempDir(), "test-runner"),
 			Git:         mockGit,
-			GitHub:      mockGitHub,
+			Reporter:    mockReporter,
 			Docker:      mockDocker,
 			LogStore:    mockLogStore,
+			Queue:       mockQueue,
+			Secrets:     mockSecret,
 		}
 
 		// and
-		repo := &MockRepo{"duck8823/duci", "git@github.com:duck8823/duci.git"}
+		repo := &MockRepo{"duck8823/duci", "git@github.com:duck8823/duci.git", "https://github.com/duck8823/duci", "https://github.com/duck8823/duci.git"}
+		id := uuid.New()
+
+		go func() {
+			<-started
+			if err := r.Cancel(id); err != nil {
+				t.Errorf("error must not occur, but got %+v", err)
+			}
+		}()
 
 		// when
-		err := r.Run(context.New("test/task", uuid.New(), &url.URL{}), repo, "master", plumbing.ZeroHash, "Hello World.")
+		err := r.Run(context.New("test/task", id, &url.URL{}), repo, "master", plumbing.ZeroHash, "Hello World.")
 
 		// then
-		if err.Error() != "context deadline exceeded" {
-			t.Errorf("error must be runner.Failure, but got %+v", err)
+		if err != context.Canceled {
+			t.Errorf("error must be context.Canceled, but got %+v", err)
+		}
+
+		select {
+		case <-killed:
+		case <-time.After(time.Second):
+			t.Error("container must be killed")
+		}
+
+		if err := r.Cancel(id); err != runner.JobNotFound {
+			t.Errorf("error must be runner.JobNotFound, but got %+v", err)
+		}
+	})
+
+	t.Run("when cancelled with secrets", func(t *testing.T) {
+		// given
+		mockSecret := mock_secret.NewMockService(ctrl)
+		mockSecret.EXPECT().
+			All(gomock.Eq("duck8823/duci")).
+			Times(1).
+			Return(map[string]string{"NPM_TOKEN": "s3cr3t"}, nil)
+
+		// and
+		mockReporter := mock_reporter.NewMockReporter(ctrl)
+		mockReporter.EXPECT().Log(gomock.Any(), gomock.Any()).AnyTimes()
+		mockReporter.EXPECT().Report(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+			AnyTimes().
+			Return(nil)
+
+		// and
+		mockGit := mock_git.NewMockService(ctrl)
+		mockGit.EXPECT().Clone(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+			Times(1).
+			DoAndReturn(func(_ interface{}, dir string, _, _, _ interface{}) error {
+				if err := os.MkdirAll(path.Join(dir, ".duci"), 0700); err != nil {
+					return err
+				}
+				return ioutil.WriteFile(path.Join(dir, ".duci/config.yml"), []byte("---\nservices:\n  - name: postgres\n    image: postgres:10"), 0600)
+			})
+
+		// and
+		application.Config.Job.Timeout = 10
+
+		started := make(chan struct{})
+		returned := make(chan struct{})
+		stopped := make(chan struct{})
+		mockDocker := mock_docker.NewMockClient(ctrl)
+		mockDocker.EXPECT().
+			CreateNetwork(gomock.Any(), gomock.Any(), gomock.Any()).
+			AnyTimes().
+			Return("network_id", nil)
+		mockDocker.EXPECT().
+			RunService(gomock.Any(), gomock.Any(), gomock.Eq("network_id")).
+			Times(1).
+			DoAndReturn(func(ctx context.Context, _ docker.ServiceOptions, _ string) (string, error) {
+				close(started)
+				<-ctx.Done()
+				<-returned
+				return "", errors.New("failed with s3cr3t")
+			})
+		mockDocker.EXPECT().
+			RemoveNetwork(gomock.Any(), gomock.Eq("network_id")).
+			Times(1).
+			DoAndReturn(func(_ interface{}, _ string) error {
+				close(stopped)
+				return nil
+			})
+
+		// and
+		var mu sync.Mutex
+		var logs []string
+		mockLogStore := mock_logstore.NewMockService(ctrl)
+		mockLogStore.EXPECT().
+			Append(gomock.Any(), gomock.Any()).
+			AnyTimes().
+			DoAndReturn(func(_ uuid.UUID, message model.Message) error {
+				mu.Lock()
+				defer mu.Unlock()
+				logs = append(logs, message.Text)
+				return nil
+			})
+		mockLogStore.EXPECT().
+			Start(gomock.Any()).
+			AnyTimes().
+			Return(nil)
+		mockLogStore.EXPECT().
+			Running(gomock.Any()).
+			AnyTimes().
+			Return(nil)
+		mockLogStore.EXPECT().
+			Finish(gomock.Any(), gomock.Any(), gomock.Any()).
+			AnyTimes().
+			Return(nil)
+
+		r := &runner.DockerRunner{
+			Name:        "test-runner",
+			BaseWorkDir: path.Join(os.TempDir(), "test-runner"),
+			Git:         mockGit,
+			Reporter:    mockReporter,
+			Docker:      mockDocker,
+			LogStore:    mockLogStore,
+			Queue:       mockQueue,
+			Secrets:     mockSecret,
+		}
+
+		// and
+		repo := &MockRepo{"duck8823/duci", "git@github.com:duck8823/duci.git", "https://github.com/duck8823/duci", "https://github.com/duck8823/duci.git"}
+		id := uuid.New()
+
+		go func() {
+			<-started
+			if err := r.Cancel(id); err != nil {
+				t.Errorf("error must not occur, but got %+v", err)
+			}
+		}()
+
+		// when
+		err := r.Run(context.New("test/task", id, &url.URL{}), repo, "master", plumbing.ZeroHash, "Hello World.")
+		close(returned)
+
+		// then
+		if err != context.Canceled {
+			t.Errorf("error must be context.Canceled, but got %+v", err)
+		}
+
+		select {
+		case <-stopped:
+		case <-time.After(time.Second):
+			t.Fatal("job must stop")
+		}
+
+		mu.Lock()
+		defer mu.Unlock()
+		for _, log := range logs {
+			if strings.Contains(log, "s3cr3t") {
+				t.Errorf("secret must be masked after cancelled, but got %s", log)
+			}
+		}
+	})
+
+	t.Run("cleanup", func(t *testing.T) {
+		for _, tt := range []struct {
+			name          string
+			keepOnFailure bool
+			removeImage   bool
+			code          int64
+			removed       bool
+		}{
+			{name: "when success", code: 0, removed: true},
+			{name: "when failure", code: 1, removed: true},
+			{name: "when success with keep on failure", keepOnFailure: true, code: 0, removed: true},
+			{name: "when failure with keep on failure", keepOnFailure: true, code: 1, removed: false},
+			{name: "when success with remove image", removeImage: true, code: 0, removed: true},
+		} {
+			t.Run(tt.name, func(t *testing.T) {
+				// setup
+				ctrl := gomock.NewController(t)
+				defer ctrl.Finish()
+
+				// given
+				application.Config.Job.Timeout = 10
+				application.Config.Job.KeepOnFailure = tt.keepOnFailure
+				application.Config.Job.RemoveImage = tt.removeImage
+				defer func() {
+					application.Config.Job.KeepOnFailure = false
+					application.Config.Job.RemoveImage = false
+				}()
+
+				// and
+				mockReporter := mock_reporter.NewMockReporter(ctrl)
+				mockReporter.EXPECT().Log(gomock.Any(), gomock.Any()).AnyTimes()
+				mockReporter.EXPECT().Report(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+					AnyTimes().
+					Return(nil)
+
+				// and
+				id := uuid.New()
+				tagName := fmt.Sprintf("duck8823/duci:%s", id)
+
+				// and
+				var workDir string
+				mockGit := mock_git.NewMockService(ctrl)
+				mockGit.EXPECT().Clone(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(_ interface{}, dir string, _, _, _ interface{}) error {
+						workDir = dir
+						return os.MkdirAll(dir, 0700)
+					})
+
+				// and
+				removeTimes := 0
+				if tt.removed {
+					removeTimes = 1
+				}
+				rmiTimes := 0
+				if tt.removeImage {
+					rmiTimes = 1
+				}
+
+				mockDocker := mock_docker.NewMockClient(ctrl)
+				mockDocker.EXPECT().
+					CreateNetwork(gomock.Any(), gomock.Any(), gomock.Any()).
+					AnyTimes().
+					Return("network_id", nil)
+				mockDocker.EXPECT().
+					RemoveNetwork(gomock.Any(), gomock.Eq("network_id")).
+					AnyTimes().
+					Return(nil)
+				mockDocker.EXPECT().
+					OOMKilled(gomock.Any(), gomock.Any()).
+					AnyTimes().
+					Return(false, nil)
+				mockDocker.EXPECT().
+					Build(gomock.Any(), gomock.Any(), gomock.Eq(tagName), gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(&MockBuildLog{}, nil)
+				mockDocker.EXPECT().
+					Run(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+					Times(1).
+					Return("container_id", &MockJobLog{}, nil)
+				mockDocker.EXPECT().
+					ExitCode(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(tt.code, nil)
+				mockDocker.EXPECT().
+					Rm(gomock.Any(), gomock.Eq("container_id")).
+					Times(removeTimes).
+					Return(nil)
+				mockDocker.EXPECT().
+					Rmi(gomock.Any(), gomock.Eq(tagName)).
+					Times(rmiTimes).
+					Return(nil)
+
+				// and
+				mockLogStore := mock_logstore.NewMockService(ctrl)
+				mockLogStore.EXPECT().
+					Append(gomock.Any(), gomock.Any()).
+					AnyTimes().
+					Return(nil)
+				mockLogStore.EXPECT().
+					Start(gomock.Any()).
+					AnyTimes().
+					Return(nil)
+				mockLogStore.EXPECT().
+					Running(gomock.Any()).
+					AnyTimes().
+					Return(nil)
+				mockLogStore.EXPECT().
+					Finish(gomock.Any(), gomock.Any(), gomock.Any()).
+					AnyTimes().
+					Return(nil)
+
+				r := &runner.DockerRunner{
+					Name:        "test-runner",
+					BaseWorkDir: path.Join(os.TempDir(), "test-runner"),
+					Git:         mockGit,
+					Reporter:    mockReporter,
+					Docker:      mockDocker,
+					LogStore:    mockLogStore,
+					Queue:       mockQueue,
+					Secrets:     mockSecret,
+				}
+
+				// and
+				repo := &MockRepo{"duck8823/duci", "git@github.com:duck8823/duci.git", "https://github.com/duck8823/duci", "https://github.com/duck8823/duci.git"}
+
+				// when
+				r.Run(context.New("test/task", id, &url.URL{}), repo, "master", plumbing.ZeroHash, "Hello World.")
+
+				// then
+				if _, err := os.Stat(workDir); os.IsNotExist(err) != tt.removed {
+					t.Errorf("work directory removed must be %+v, but not", tt.removed)
+				}
+
+				// cleanup
+				os.RemoveAll(workDir)
+			})
+		}
+	})
+}
+
+func TestDockerRunner_Run_Concurrency(t *testing.T) {
+	// setup
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	timeout := application.Config.Job.Timeout
+	defer func() {
+		application.Config.Job.Timeout = timeout
+	}()
+	application.Config.Job.Timeout = 1
+
+	mockQueue := mock_queue.NewMockService(ctrl)
+	mockQueue.EXPECT().Push(gomock.Any()).AnyTimes().Return(nil)
+	mockQueue.EXPECT().Running(gomock.Any()).AnyTimes().Return(nil)
+	mockQueue.EXPECT().Done(gomock.Any()).AnyTimes().Return(nil)
+
+	mockSecret := mock_secret.NewMockService(ctrl)
+	mockSecret.EXPECT().All(gomock.Any()).AnyTimes().Return(map[string]string{}, nil)
+
+	repo := &MockRepo{"duck8823/duci", "git@github.com:duck8823/duci.git", "https://github.com/duck8823/duci", "https://github.com/duck8823/duci.git"}
+
+	// newRunner returns runner of jobs with the config, which calls build while building the image.
+	newRunner := func(config string, build func(), reporter *mock_reporter.MockReporter) *runner.DockerRunner {
+		mockGit := mock_git.NewMockService(ctrl)
+		mockGit.EXPECT().Clone(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+			Times(1).
+			DoAndReturn(func(_ interface{}, dir string, _, _, _ interface{}) error {
+				if err := os.MkdirAll(path.Join(dir, ".duci"), 0700); err != nil {
+					return err
+				}
+				return ioutil.WriteFile(path.Join(dir, ".duci/config.yml"), []byte(config), 0600)
+			})
+
+		mockDocker := mock_docker.NewMockClient(ctrl)
+		mockDocker.EXPECT().CreateNetwork(gomock.Any(), gomock.Any(), gomock.Any()).AnyTimes().Return("network_id", nil)
+		mockDocker.EXPECT().RemoveNetwork(gomock.Any(), gomock.Any()).AnyTimes().Return(nil)
+		mockDocker.EXPECT().OOMKilled(gomock.Any(), gomock.Any()).AnyTimes().Return(false, nil)
+		mockDocker.EXPECT().
+			Build(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+			Times(1).
+			DoAndReturn(func(_, _, _, _, _ interface{}) (docker.Log, error) {
+				build()
+				return &MockBuildLog{}, nil
+			})
+		mockDocker.EXPECT().Run(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Times(1).Return("container_id", &MockJobLog{}, nil)
+		mockDocker.EXPECT().ExitCode(gomock.Any(), gomock.Any()).AnyTimes().Return(int64(0), nil)
+		mockDocker.EXPECT().Rm(gomock.Any(), gomock.Any()).AnyTimes().Return(nil)
+
+		mockLogStore := mock_logstore.NewMockService(ctrl)
+		mockLogStore.EXPECT().Append(gomock.Any(), gomock.Any()).AnyTimes().Return(nil)
+		mockLogStore.EXPECT().Start(gomock.Any()).AnyTimes().Return(nil)
+		mockLogStore.EXPECT().Running(gomock.Any()).AnyTimes().Return(nil)
+		mockLogStore.EXPECT().Finish(gomock.Any(), gomock.Any(), gomock.Any()).AnyTimes().Return(nil)
+
+		return &runner.DockerRunner{
+			Name:        "test-runner",
+			BaseWorkDir: path.Join(os.TempDir(), "test-runner"),
+			Git:         mockGit,
+			Reporter:    reporter,
+			Docker:      mockDocker,
+			LogStore:    mockLogStore,
+			Queue:       mockQueue,
+			Secrets:     mockSecret,
+		}
+	}
+
+	t.Run("with matrix job and job waiting for slot", func(t *testing.T) {
+		// given
+		var mu sync.Mutex
+		reports := map[string]model.State{}
+		matrixReporter := mock_reporter.NewMockReporter(ctrl)
+		matrixReporter.EXPECT().Log(gomock.Any(), gomock.Any()).AnyTimes()
+		matrixReporter.EXPECT().Report(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+			AnyTimes().
+			Do(func(ctx context.Context, _ interface{}, _ interface{}, state model.State, _ string) {
+				mu.Lock()
+				defer mu.Unlock()
+				reports[ctx.TaskName()] = state
+			})
+
+		started := make(chan struct{})
+		proceed := make(chan struct{})
+		matrixRunner := newRunner("---\nmatrix:\n  args:\n    - GO_VERSION: \"1.11\"\n", func() {
+			close(started)
+			<-proceed
+		}, matrixReporter)
+
+		// and
+		matrixDone := make(chan struct{})
+		otherReporter := mock_reporter.NewMockReporter(ctrl)
+		otherReporter.EXPECT().Log(gomock.Any(), gomock.Any()).AnyTimes()
+		otherReporter.EXPECT().Report(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).AnyTimes().Return(nil)
+		otherRunner := newRunner("---\n", func() {
+			select {
+			case <-matrixDone:
+			case <-time.After(5 * time.Second):
+			}
+		}, otherReporter)
+
+		// when
+		var err error
+		go func() {
+			err = matrixRunner.Run(context.New("test/matrix", uuid.New(), &url.URL{}), repo, "master", plumbing.ZeroHash)
+			close(matrixDone)
+		}()
+
+		// and other job waits for the slot while the cell runs
+		<-started
+		otherDone := make(chan struct{})
+		go func() {
+			otherRunner.Run(context.New("test/other", uuid.New(), &url.URL{}), repo, "feature", plumbing.ZeroHash)
+			close(otherDone)
+		}()
+		time.Sleep(100 * time.Millisecond)
+		close(proceed)
+
+		// then
+		select {
+		case <-matrixDone:
+		case <-time.After(5 * time.Second):
+			t.Fatal("matrix job must finish")
+		}
+		if err != nil {
+			t.Errorf("error must not occur, but got %+v", err)
+		}
+		mu.Lock()
+		if reports["test/matrix"] != model.SUCCESS {
+			t.Errorf("matrix job must be reported %s, but got %s", model.SUCCESS, reports["test/matrix"])
+		}
+		mu.Unlock()
+
+		<-otherDone
+	})
+}
+
+func TestDockerRunner_Resume(t *testing.T) {
+	// setup
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	t.Run("with running job", func(t *testing.T) {
+		// given
+		job := &model.QueuedJob{
+			ID:         uuid.New(),
+			TaskName:   "test/task",
+			TargetURL:  "http://example.com",
+			Repository: model.Repository{FullName: "duck8823/duci", SSHURL: "git@github.com:duck8823/duci.git"},
+			Running:    true,
+		}
+
+		// and
+		mockQueue := mock_queue.NewMockService(ctrl)
+		mockQueue.EXPECT().All().Times(1).Return([]*model.QueuedJob{job}, nil)
+		mockQueue.EXPECT().Done(gomock.Eq(job.ID)).Times(1).Return(nil)
+
+		mockReporter := mock_reporter.NewMockReporter(ctrl)
+		mockReporter.EXPECT().Log(gomock.Any(), gomock.Any()).AnyTimes()
+		mockReporter.EXPECT().
+			Report(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Eq(model.ERROR), gomock.Any()).
+			Times(1).
+			Return(nil)
+
+		mockLogStore := mock_logstore.NewMockService(ctrl)
+		mockLogStore.EXPECT().Finish(gomock.Eq(job.ID), gomock.Eq(model.ERROR), gomock.Eq(int64(-1))).Times(1).Return(nil)
+
+		r := &runner.DockerRunner{
+			Reporter: mockReporter,
+			LogStore: mockLogStore,
+			Queue:    mockQueue,
+		}
+
+		// expect
+		if err := r.Resume(); err != nil {
+			t.Errorf("error must not occur, but got %+v", err)
+		}
+	})
+
+	t.Run("when queue returns error", func(t *testing.T) {
+		// given
+		mockQueue := mock_queue.NewMockService(ctrl)
+		mockQueue.EXPECT().All().Times(1).Return(nil, errors.New("test error"))
+
+		r := &runner.DockerRunner{Queue: mockQueue}
+
+		// expect
+		if err := r.Resume(); err == nil {
+			t.Error("error must occur, but got nil")
 		}
 	})
 }
@@ -799,6 +2707,8 @@ func TestRunnerImpl_Run(t *testing.T) {
 type MockRepo struct {
 	FullName string
 	SSHURL   string
+	HTMLURL  string
+	CloneURL string
 }
 
 func (r *MockRepo) GetFullName() string {
@@ -809,6 +2719,14 @@ func (r *MockRepo) GetSSHURL() string {
 	return r.SSHURL
 }
 
+func (r *MockRepo) GetHTMLURL() string {
+	return r.HTMLURL
+}
+
+func (r *MockRepo) GetCloneURL() string {
+	return r.CloneURL
+}
+
 type MockBuildLog struct {
 }
 
@@ -816,6 +2734,14 @@ func (l *MockBuildLog) ReadLine() (*docker.LogLine, error) {
 	return &docker.LogLine{Timestamp: clock.Now(), Message: []byte("{\"stream\":\"Hello World,\"}")}, io.EOF
 }
 
+type MockTextLog struct {
+	Text string
+}
+
+func (l *MockTextLog) ReadLine() (*docker.LogLine, error) {
+	return &docker.LogLine{Timestamp: clock.Now(), Message: []byte(l.Text)}, io.EOF
+}
+
 type MockJobLog struct {
 }
 
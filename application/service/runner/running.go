@@ -0,0 +1,123 @@
+package runner
+
+import (
+	"github.com/duck8823/duci/application/context"
+	"github.com/google/uuid"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// maskText is replacement of secret values in logs.
+const maskText = "***"
+
+// runningJob is a job in flight ( queued or running ).
+type runningJob struct {
+	fullName string
+	ref      string
+	taskName string
+	cancel   func()
+}
+
+// runningJobs holds cancel functions of jobs in flight, and values of secrets to mask in their logs.
+// Secrets are held apart from jobs, because logs are written until the job stops even after cancelled.
+type runningJobs struct {
+	mu   sync.Mutex
+	jobs map[uuid.UUID]*runningJob
+	// secrets is values to mask in logs, longest first
+	secrets map[uuid.UUID][]string
+}
+
+func (r *runningJobs) add(ctx context.Context, fullName string, ref string, cancel func()) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.jobs == nil {
+		r.jobs = make(map[uuid.UUID]*runningJob)
+	}
+	r.jobs[ctx.UUID()] = &runningJob{
+		fullName: fullName,
+		ref:      ref,
+		taskName: ctx.TaskName(),
+		cancel:   cancel,
+	}
+}
+
+func (r *runningJobs) remove(id uuid.UUID) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	delete(r.jobs, id)
+}
+
+// setSecrets registers values of secrets of the job, to be masked in logs.
+func (r *runningJobs) setSecrets(id uuid.UUID, secrets map[string]string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.secrets == nil {
+		r.secrets = make(map[uuid.UUID][]string)
+	}
+	var values []string
+	for _, value := range secrets {
+		if len(value) > 0 {
+			values = append(values, value)
+		}
+	}
+	// longer values first, not to leave a part of a secret containing another
+	sort.Slice(values, func(i, j int) bool {
+		return len(values[i]) > len(values[j])
+	})
+	r.secrets[id] = values
+}
+
+// removeSecrets stops masking secrets of the job.
+func (r *runningJobs) removeSecrets(id uuid.UUID) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	delete(r.secrets, id)
+}
+
+// mask replaces values of secrets of the job in the text.
+func (r *runningJobs) mask(id uuid.UUID, text string) string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, value := range r.secrets[id] {
+		text = strings.Replace(text, value, maskText, -1)
+	}
+	return text
+}
+
+func (r *runningJobs) cancel(id uuid.UUID) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	job, ok := r.jobs[id]
+	if !ok {
+		return false
+	}
+	job.cancel()
+	return true
+}
+
+// cancelRef cancels jobs of the ref, except the given one, and returns ids of cancelled jobs.
+// When taskName is empty, jobs of all tasks are cancelled.
+func (r *runningJobs) cancelRef(fullName string, ref string, taskName string, except uuid.UUID) []uuid.UUID {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var ids []uuid.UUID
+	for id, job := range r.jobs {
+		if id == except || job.fullName != fullName || job.ref != ref {
+			continue
+		}
+		if len(taskName) > 0 && job.taskName != taskName {
+			continue
+		}
+		job.cancel()
+		ids = append(ids, id)
+	}
+	return ids
+}
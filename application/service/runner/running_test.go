@@ -0,0 +1,80 @@
+package runner
+
+import (
+	"github.com/duck8823/duci/application/context"
+	"github.com/google/uuid"
+	"net/url"
+	"testing"
+)
+
+func TestRunningJobs_CancelRef(t *testing.T) {
+	// given
+	jobs := &runningJobs{}
+
+	cancelled := make(map[uuid.UUID]bool)
+	add := func(taskName string, fullName string, ref string) uuid.UUID {
+		id := uuid.New()
+		jobs.add(context.New(taskName, id, &url.URL{}), fullName, ref, func() {
+			cancelled[id] = true
+		})
+		return id
+	}
+
+	self := add("duci/push", "duck8823/duci", "refs/heads/master")
+	sameRef := add("duci/push", "duck8823/duci", "refs/heads/master")
+	otherTask := add("duci/pr/test", "duck8823/duci", "refs/heads/master")
+	otherRef := add("duci/push", "duck8823/duci", "refs/heads/develop")
+	otherRepo := add("duci/push", "duck8823/other", "refs/heads/master")
+
+	// when
+	ids := jobs.cancelRef("duck8823/duci", "refs/heads/master", "duci/push", self)
+
+	// then
+	if len(ids) != 1 || ids[0] != sameRef {
+		t.Errorf("cancelled ids must be [%s], but got %+v", sameRef, ids)
+	}
+
+	for _, id := range []uuid.UUID{self, otherTask, otherRef, otherRepo} {
+		if cancelled[id] {
+			t.Errorf("job %s must not be cancelled", id)
+		}
+	}
+
+	t.Run("without task name", func(t *testing.T) {
+		// when
+		ids := jobs.cancelRef("duck8823/duci", "refs/heads/master", "", uuid.Nil)
+
+		// then
+		if len(ids) != 3 {
+			t.Errorf("length must be 3, but got %+v", ids)
+		}
+	})
+}
+
+func TestRunningJobs_Mask(t *testing.T) {
+	// given
+	jobs := &runningJobs{}
+
+	id := uuid.New()
+	jobs.add(context.New("duci/push", id, &url.URL{}), "duck8823/duci", "refs/heads/master", func() {})
+	jobs.setSecrets(id, map[string]string{"SHORT": "secret", "LONG": "secret_value", "EMPTY": ""})
+
+	// when
+	actual := jobs.mask(id, "token is secret_value, and password is secret.")
+
+	// then
+	expected := "token is ***, and password is ***."
+	if actual != expected {
+		t.Errorf("text must be %s, but got %s", expected, actual)
+	}
+
+	t.Run("with other job", func(t *testing.T) {
+		// when
+		actual := jobs.mask(uuid.New(), "secret_value")
+
+		// then
+		if actual != "secret_value" {
+			t.Errorf("text must not be masked, but got %s", actual)
+		}
+	})
+}
@@ -0,0 +1,141 @@
+package secret
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"github.com/duck8823/duci/infrastructure/store"
+	"github.com/pkg/errors"
+	"io"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+var keyPrefix = []byte("secret/")
+
+// validName is name of secret available as environment variable.
+var validName = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+var KeyNotConfigured = errors.New("secret key is not configured")
+
+var InvalidName = errors.New("name of secret must be letters, digits and underscores")
+
+type Service interface {
+	Put(fullName string, name string, value string) error
+	Delete(fullName string, name string) error
+	Names(fullName string) ([]string, error)
+	All(fullName string) (map[string]string, error)
+}
+
+type storeServiceImpl struct {
+	db  store.Store
+	gcm cipher.AEAD
+}
+
+// New returns service of secrets encrypted with the key.
+// Without key, no secret can be stored.
+func New(database store.Store, key string) (Service, error) {
+	if len(key) == 0 {
+		return &storeServiceImpl{db: database}, nil
+	}
+
+	hash := sha256.Sum256([]byte(key))
+	block, err := aes.NewCipher(hash[:])
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return &storeServiceImpl{db: database, gcm: gcm}, nil
+}
+
+// Put stores the secret of the repository, encrypted.
+func (s *storeServiceImpl) Put(fullName string, name string, value string) error {
+	if s.gcm == nil {
+		return KeyNotConfigured
+	}
+	if !validName.MatchString(name) {
+		return InvalidName
+	}
+
+	nonce := make([]byte, s.gcm.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return errors.WithStack(err)
+	}
+	// the key is authenticated too, not to be moved to other repositories
+	k := key(fullName, name)
+	sealed := s.gcm.Seal(nonce, nonce, []byte(value), k)
+
+	if err := s.db.Put(k, sealed, nil); err != nil {
+		return errors.WithStack(err)
+	}
+	return nil
+}
+
+// Delete removes the secret of the repository.
+func (s *storeServiceImpl) Delete(fullName string, name string) error {
+	k := key(fullName, name)
+	if ok, err := s.db.Has(k, nil); err != nil {
+		return errors.WithStack(err)
+	} else if !ok {
+		return store.NotFoundError
+	}
+	if err := s.db.Delete(k, nil); err != nil {
+		return errors.WithStack(err)
+	}
+	return nil
+}
+
+// Names returns names of secrets of the repository, without values.
+func (s *storeServiceImpl) Names(fullName string) ([]string, error) {
+	prefix := key(fullName, "")
+	iter := s.db.NewIterator(store.Prefix(prefix), nil)
+	defer iter.Release()
+
+	names := []string{}
+	for iter.Next() {
+		names = append(names, strings.TrimPrefix(string(iter.Key()), string(prefix)))
+	}
+	if err := iter.Error(); err != nil {
+		return nil, errors.WithStack(err)
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// All returns decrypted secrets of the repository, keyed by name.
+func (s *storeServiceImpl) All(fullName string) (map[string]string, error) {
+	secrets := make(map[string]string)
+	if s.gcm == nil {
+		return secrets, nil
+	}
+
+	prefix := key(fullName, "")
+	iter := s.db.NewIterator(store.Prefix(prefix), nil)
+	defer iter.Release()
+
+	for iter.Next() {
+		sealed := iter.Value()
+		if len(sealed) < s.gcm.NonceSize() {
+			return nil, errors.Errorf("invalid secret %s", iter.Key())
+		}
+		nonce, ciphertext := sealed[:s.gcm.NonceSize()], sealed[s.gcm.NonceSize():]
+		value, err := s.gcm.Open(nil, nonce, ciphertext, iter.Key())
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to decrypt secret %s", iter.Key())
+		}
+		secrets[strings.TrimPrefix(string(iter.Key()), string(prefix))] = string(value)
+	}
+	if err := iter.Error(); err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return secrets, nil
+}
+
+func key(fullName string, name string) []byte {
+	return []byte(string(keyPrefix) + fullName + "/" + name)
+}
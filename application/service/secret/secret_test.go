@@ -0,0 +1,205 @@
+package secret_test
+
+import (
+	"bytes"
+	"github.com/duck8823/duci/application/service/secret"
+	"github.com/duck8823/duci/infrastructure/store"
+	"github.com/pkg/errors"
+	"github.com/syndtr/goleveldb/leveldb"
+	"github.com/syndtr/goleveldb/leveldb/storage"
+	"reflect"
+	"testing"
+)
+
+func TestStoreServiceImpl_Put(t *testing.T) {
+	// setup
+	db, err := leveldb.Open(storage.NewMemStorage(), nil)
+	if err != nil {
+		t.Fatalf("error occurred: %+v", err)
+	}
+	defer db.Close()
+
+	service, err := secret.New(db, "secret key")
+	if err != nil {
+		t.Fatalf("error occurred: %+v", err)
+	}
+
+	t.Run("with valid name", func(t *testing.T) {
+		// when
+		if err := service.Put("duck8823/duci", "NPM_TOKEN", "s3cr3t"); err != nil {
+			t.Fatalf("error must not occur, but got %+v", err)
+		}
+
+		// then
+		iter := db.NewIterator(store.Prefix([]byte("secret/")), nil)
+		defer iter.Release()
+		for iter.Next() {
+			if bytes.Contains(iter.Value(), []byte("s3cr3t")) {
+				t.Errorf("value must be encrypted, but got %s", iter.Value())
+			}
+		}
+
+		// and
+		secrets, err := service.All("duck8823/duci")
+		if err != nil {
+			t.Fatalf("error must not occur, but got %+v", err)
+		}
+		if !reflect.DeepEqual(secrets, map[string]string{"NPM_TOKEN": "s3cr3t"}) {
+			t.Errorf("secrets must be decrypted, but got %+v", secrets)
+		}
+	})
+
+	t.Run("with invalid name", func(t *testing.T) {
+		// expect
+		if err := service.Put("duck8823/duci", "../TOKEN", "s3cr3t"); err != secret.InvalidName {
+			t.Errorf("error must be %+v, but got %+v", secret.InvalidName, err)
+		}
+	})
+
+	t.Run("without key", func(t *testing.T) {
+		// given
+		service, err := secret.New(db, "")
+		if err != nil {
+			t.Fatalf("error occurred: %+v", err)
+		}
+
+		// expect
+		if err := service.Put("duck8823/duci", "NPM_TOKEN", "s3cr3t"); err != secret.KeyNotConfigured {
+			t.Errorf("error must be %+v, but got %+v", secret.KeyNotConfigured, err)
+		}
+	})
+}
+
+func TestStoreServiceImpl_All(t *testing.T) {
+	// setup
+	db, err := leveldb.Open(storage.NewMemStorage(), nil)
+	if err != nil {
+		t.Fatalf("error occurred: %+v", err)
+	}
+	defer db.Close()
+
+	service, err := secret.New(db, "secret key")
+	if err != nil {
+		t.Fatalf("error occurred: %+v", err)
+	}
+
+	// and
+	for _, s := range []struct{ fullName, name, value string }{
+		{"duck8823/duci", "NPM_TOKEN", "duci_token"},
+		{"duck8823/duci-other", "NPM_TOKEN", "other_token"},
+		{"duck8823/duci", "DB_PASSWORD", "duci_password"},
+	} {
+		if err := service.Put(s.fullName, s.name, s.value); err != nil {
+			t.Fatalf("error occurred: %+v", err)
+		}
+	}
+
+	t.Run("with secrets of the repository", func(t *testing.T) {
+		// when
+		secrets, err := service.All("duck8823/duci")
+
+		// then
+		if err != nil {
+			t.Fatalf("error must not occur, but got %+v", err)
+		}
+		expected := map[string]string{"NPM_TOKEN": "duci_token", "DB_PASSWORD": "duci_password"}
+		if !reflect.DeepEqual(secrets, expected) {
+			t.Errorf("secrets must be %+v, but got %+v", expected, secrets)
+		}
+	})
+
+	t.Run("with secret moved to other repository", func(t *testing.T) {
+		// given
+		value, err := db.Get([]byte("secret/duck8823/duci/NPM_TOKEN"), nil)
+		if err != nil {
+			t.Fatalf("error occurred: %+v", err)
+		}
+		if err := db.Put([]byte("secret/duck8823/stolen/NPM_TOKEN"), value, nil); err != nil {
+			t.Fatalf("error occurred: %+v", err)
+		}
+
+		// expect
+		if _, err := service.All("duck8823/stolen"); err == nil {
+			t.Error("error must occur")
+		}
+	})
+
+	t.Run("with wrong key", func(t *testing.T) {
+		// given
+		service, err := secret.New(db, "wrong key")
+		if err != nil {
+			t.Fatalf("error occurred: %+v", err)
+		}
+
+		// expect
+		if _, err := service.All("duck8823/duci"); err == nil {
+			t.Error("error must occur")
+		}
+	})
+}
+
+func TestStoreServiceImpl_Names(t *testing.T) {
+	// setup
+	db, err := leveldb.Open(storage.NewMemStorage(), nil)
+	if err != nil {
+		t.Fatalf("error occurred: %+v", err)
+	}
+	defer db.Close()
+
+	service, err := secret.New(db, "secret key")
+	if err != nil {
+		t.Fatalf("error occurred: %+v", err)
+	}
+
+	// given
+	for _, name := range []string{"NPM_TOKEN", "DB_PASSWORD"} {
+		if err := service.Put("duck8823/duci", name, "value"); err != nil {
+			t.Fatalf("error occurred: %+v", err)
+		}
+	}
+
+	// when
+	names, err := service.Names("duck8823/duci")
+
+	// then
+	if err != nil {
+		t.Fatalf("error must not occur, but got %+v", err)
+	}
+	if !reflect.DeepEqual(names, []string{"DB_PASSWORD", "NPM_TOKEN"}) {
+		t.Errorf("names must be sorted, but got %+v", names)
+	}
+}
+
+func TestStoreServiceImpl_Delete(t *testing.T) {
+	// setup
+	db, err := leveldb.Open(storage.NewMemStorage(), nil)
+	if err != nil {
+		t.Fatalf("error occurred: %+v", err)
+	}
+	defer db.Close()
+
+	service, err := secret.New(db, "secret key")
+	if err != nil {
+		t.Fatalf("error occurred: %+v", err)
+	}
+
+	// given
+	if err := service.Put("duck8823/duci", "NPM_TOKEN", "value"); err != nil {
+		t.Fatalf("error occurred: %+v", err)
+	}
+
+	// when
+	if err := service.Delete("duck8823/duci", "NPM_TOKEN"); err != nil {
+		t.Fatalf("error must not occur, but got %+v", err)
+	}
+
+	// then
+	if names, _ := service.Names("duck8823/duci"); len(names) != 0 {
+		t.Errorf("secret must be deleted, but got %+v", names)
+	}
+
+	// and
+	if err := service.Delete("duck8823/duci", "NPM_TOKEN"); errors.Cause(err) != store.NotFoundError {
+		t.Errorf("error must be %+v, but got %+v", store.NotFoundError, err)
+	}
+}
@@ -1,10 +1,47 @@
 package model
 
-import "time"
+import (
+	"github.com/google/uuid"
+	"time"
+)
+
+type State = string
+
+const (
+	QUEUED    State = "queued"
+	RUNNING   State = "running"
+	SUCCESS   State = "success"
+	FAILURE   State = "failure"
+	ERROR     State = "error"
+	CANCELLED State = "cancelled"
+)
+
+type Trigger = string
+
+const (
+	PUSH         Trigger = "push"
+	PULL_REQUEST Trigger = "pull_request"
+	COMMENT      Trigger = "comment"
+	MANUAL       Trigger = "manual"
+)
 
 type Job struct {
-	Finished bool      `json:"finished"`
-	Stream   []Message `json:"stream"`
+	ID         uuid.UUID `json:"id"`
+	Repository string    `json:"repository"`
+	// RepositoryURL is html url of the repository, to link from dashboard
+	RepositoryURL string    `json:"repositoryUrl,omitempty"`
+	Ref           string    `json:"ref"`
+	SHA           string    `json:"sha"`
+	TaskName      string    `json:"taskName"`
+	Command       []string  `json:"command"`
+	Trigger       Trigger   `json:"trigger"`
+	State         State     `json:"state"`
+	ExitCode      int64     `json:"exitCode"`
+	QueuedAt      time.Time `json:"queuedAt"`
+	StartedAt     time.Time `json:"startedAt"`
+	FinishedAt    time.Time `json:"finishedAt"`
+	Finished      bool      `json:"finished"`
+	Stream        []Message `json:"stream,omitempty"`
 }
 
 type Message struct {
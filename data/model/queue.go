@@ -0,0 +1,43 @@
+package model
+
+import (
+	"github.com/google/uuid"
+	"time"
+)
+
+// QueuedJob is a job request waiting for execution or running.
+type QueuedJob struct {
+	ID         uuid.UUID  `json:"id"`
+	TaskName   string     `json:"taskName"`
+	TargetURL  string     `json:"targetUrl"`
+	Repository Repository `json:"repository"`
+	Ref        string     `json:"ref"`
+	SHA        string     `json:"sha"`
+	Command    []string   `json:"command"`
+	Trigger    Trigger    `json:"trigger"`
+	QueuedAt   time.Time  `json:"queuedAt"`
+	Running    bool       `json:"running"`
+}
+
+type Repository struct {
+	FullName string `json:"fullName"`
+	SSHURL   string `json:"sshUrl"`
+	HTMLURL  string `json:"htmlUrl"`
+	CloneURL string `json:"cloneUrl"`
+}
+
+func (r *Repository) GetFullName() string {
+	return r.FullName
+}
+
+func (r *Repository) GetSSHURL() string {
+	return r.SSHURL
+}
+
+func (r *Repository) GetHTMLURL() string {
+	return r.HTMLURL
+}
+
+func (r *Repository) GetCloneURL() string {
+	return r.CloneURL
+}
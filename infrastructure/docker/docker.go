@@ -7,14 +7,29 @@ import (
 	"github.com/docker/docker/api/types"
 	"github.com/docker/docker/api/types/container"
 	moby "github.com/docker/docker/client"
+	"github.com/docker/go-units"
 	"github.com/pkg/errors"
 	"io"
 	"strings"
 )
 
+// Network modes of jobs.
+const (
+	// NetworkNone is no network.
+	NetworkNone = "none"
+	// NetworkIsolated is network of the job, without access to outside.
+	NetworkIsolated = "isolated"
+	// NetworkBridge is network of the job, with access to outside through the bridge.
+	NetworkBridge = "bridge"
+)
+
 type RuntimeOptions struct {
 	Environments Environments
 	Volumes      Volumes
+	// Network is set by the runner, to join the container to network of the job.
+	Network string `yaml:"-"`
+	// Resources is set by the runner, within limits of the server.
+	Resources Resources `yaml:"-"`
 }
 
 type Environments map[string]interface{}
@@ -38,12 +53,58 @@ func (v Volumes) ToMap() map[string]struct{} {
 	return m
 }
 
+// Resources is limits of resources for the container. Zero means unlimited.
+type Resources struct {
+	// Memory is limit in bytes.
+	Memory    int64
+	CPUShares int64
+	// CPUQuota is CPU time in microseconds per 100 milliseconds.
+	CPUQuota  int64
+	PidsLimit int64
+	// Ulimits is limits keyed by the name ( e.g. nofile ), with the same soft and hard limit.
+	Ulimits map[string]int64
+}
+
+func (r Resources) ToResources() container.Resources {
+	resources := container.Resources{
+		Memory:    r.Memory,
+		CPUShares: r.CPUShares,
+		CPUQuota:  r.CPUQuota,
+		PidsLimit: r.PidsLimit,
+	}
+	if r.CPUQuota > 0 {
+		resources.CPUPeriod = 100000
+	}
+	for name, limit := range r.Ulimits {
+		resources.Ulimits = append(resources.Ulimits, &units.Ulimit{Name: name, Soft: limit, Hard: limit})
+	}
+	return resources
+}
+
+// BuildArgs is values of ARG in Dockerfile.
+type BuildArgs map[string]string
+
+func (a BuildArgs) ToMap() map[string]*string {
+	m := make(map[string]*string)
+	for key, val := range a {
+		val := val
+		m[key] = &val
+	}
+	return m
+}
+
 type Client interface {
-	Build(ctx context.Context, file io.Reader, tag string, dockerfile string) (Log, error)
+	Build(ctx context.Context, file io.Reader, tag string, dockerfile string, args BuildArgs) (Log, error)
 	Run(ctx context.Context, opts RuntimeOptions, tag string, cmd ...string) (string, Log, error)
+	Kill(ctx context.Context, containerId string) error
 	Rm(ctx context.Context, containerId string) error
 	Rmi(ctx context.Context, tag string) error
 	ExitCode(ctx context.Context, containerId string) (int64, error)
+	OOMKilled(ctx context.Context, containerId string) (bool, error)
+	CreateNetwork(ctx context.Context, name string, internal bool) (string, error)
+	RemoveNetwork(ctx context.Context, networkId string) error
+	RunService(ctx context.Context, opts ServiceOptions, networkId string) (string, error)
+	WaitHealthy(ctx context.Context, containerId string) error
 }
 
 type clientImpl struct {
@@ -58,10 +119,11 @@ func New() (Client, error) {
 	return &clientImpl{moby: cli}, nil
 }
 
-func (c *clientImpl) Build(ctx context.Context, file io.Reader, tag string, dockerfile string) (Log, error) {
+func (c *clientImpl) Build(ctx context.Context, file io.Reader, tag string, dockerfile string, args BuildArgs) (Log, error) {
 	opts := types.ImageBuildOptions{
 		Tags:       []string{tag},
 		Dockerfile: dockerfile,
+		BuildArgs:  args.ToMap(),
 		Remove:     true,
 	}
 	resp, err := c.moby.ImageBuild(ctx, file, opts)
@@ -79,7 +141,9 @@ func (c *clientImpl) Run(ctx context.Context, opts RuntimeOptions, tag string, c
 		Volumes: opts.Volumes.ToMap(),
 		Cmd:     cmd,
 	}, &container.HostConfig{
-		Binds: opts.Volumes,
+		Binds:       opts.Volumes,
+		NetworkMode: container.NetworkMode(opts.Network),
+		Resources:   opts.Resources.ToResources(),
 	}, nil, "")
 	if err != nil {
 		return "", nil, errors.WithStack(err)
@@ -101,6 +165,13 @@ func (c *clientImpl) Run(ctx context.Context, opts RuntimeOptions, tag string, c
 	return con.ID, &runLogger{bufio.NewReader(log)}, nil
 }
 
+func (c *clientImpl) Kill(ctx context.Context, containerId string) error {
+	if err := c.moby.ContainerKill(ctx, containerId, "KILL"); err != nil {
+		return errors.WithStack(err)
+	}
+	return nil
+}
+
 func (c *clientImpl) Rm(ctx context.Context, containerId string) error {
 	if err := c.moby.ContainerRemove(ctx, containerId, types.ContainerRemoveOptions{}); err != nil {
 		return errors.WithStack(err)
@@ -124,3 +195,12 @@ func (c *clientImpl) ExitCode(ctx context.Context, containerId string) (int64, e
 		return -1, errors.WithStack(e)
 	}
 }
+
+// OOMKilled returns whether the container was killed by out of memory.
+func (c *clientImpl) OOMKilled(ctx context.Context, containerId string) (bool, error) {
+	info, err := c.moby.ContainerInspect(ctx, containerId)
+	if err != nil {
+		return false, errors.WithStack(err)
+	}
+	return info.State.OOMKilled, nil
+}
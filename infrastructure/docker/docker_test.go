@@ -5,6 +5,7 @@ import (
 	"github.com/docker/docker/api/types"
 	"github.com/docker/docker/api/types/container"
 	"github.com/docker/docker/client"
+	"github.com/docker/go-units"
 	"github.com/duck8823/duci/application/context"
 	"github.com/duck8823/duci/infrastructure/docker"
 	"github.com/google/uuid"
@@ -55,7 +56,7 @@ func TestClientImpl_Build(t *testing.T) {
 			}
 
 			// when
-			logger, err := cli.Build(context.New("test/task", uuid.New(), &url.URL{}), tar, tag, "./Dockerfile")
+			logger, err := cli.Build(context.New("test/task", uuid.New(), &url.URL{}), tar, tag, "./Dockerfile", nil)
 			if err != nil {
 				t.Fatalf("error occurred: %+v", err)
 			}
@@ -84,7 +85,7 @@ func TestClientImpl_Build(t *testing.T) {
 			}
 
 			// when
-			logger, err := cli.Build(context.New("test/task", uuid.New(), &url.URL{}), tar, tag, ".duci/Dockerfile")
+			logger, err := cli.Build(context.New("test/task", uuid.New(), &url.URL{}), tar, tag, ".duci/Dockerfile", nil)
 			if err != nil {
 				t.Fatalf("error occurred: %+v", err)
 			}
@@ -114,7 +115,7 @@ func TestClientImpl_Build(t *testing.T) {
 		}
 
 		// expect
-		if _, err := cli.Build(context.New("test/task", uuid.New(), &url.URL{}), tar, tag, "./Dockerfile"); err == nil {
+		if _, err := cli.Build(context.New("test/task", uuid.New(), &url.URL{}), tar, tag, "./Dockerfile", nil); err == nil {
 			t.Error("error must not be nil")
 		}
 	})
@@ -263,6 +264,51 @@ func TestClientImpl_Run(t *testing.T) {
 	})
 }
 
+func TestClientImpl_Kill(t *testing.T) {
+	// setup
+	cli, err := docker.New()
+	if err != nil {
+		t.Fatalf("error occurred: %+v", err)
+	}
+
+	moby, err := client.NewEnvClient()
+	if err != nil {
+		t.Fatalf("error occurred: %+v", err)
+	}
+
+	// given
+	tag := "alpine:latest"
+	imagePull(t, tag)
+
+	con, err := moby.ContainerCreate(context.New("test/task", uuid.New(), &url.URL{}), &container.Config{
+		Image: tag,
+		Cmd:   []string{"sleep", "60"},
+	}, nil, nil, "")
+	if err != nil {
+		t.Fatalf("error occurred: %+v", err)
+	}
+	if err := moby.ContainerStart(context.New("test/task", uuid.New(), &url.URL{}), con.ID, types.ContainerStartOptions{}); err != nil {
+		t.Fatalf("error occurred: %+v", err)
+	}
+
+	// when
+	if err := cli.Kill(context.New("test/task", uuid.New(), &url.URL{}), con.ID); err != nil {
+		t.Fatalf("error occurred: %+v", err)
+	}
+
+	// then
+	info, err := moby.ContainerInspect(context.New("test/task", uuid.New(), &url.URL{}), con.ID)
+	if err != nil {
+		t.Fatalf("error occurred: %+v", err)
+	}
+	if info.State.Running {
+		t.Error("container must not be running")
+	}
+
+	// cleanup
+	removeContainer(t, con.ID)
+}
+
 func TestClientImpl_Rm(t *testing.T) {
 	// setup
 	cli, err := docker.New()
@@ -441,6 +487,76 @@ func TestVolumes_Volumes(t *testing.T) {
 	}
 }
 
+func TestBuildArgs_ToMap(t *testing.T) {
+	// given
+	version := "1.11"
+	expected := map[string]*string{"GO_VERSION": &version}
+
+	// when
+	actual := docker.BuildArgs{"GO_VERSION": "1.11"}.ToMap()
+
+	// then
+	if !reflect.DeepEqual(actual, expected) {
+		t.Errorf("must be equal. actual=%+v, wont=%+v", actual, expected)
+	}
+}
+
+func TestResources_ToResources(t *testing.T) {
+	// given
+	expected := container.Resources{
+		Memory:    1 << 30,
+		CPUQuota:  50000,
+		CPUPeriod: 100000,
+		PidsLimit: 256,
+		Ulimits:   []*units.Ulimit{{Name: "nofile", Soft: 1024, Hard: 1024}},
+	}
+
+	// when
+	actual := docker.Resources{
+		Memory:    1 << 30,
+		CPUQuota:  50000,
+		PidsLimit: 256,
+		Ulimits:   map[string]int64{"nofile": 1024},
+	}.ToResources()
+
+	// then
+	if !reflect.DeepEqual(actual, expected) {
+		t.Errorf("must be equal. actual=%+v, wont=%+v", actual, expected)
+	}
+}
+
+func TestClientImpl_OOMKilled(t *testing.T) {
+	// setup
+	cli, err := docker.New()
+	if err != nil {
+		t.Fatalf("error occurred: %+v", err)
+	}
+
+	// given
+	imagePull(t, "alpine:latest")
+
+	// and
+	containerId, _, err := cli.Run(context.New("test/task", uuid.New(), &url.URL{}), docker.RuntimeOptions{}, "alpine", "sh", "-c", "exit 1")
+	if err != nil {
+		t.Fatalf("error occurred: %+v", err)
+	}
+	containerWait(t, containerId)
+
+	// when
+	oom, err := cli.OOMKilled(context.New("test/task", uuid.New(), &url.URL{}), containerId)
+
+	// then
+	if err != nil {
+		t.Errorf("error must not occur, but got %+v", err)
+	}
+	if oom {
+		t.Error("container must not be killed by out of memory")
+	}
+
+	// cleanup
+	removeContainer(t, containerId)
+}
+
 func contains(strings []string, str string) bool {
 	for _, s := range strings {
 		if s == str {
@@ -36,16 +36,16 @@ func (m *MockClient) EXPECT() *MockClientMockRecorder {
 }
 
 // Build mocks base method
-func (m *MockClient) Build(ctx context.Context, file io.Reader, tag, dockerfile string) (docker.Log, error) {
-	ret := m.ctrl.Call(m, "Build", ctx, file, tag, dockerfile)
+func (m *MockClient) Build(ctx context.Context, file io.Reader, tag, dockerfile string, args docker.BuildArgs) (docker.Log, error) {
+	ret := m.ctrl.Call(m, "Build", ctx, file, tag, dockerfile, args)
 	ret0, _ := ret[0].(docker.Log)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // Build indicates an expected call of Build
-func (mr *MockClientMockRecorder) Build(ctx, file, tag, dockerfile interface{}) *gomock.Call {
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Build", reflect.TypeOf((*MockClient)(nil).Build), ctx, file, tag, dockerfile)
+func (mr *MockClientMockRecorder) Build(ctx, file, tag, dockerfile, args interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Build", reflect.TypeOf((*MockClient)(nil).Build), ctx, file, tag, dockerfile, args)
 }
 
 // Run mocks base method
@@ -67,6 +67,18 @@ func (mr *MockClientMockRecorder) Run(ctx, opts, tag interface{}, cmd ...interfa
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Run", reflect.TypeOf((*MockClient)(nil).Run), varargs...)
 }
 
+// Kill mocks base method
+func (m *MockClient) Kill(ctx context.Context, containerId string) error {
+	ret := m.ctrl.Call(m, "Kill", ctx, containerId)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Kill indicates an expected call of Kill
+func (mr *MockClientMockRecorder) Kill(ctx, containerId interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Kill", reflect.TypeOf((*MockClient)(nil).Kill), ctx, containerId)
+}
+
 // Rm mocks base method
 func (m *MockClient) Rm(ctx context.Context, containerId string) error {
 	ret := m.ctrl.Call(m, "Rm", ctx, containerId)
@@ -103,3 +115,66 @@ func (m *MockClient) ExitCode(ctx context.Context, containerId string) (int64, e
 func (mr *MockClientMockRecorder) ExitCode(ctx, containerId interface{}) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ExitCode", reflect.TypeOf((*MockClient)(nil).ExitCode), ctx, containerId)
 }
+
+// OOMKilled mocks base method
+func (m *MockClient) OOMKilled(ctx context.Context, containerId string) (bool, error) {
+	ret := m.ctrl.Call(m, "OOMKilled", ctx, containerId)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// OOMKilled indicates an expected call of OOMKilled
+func (mr *MockClientMockRecorder) OOMKilled(ctx, containerId interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "OOMKilled", reflect.TypeOf((*MockClient)(nil).OOMKilled), ctx, containerId)
+}
+
+// CreateNetwork mocks base method
+func (m *MockClient) CreateNetwork(ctx context.Context, name string, internal bool) (string, error) {
+	ret := m.ctrl.Call(m, "CreateNetwork", ctx, name, internal)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateNetwork indicates an expected call of CreateNetwork
+func (mr *MockClientMockRecorder) CreateNetwork(ctx, name, internal interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateNetwork", reflect.TypeOf((*MockClient)(nil).CreateNetwork), ctx, name, internal)
+}
+
+// RemoveNetwork mocks base method
+func (m *MockClient) RemoveNetwork(ctx context.Context, networkId string) error {
+	ret := m.ctrl.Call(m, "RemoveNetwork", ctx, networkId)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RemoveNetwork indicates an expected call of RemoveNetwork
+func (mr *MockClientMockRecorder) RemoveNetwork(ctx, networkId interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RemoveNetwork", reflect.TypeOf((*MockClient)(nil).RemoveNetwork), ctx, networkId)
+}
+
+// RunService mocks base method
+func (m *MockClient) RunService(ctx context.Context, opts docker.ServiceOptions, networkId string) (string, error) {
+	ret := m.ctrl.Call(m, "RunService", ctx, opts, networkId)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RunService indicates an expected call of RunService
+func (mr *MockClientMockRecorder) RunService(ctx, opts, networkId interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RunService", reflect.TypeOf((*MockClient)(nil).RunService), ctx, opts, networkId)
+}
+
+// WaitHealthy mocks base method
+func (m *MockClient) WaitHealthy(ctx context.Context, containerId string) error {
+	ret := m.ctrl.Call(m, "WaitHealthy", ctx, containerId)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// WaitHealthy indicates an expected call of WaitHealthy
+func (mr *MockClientMockRecorder) WaitHealthy(ctx, containerId interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "WaitHealthy", reflect.TypeOf((*MockClient)(nil).WaitHealthy), ctx, containerId)
+}
@@ -0,0 +1,138 @@
+package docker
+
+import (
+	"context"
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/network"
+	moby "github.com/docker/docker/client"
+	"github.com/pkg/errors"
+	"io"
+	"io/ioutil"
+	"time"
+)
+
+// ServiceOptions is options of sidecar container, e.g. database for integration tests.
+type ServiceOptions struct {
+	Name         string
+	Image        string
+	Environments Environments
+	Healthcheck  *Healthcheck
+	// Resources is set by the runner, within limits of the server.
+	Resources Resources `yaml:"-"`
+}
+
+// Healthcheck is command to check that the service is ready. Durations are in seconds.
+type Healthcheck struct {
+	Test     []string
+	Interval int64
+	Timeout  int64
+	Retries  int
+}
+
+func (h *Healthcheck) toConfig() *container.HealthConfig {
+	if h == nil {
+		return nil
+	}
+	return &container.HealthConfig{
+		Test:     h.Test,
+		Interval: time.Duration(h.Interval) * time.Second,
+		Timeout:  time.Duration(h.Timeout) * time.Second,
+		Retries:  h.Retries,
+	}
+}
+
+// healthInterval is interval to inspect health of services.
+const healthInterval = 500 * time.Millisecond
+
+// CreateNetwork creates user-defined network, and returns its id.
+// Containers on internal network can not reach outside of the network.
+func (c *clientImpl) CreateNetwork(ctx context.Context, name string, internal bool) (string, error) {
+	resp, err := c.moby.NetworkCreate(ctx, name, types.NetworkCreate{CheckDuplicate: true, Internal: internal})
+	if err != nil {
+		return "", errors.WithStack(err)
+	}
+	return resp.ID, nil
+}
+
+// RemoveNetwork removes the network.
+func (c *clientImpl) RemoveNetwork(ctx context.Context, networkId string) error {
+	if err := c.moby.NetworkRemove(ctx, networkId); err != nil {
+		return errors.WithStack(err)
+	}
+	return nil
+}
+
+// RunService starts the service on the network, reachable by its name.
+// The image is pulled if not exists.
+func (c *clientImpl) RunService(ctx context.Context, opts ServiceOptions, networkId string) (string, error) {
+	if err := c.pull(ctx, opts.Image); err != nil {
+		return "", errors.WithStack(err)
+	}
+
+	con, err := c.moby.ContainerCreate(ctx, &container.Config{
+		Image:       opts.Image,
+		Env:         opts.Environments.ToArray(),
+		Healthcheck: opts.Healthcheck.toConfig(),
+	}, &container.HostConfig{
+		Resources: opts.Resources.ToResources(),
+	}, &network.NetworkingConfig{
+		EndpointsConfig: map[string]*network.EndpointSettings{
+			networkId: {Aliases: []string{opts.Name}},
+		},
+	}, "")
+	if err != nil {
+		return "", errors.WithStack(err)
+	}
+
+	if err := c.moby.ContainerStart(ctx, con.ID, types.ContainerStartOptions{}); err != nil {
+		return con.ID, errors.WithStack(err)
+	}
+	return con.ID, nil
+}
+
+// WaitHealthy waits until the service becomes healthy.
+// Services without healthcheck are regarded as healthy once running.
+func (c *clientImpl) WaitHealthy(ctx context.Context, containerId string) error {
+	for {
+		info, err := c.moby.ContainerInspect(ctx, containerId)
+		if err != nil {
+			return errors.WithStack(err)
+		}
+
+		switch {
+		case !info.State.Running:
+			return errors.Errorf("service exited with code %d", info.State.ExitCode)
+		case info.State.Health == nil || info.State.Health.Status == types.Healthy:
+			return nil
+		case info.State.Health.Status == types.Unhealthy:
+			return errors.New("service is unhealthy")
+		}
+
+		select {
+		case <-ctx.Done():
+			return errors.WithStack(ctx.Err())
+		case <-time.After(healthInterval):
+		}
+	}
+}
+
+func (c *clientImpl) pull(ctx context.Context, image string) error {
+	if _, _, err := c.moby.ImageInspectWithRaw(ctx, image); err == nil {
+		return nil
+	} else if !moby.IsErrNotFound(err) {
+		return errors.WithStack(err)
+	}
+
+	stream, err := c.moby.ImagePull(ctx, image, types.ImagePullOptions{})
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	defer stream.Close()
+
+	// wait until pull
+	if _, err := io.Copy(ioutil.Discard, stream); err != nil {
+		return errors.WithStack(err)
+	}
+	return nil
+}
@@ -0,0 +1,92 @@
+package docker_test
+
+import (
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/client"
+	"github.com/duck8823/duci/application/context"
+	"github.com/duck8823/duci/infrastructure/docker"
+	"github.com/google/uuid"
+	"github.com/labstack/gommon/random"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestClientImpl_CreateNetwork(t *testing.T) {
+	// setup
+	cli, err := docker.New()
+	if err != nil {
+		t.Fatalf("error occurred: %+v", err)
+	}
+
+	moby, err := client.NewEnvClient()
+	if err != nil {
+		t.Fatalf("error occurred: %+v", err)
+	}
+
+	// given
+	name := strings.ToLower(random.String(16))
+
+	// when
+	networkId, err := cli.CreateNetwork(context.New("test/task", uuid.New(), &url.URL{}), name, true)
+	if err != nil {
+		t.Fatalf("error occurred: %+v", err)
+	}
+
+	// then
+	info, err := moby.NetworkInspect(context.New("test/task", uuid.New(), &url.URL{}), networkId, types.NetworkInspectOptions{})
+	if err != nil {
+		t.Fatalf("network must exist, but got %+v", err)
+	}
+	if !info.Internal {
+		t.Error("network must be internal")
+	}
+
+	// when
+	if err := cli.RemoveNetwork(context.New("test/task", uuid.New(), &url.URL{}), networkId); err != nil {
+		t.Fatalf("error occurred: %+v", err)
+	}
+
+	// then
+	if _, err := moby.NetworkInspect(context.New("test/task", uuid.New(), &url.URL{}), networkId, types.NetworkInspectOptions{}); err == nil {
+		t.Error("network must be removed")
+	}
+}
+
+func TestClientImpl_RunService(t *testing.T) {
+	// setup
+	cli, err := docker.New()
+	if err != nil {
+		t.Fatalf("error occurred: %+v", err)
+	}
+
+	networkId, err := cli.CreateNetwork(context.New("test/task", uuid.New(), &url.URL{}), strings.ToLower(random.String(16)), false)
+	if err != nil {
+		t.Fatalf("error occurred: %+v", err)
+	}
+
+	t.Run("when service exited", func(t *testing.T) {
+		// given
+		opts := docker.ServiceOptions{Name: "alpine", Image: "alpine:latest"}
+
+		// when
+		containerId, err := cli.RunService(context.New("test/task", uuid.New(), &url.URL{}), opts, networkId)
+		if err != nil {
+			t.Fatalf("error occurred: %+v", err)
+		}
+		containerWait(t, containerId)
+
+		// then
+		if err := cli.WaitHealthy(context.New("test/task", uuid.New(), &url.URL{}), containerId); err == nil {
+			t.Error("error must occur")
+		}
+
+		// cleanup
+		removeContainer(t, containerId)
+	})
+
+	// cleanup
+	if err := cli.RemoveNetwork(context.New("test/task", uuid.New(), &url.URL{}), networkId); err != nil {
+		t.Fatalf("error occurred: %+v", err)
+	}
+}
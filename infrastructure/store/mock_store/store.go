@@ -71,6 +71,30 @@ func (mr *MockStoreMockRecorder) Put(key, value, wo interface{}) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Put", reflect.TypeOf((*MockStore)(nil).Put), key, value, wo)
 }
 
+// Delete mocks base method
+func (m *MockStore) Delete(key []byte, wo *store.WriteOptions) error {
+	ret := m.ctrl.Call(m, "Delete", key, wo)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Delete indicates an expected call of Delete
+func (mr *MockStoreMockRecorder) Delete(key, wo interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Delete", reflect.TypeOf((*MockStore)(nil).Delete), key, wo)
+}
+
+// NewIterator mocks base method
+func (m *MockStore) NewIterator(slice *store.Range, ro *store.ReadOptions) store.Iterator {
+	ret := m.ctrl.Call(m, "NewIterator", slice, ro)
+	ret0, _ := ret[0].(store.Iterator)
+	return ret0
+}
+
+// NewIterator indicates an expected call of NewIterator
+func (mr *MockStoreMockRecorder) NewIterator(slice, ro interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "NewIterator", reflect.TypeOf((*MockStore)(nil).NewIterator), slice, ro)
+}
+
 // Close mocks base method
 func (m *MockStore) Close() error {
 	ret := m.ctrl.Call(m, "Close")
@@ -1,8 +1,12 @@
 package store
 
 import (
+	"github.com/pkg/errors"
+	"github.com/syndtr/goleveldb/leveldb"
 	leveldb_errors "github.com/syndtr/goleveldb/leveldb/errors"
+	"github.com/syndtr/goleveldb/leveldb/iterator"
 	"github.com/syndtr/goleveldb/leveldb/opt"
+	"github.com/syndtr/goleveldb/leveldb/util"
 )
 
 var (
@@ -11,10 +15,28 @@ var (
 
 type ReadOptions = opt.ReadOptions
 type WriteOptions = opt.WriteOptions
+type Iterator = iterator.Iterator
+type Range = util.Range
 
 type Store interface {
 	Get(key []byte, ro *ReadOptions) (value []byte, err error)
 	Has(key []byte, ro *ReadOptions) (ret bool, err error)
 	Put(key, value []byte, wo *WriteOptions) error
+	Delete(key []byte, wo *WriteOptions) error
+	NewIterator(slice *Range, ro *ReadOptions) Iterator
 	Close() error
 }
+
+// New opens database. The database can be shared among services.
+func New(path string) (Store, error) {
+	database, err := leveldb.OpenFile(path, nil)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return database, nil
+}
+
+// Prefix returns range of keys starting with the prefix.
+func Prefix(prefix []byte) *Range {
+	return util.BytesPrefix(prefix)
+}
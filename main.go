@@ -23,6 +23,10 @@ func main() {
 		return
 	}
 
+	if len(application.Config.GitHub.WebhookSecret) == 0 {
+		logger.Info(mainId, "warning: webhook_secret is not set, then payloads without signature are accepted")
+	}
+
 	rtr, err := router.New()
 	if err != nil {
 		logger.Errorf(mainId, "Failed to initialize controllers.\n%+v", err)
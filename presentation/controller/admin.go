@@ -0,0 +1,26 @@
+package controller
+
+import (
+	"crypto/subtle"
+	"github.com/duck8823/duci/application"
+	"net/http"
+	"strings"
+)
+
+// authorized verifies the bearer token with admin token of the server, and responds error if not.
+// The api is disabled without admin token.
+func authorized(w http.ResponseWriter, r *http.Request) bool {
+	token := string(application.Config.Server.AdminToken)
+	if len(token) == 0 {
+		http.Error(w, "Admin token is not configured", http.StatusForbidden)
+		return false
+	}
+
+	header := r.Header.Get("Authorization")
+	actual := strings.TrimPrefix(header, "Bearer ")
+	if actual == header || subtle.ConstantTimeCompare([]byte(actual), []byte(token)) != 1 {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return false
+	}
+	return true
+}
@@ -0,0 +1,30 @@
+package controller
+
+import (
+	"net/http"
+	"strings"
+)
+
+// DashboardController serves pages of the web dashboard.
+// The pages have no external assets, and read jobs and logs through the API.
+type DashboardController struct{}
+
+// Index serves the page listing jobs.
+func (c *DashboardController) Index(w http.ResponseWriter, _ *http.Request) {
+	writeHTML(w, indexHTML)
+}
+
+// Job serves the page of a job with its live logs.
+func (c *DashboardController) Job(w http.ResponseWriter, _ *http.Request) {
+	writeHTML(w, jobHTML)
+}
+
+func writeHTML(w http.ResponseWriter, html string) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Write([]byte(html))
+}
+
+// acceptsHTML reports whether the request comes from browsers.
+func acceptsHTML(r *http.Request) bool {
+	return strings.Contains(r.Header.Get("Accept"), "text/html")
+}
@@ -0,0 +1,282 @@
+package controller
+
+// Pages of the dashboard are kept as constants, so that the binary serves them without external assets.
+
+const dashboardStyle = `
+<style>
+  body { margin: 0; font-family: -apple-system, "Segoe UI", Helvetica, Arial, sans-serif; font-size: 14px; color: #24292e; }
+  header { padding: 12px 24px; background: #24292e; }
+  header a { color: #fff; font-weight: bold; font-size: 18px; text-decoration: none; }
+  main { padding: 16px 24px; }
+  a { color: #0366d6; }
+  table { border-collapse: collapse; width: 100%; }
+  th, td { text-align: left; padding: 6px 8px; border-bottom: 1px solid #e1e4e8; white-space: nowrap; }
+  form { margin-bottom: 12px; }
+  input, select, button { font-size: 14px; padding: 4px 6px; }
+  code { font-family: SFMono-Regular, Consolas, Menlo, monospace; }
+  dl { display: grid; grid-template-columns: max-content auto; gap: 4px 16px; }
+  dt { color: #586069; }
+  dd { margin: 0; }
+  .state { display: inline-block; min-width: 72px; padding: 2px 6px; border-radius: 3px; color: #fff; text-align: center; }
+  .state-queued, .state-running { background: #dbab09; }
+  .state-success { background: #28a745; }
+  .state-failure { background: #cb2431; }
+  .state-error, .state-cancelled { background: #6a737d; }
+  .pager { margin-top: 12px; }
+  pre.log { background: #1e1e1e; color: #d4d4d4; padding: 12px; overflow-x: auto; font-size: 13px; line-height: 1.4; }
+  .ansi-bold { font-weight: bold; }
+  .ansi-fg-30 { color: #000; } .ansi-fg-31 { color: #cd3131; } .ansi-fg-32 { color: #0dbc79; } .ansi-fg-33 { color: #e5e510; }
+  .ansi-fg-34 { color: #2472c8; } .ansi-fg-35 { color: #bc3fbc; } .ansi-fg-36 { color: #11a8cd; } .ansi-fg-37 { color: #e5e5e5; }
+  .ansi-fg-90 { color: #666; } .ansi-fg-91 { color: #f14c4c; } .ansi-fg-92 { color: #23d18b; } .ansi-fg-93 { color: #f5f543; }
+  .ansi-fg-94 { color: #3b8eea; } .ansi-fg-95 { color: #d670d6; } .ansi-fg-96 { color: #29b8db; } .ansi-fg-97 { color: #fff; }
+  .ansi-bg-40 { background: #000; } .ansi-bg-41 { background: #cd3131; } .ansi-bg-42 { background: #0dbc79; } .ansi-bg-43 { background: #e5e510; }
+  .ansi-bg-44 { background: #2472c8; } .ansi-bg-45 { background: #bc3fbc; } .ansi-bg-46 { background: #11a8cd; } .ansi-bg-47 { background: #e5e5e5; }
+</style>
+`
+
+const dashboardScript = `
+<script>
+  var GITHUB_URL = "https://github.com";
+
+  function esc(s) {
+    return String(s === undefined || s === null ? "" : s)
+      .replace(/&/g, "&amp;").replace(/</g, "&lt;").replace(/>/g, "&gt;").replace(/"/g, "&quot;");
+  }
+
+  function isZero(t) {
+    return !t || t.indexOf("0001-01-01") === 0;
+  }
+
+  function formatTime(t) {
+    return isZero(t) ? "-" : new Date(t).toLocaleString();
+  }
+
+  function duration(job) {
+    if (isZero(job.startedAt)) {
+      return "-";
+    }
+    var end = isZero(job.finishedAt) ? new Date() : new Date(job.finishedAt);
+    var sec = Math.max(0, Math.round((end - new Date(job.startedAt)) / 1000));
+    return (sec >= 60 ? Math.floor(sec / 60) + "m " : "") + (sec % 60) + "s";
+  }
+
+  function state(s) {
+    return '<span class="state state-' + esc(s) + '">' + esc(s || "unknown") + '</span>';
+  }
+
+  // repositoryURL returns html url of the repository, on github.com unless it is known.
+  function repositoryURL(job) {
+    return esc(job.repositoryUrl || GITHUB_URL + "/" + job.repository);
+  }
+
+  function commitLink(job) {
+    if (!job.repository || !job.sha) {
+      return "-";
+    }
+    return '<a href="' + repositoryURL(job) + '/commit/' + esc(job.sha) + '"><code>' + esc(job.sha.substring(0, 7)) + '</code></a>';
+  }
+
+  function pullRequestsLink(job) {
+    if (!job.repository || !job.sha) {
+      return "-";
+    }
+    return '<a href="' + repositoryURL(job) + '/pulls?q=is%3Apr+' + esc(job.sha) + '">pull requests</a>';
+  }
+
+  // ansi converts SGR escape sequences ( colors and bold ) into html.
+  function ansi(text) {
+    var html = "", classes = [], re = /\x1b\[([0-9;]*)m/g, last = 0, m;
+    function open() {
+      return classes.length ? '<span class="' + classes.join(" ") + '">' : "";
+    }
+    function close() {
+      return classes.length ? "</span>" : "";
+    }
+    while ((m = re.exec(text)) !== null) {
+      html += esc(text.substring(last, m.index)) + close();
+      (m[1] || "0").split(";").forEach(function (code) {
+        var n = parseInt(code, 10);
+        if (n === 0) {
+          classes = [];
+        } else if (n === 1) {
+          classes.push("ansi-bold");
+        } else if ((n >= 30 && n <= 37) || (n >= 90 && n <= 97)) {
+          classes = classes.filter(function (c) { return c.indexOf("ansi-fg-") !== 0; });
+          classes.push("ansi-fg-" + n);
+        } else if (n >= 40 && n <= 47) {
+          classes = classes.filter(function (c) { return c.indexOf("ansi-bg-") !== 0; });
+          classes.push("ansi-bg-" + n);
+        } else if (n === 39) {
+          classes = classes.filter(function (c) { return c.indexOf("ansi-fg-") !== 0; });
+        } else if (n === 49) {
+          classes = classes.filter(function (c) { return c.indexOf("ansi-bg-") !== 0; });
+        }
+      });
+      html += open();
+      last = re.lastIndex;
+    }
+    return html + esc(text.substring(last)) + close();
+  }
+</script>
+`
+
+const indexHTML = `<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>duci - jobs</title>
+` + dashboardStyle + dashboardScript + `
+</head>
+<body>
+<header><a href="/ui">duci</a></header>
+<main>
+  <form id="filter">
+    <input name="repo" placeholder="owner/repository">
+    <input name="branch" placeholder="branch">
+    <input name="sha" placeholder="sha">
+    <select name="state">
+      <option value="">all states</option>
+      <option>queued</option><option>running</option><option>success</option>
+      <option>failure</option><option>error</option><option>cancelled</option>
+    </select>
+    <button type="submit">Filter</button>
+  </form>
+  <table>
+    <thead>
+      <tr><th>State</th><th>Repository</th><th>Ref</th><th>Commit</th><th>Task</th><th>Trigger</th><th>Queued</th><th>Duration</th></tr>
+    </thead>
+    <tbody id="jobs"></tbody>
+  </table>
+  <div class="pager">
+    <button id="prev">&laquo; Newer</button>
+    <button id="next">Older &raquo;</button>
+  </div>
+</main>
+<script>
+  var LIMIT = 20;
+  var params = new URLSearchParams(location.search);
+  var offset = parseInt(params.get("offset") || "0", 10);
+
+  var form = document.getElementById("filter");
+  ["repo", "branch", "sha", "state"].forEach(function (name) {
+    form.elements[name].value = params.get(name) || "";
+  });
+
+  function query(o) {
+    var q = new URLSearchParams();
+    ["repo", "branch", "sha", "state"].forEach(function (name) {
+      if (form.elements[name].value) {
+        q.set(name, form.elements[name].value);
+      }
+    });
+    q.set("offset", o);
+    return q;
+  }
+
+  function load() {
+    var q = query(offset);
+    q.set("limit", LIMIT);
+    fetch("/jobs?" + q.toString()).then(function (res) {
+      return res.json();
+    }).then(function (jobs) {
+      document.getElementById("jobs").innerHTML = jobs.map(function (job) {
+        return "<tr>" +
+          "<td>" + state(job.state) + "</td>" +
+          "<td>" + esc(job.repository) + "</td>" +
+          "<td>" + esc(job.ref) + "</td>" +
+          "<td>" + commitLink(job) + "</td>" +
+          '<td><a href="/ui/jobs/' + esc(job.id) + '">' + esc(job.taskName || job.id) + "</a></td>" +
+          "<td>" + esc(job.trigger) + "</td>" +
+          "<td>" + formatTime(job.queuedAt) + "</td>" +
+          "<td>" + duration(job) + "</td>" +
+          "</tr>";
+      }).join("");
+      document.getElementById("prev").disabled = offset === 0;
+      document.getElementById("next").disabled = jobs.length < LIMIT;
+    });
+  }
+
+  form.addEventListener("submit", function (e) {
+    e.preventDefault();
+    location.search = query(0).toString();
+  });
+  document.getElementById("prev").addEventListener("click", function () {
+    location.search = query(Math.max(0, offset - LIMIT)).toString();
+  });
+  document.getElementById("next").addEventListener("click", function () {
+    location.search = query(offset + LIMIT).toString();
+  });
+
+  load();
+  setInterval(load, 5000);
+</script>
+</body>
+</html>
+`
+
+const jobHTML = `<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>duci - job</title>
+` + dashboardStyle + dashboardScript + `
+</head>
+<body>
+<header><a href="/ui">duci</a></header>
+<main>
+  <dl id="job"></dl>
+  <pre class="log" id="log"></pre>
+</main>
+<script>
+  var id = location.pathname.split("/").pop();
+  var timer;
+
+  function loadJob() {
+    fetch("/jobs/" + id).then(function (res) {
+      if (!res.ok) {
+        throw new Error(res.statusText);
+      }
+      return res.json();
+    }).then(function (job) {
+      document.title = "duci - " + (job.taskName || job.id);
+      document.getElementById("job").innerHTML =
+        "<dt>State</dt><dd>" + state(job.state) + (job.finished ? " ( exit code " + esc(job.exitCode) + " )" : "") + "</dd>" +
+        "<dt>Repository</dt><dd>" + (job.repository ? '<a href="' + repositoryURL(job) + '">' + esc(job.repository) + "</a>" : "-") + "</dd>" +
+        "<dt>Ref</dt><dd>" + esc(job.ref) + "</dd>" +
+        "<dt>Commit</dt><dd>" + commitLink(job) + " " + (job.trigger === "pull_request" || job.trigger === "comment" ? pullRequestsLink(job) : "") + "</dd>" +
+        "<dt>Task</dt><dd>" + esc(job.taskName) + " <code>" + esc((job.command || []).join(" ")) + "</code></dd>" +
+        "<dt>Trigger</dt><dd>" + esc(job.trigger) + "</dd>" +
+        "<dt>Queued</dt><dd>" + formatTime(job.queuedAt) + "</dd>" +
+        "<dt>Started</dt><dd>" + formatTime(job.startedAt) + "</dd>" +
+        "<dt>Finished</dt><dd>" + formatTime(job.finishedAt) + "</dd>" +
+        "<dt>Duration</dt><dd>" + duration(job) + "</dd>";
+      if (job.finished) {
+        clearInterval(timer);
+      }
+    }).catch(function (err) {
+      document.getElementById("job").innerHTML = "<dt>Error</dt><dd>" + esc(err.message) + "</dd>";
+      clearInterval(timer);
+    });
+  }
+
+  var log = document.getElementById("log");
+  var source = new EventSource("/logs/" + id);
+  source.onmessage = function (e) {
+    var msg = JSON.parse(e.data);
+    var follow = window.innerHeight + window.scrollY >= document.body.offsetHeight - 10;
+    log.insertAdjacentHTML("beforeend", ansi(msg.message.replace(/\n$/, "")) + "\n");
+    if (follow) {
+      window.scrollTo(0, document.body.scrollHeight);
+    }
+  };
+  source.addEventListener("finished", function () {
+    source.close();
+    loadJob();
+  });
+
+  loadJob();
+  timer = setInterval(loadJob, 3000);
+</script>
+</body>
+</html>
+`
@@ -0,0 +1,49 @@
+package controller_test
+
+import (
+	"github.com/duck8823/duci/presentation/controller"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestDashboardController(t *testing.T) {
+	// given
+	handler := &controller.DashboardController{}
+
+	for _, tt := range []struct {
+		name     string
+		handler  http.HandlerFunc
+		expected string
+	}{
+		{name: "Index", handler: handler.Index, expected: `fetch("/jobs?"`},
+		{name: "Job", handler: handler.Job, expected: `new EventSource("/logs/"`},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			// given
+			req := httptest.NewRequest("GET", "/", nil)
+			rec := httptest.NewRecorder()
+
+			// when
+			tt.handler(rec, req)
+
+			// then
+			if rec.Code != 200 {
+				t.Errorf("status must equal %+v, but got %+v", 200, rec.Code)
+			}
+
+			if !strings.HasPrefix(rec.Header().Get("Content-Type"), "text/html") {
+				t.Errorf("content type must be text/html, but got %s", rec.Header().Get("Content-Type"))
+			}
+
+			if !strings.Contains(rec.Body.String(), tt.expected) {
+				t.Errorf("body must contain %s", tt.expected)
+			}
+
+			if strings.Contains(rec.Body.String(), "src=\"http") || strings.Contains(rec.Body.String(), "href=\"http") {
+				t.Error("must not refer external assets")
+			}
+		})
+	}
+}
@@ -0,0 +1,132 @@
+package controller
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/duck8823/duci/application/service/logstore"
+	"github.com/duck8823/duci/application/service/runner"
+	"github.com/duck8823/duci/infrastructure/store"
+	"github.com/go-chi/chi"
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	defaultLimit = 20
+	maxLimit     = 100
+)
+
+type JobController struct {
+	Runner   runner.Runner
+	LogStore logstore.Service
+}
+
+// List responds metadata of jobs filtered by query parameters, newest first.
+func (c *JobController) List(w http.ResponseWriter, r *http.Request) {
+	query, err := parseJobQuery(r.URL.Query())
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Error occurred: %s", err.Error()), http.StatusBadRequest)
+		return
+	}
+
+	jobs, err := c.LogStore.List(query)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Sorry, Error occurred: %s", err.Error()), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(jobs)
+}
+
+// Show responds metadata of the job.
+func (c *JobController) Show(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(chi.URLParam(r, "uuid"))
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Error occurred: %s", err.Error()), http.StatusBadRequest)
+		return
+	}
+
+	job, err := c.LogStore.Get(id)
+	if errors.Cause(err) == store.NotFoundError {
+		http.Error(w, fmt.Sprintf("Job not found: %s", id), http.StatusNotFound)
+		return
+	} else if err != nil {
+		http.Error(w, fmt.Sprintf("Sorry, Error occurred: %s", err.Error()), http.StatusInternalServerError)
+		return
+	}
+	job.Stream = nil
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(job)
+}
+
+// Cancel cancels the job in flight. It requires admin token of the server.
+func (c *JobController) Cancel(w http.ResponseWriter, r *http.Request) {
+	if !authorized(w, r) {
+		return
+	}
+
+	id, err := uuid.Parse(chi.URLParam(r, "uuid"))
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Error occurred: %s", err.Error()), http.StatusBadRequest)
+		return
+	}
+
+	if err := c.Runner.Cancel(id); err == runner.JobNotFound {
+		http.Error(w, fmt.Sprintf("Job not found: %s", id), http.StatusNotFound)
+		return
+	} else if err != nil {
+		http.Error(w, fmt.Sprintf("Sorry, Error occurred: %s", err.Error()), http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+func parseJobQuery(values url.Values) (*logstore.Query, error) {
+	query := &logstore.Query{
+		Repository: values.Get("repo"),
+		Ref:        values.Get("ref"),
+		SHA:        values.Get("sha"),
+		State:      values.Get("state"),
+		Limit:      defaultLimit,
+	}
+	if branch := values.Get("branch"); len(branch) > 0 {
+		query.Ref = fmt.Sprintf("refs/heads/%s", strings.TrimPrefix(branch, "refs/heads/"))
+	}
+
+	for name, dest := range map[string]*time.Time{"since": &query.Since, "until": &query.Until} {
+		if len(values.Get(name)) == 0 {
+			continue
+		}
+		t, err := time.Parse(time.RFC3339, values.Get(name))
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid %s", name)
+		}
+		*dest = t
+	}
+
+	for name, dest := range map[string]*int{"offset": &query.Offset, "limit": &query.Limit} {
+		if len(values.Get(name)) == 0 {
+			continue
+		}
+		n, err := strconv.Atoi(values.Get(name))
+		if err != nil || n < 0 {
+			return nil, errors.Errorf("invalid %s: %s", name, values.Get(name))
+		}
+		*dest = n
+	}
+	if query.Limit == 0 {
+		return nil, errors.New("invalid limit: 0")
+	}
+	if query.Limit > maxLimit {
+		query.Limit = maxLimit
+	}
+	return query, nil
+}
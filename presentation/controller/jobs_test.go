@@ -0,0 +1,289 @@
+package controller_test
+
+import (
+	ctx "context"
+	"encoding/json"
+	"github.com/duck8823/duci/application"
+	"github.com/duck8823/duci/application/service/logstore"
+	"github.com/duck8823/duci/application/service/logstore/mock_logstore"
+	"github.com/duck8823/duci/application/service/runner"
+	"github.com/duck8823/duci/application/service/runner/mock_runner"
+	"github.com/duck8823/duci/data/model"
+	"github.com/duck8823/duci/infrastructure/store"
+	"github.com/duck8823/duci/presentation/controller"
+	"github.com/go-chi/chi"
+	"github.com/golang/mock/gomock"
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestJobController_List(t *testing.T) {
+	t.Run("with valid query", func(t *testing.T) {
+		// setup
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		// given
+		jobs := []*model.Job{{ID: uuid.New(), Repository: "duck8823/duci", State: model.FAILURE}}
+
+		// and
+		expected := &logstore.Query{
+			Repository: "duck8823/duci",
+			Ref:        "refs/heads/master",
+			State:      model.FAILURE,
+			Since:      time.Date(2018, time.October, 1, 0, 0, 0, 0, time.UTC),
+			Limit:      1,
+		}
+
+		// and
+		mockLogStore := mock_logstore.NewMockService(ctrl)
+		mockLogStore.EXPECT().
+			List(gomock.Eq(expected)).
+			Times(1).
+			Return(jobs, nil)
+
+		handler := &controller.JobController{LogStore: mockLogStore}
+
+		// and
+		req := httptest.NewRequest("GET", "/jobs?repo=duck8823/duci&branch=master&state=failure&since=2018-10-01T00:00:00Z&limit=1", nil)
+		rec := httptest.NewRecorder()
+
+		// when
+		handler.List(rec, req)
+
+		// then
+		if rec.Code != 200 {
+			t.Errorf("status must equal %+v, but got %+v", 200, rec.Code)
+		}
+
+		// and
+		var actual []*model.Job
+		if err := json.NewDecoder(rec.Body).Decode(&actual); err != nil {
+			t.Fatalf("error occurred: %+v", err)
+		}
+		if !cmp.Equal(actual, jobs) {
+			t.Errorf("find differences: %+v", cmp.Diff(actual, jobs))
+		}
+	})
+
+	t.Run("with invalid query", func(t *testing.T) {
+		for _, query := range []string{"since=yesterday", "until=1", "limit=-1", "limit=0", "offset=a"} {
+			t.Run(query, func(t *testing.T) {
+				// given
+				handler := &controller.JobController{}
+
+				req := httptest.NewRequest("GET", "/jobs?"+query, nil)
+				rec := httptest.NewRecorder()
+
+				// when
+				handler.List(rec, req)
+
+				// then
+				if rec.Code != 400 {
+					t.Errorf("status must equal %+v, but got %+v", 400, rec.Code)
+				}
+			})
+		}
+	})
+
+	t.Run("when store returns error", func(t *testing.T) {
+		// setup
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		// given
+		mockLogStore := mock_logstore.NewMockService(ctrl)
+		mockLogStore.EXPECT().
+			List(gomock.Any()).
+			Times(1).
+			Return(nil, errors.New("test error"))
+
+		handler := &controller.JobController{LogStore: mockLogStore}
+
+		req := httptest.NewRequest("GET", "/jobs", nil)
+		rec := httptest.NewRecorder()
+
+		// when
+		handler.List(rec, req)
+
+		// then
+		if rec.Code != 500 {
+			t.Errorf("status must equal %+v, but got %+v", 500, rec.Code)
+		}
+	})
+}
+
+func TestJobController_Show(t *testing.T) {
+	// setup
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	// given
+	id, err := uuid.NewRandom()
+	if err != nil {
+		t.Fatalf("error occurred: %+v", err)
+	}
+
+	for _, tt := range []struct {
+		name     string
+		job      *model.Job
+		err      error
+		expected int
+	}{
+		{name: "with stored job", job: &model.Job{ID: id, Stream: []model.Message{{Text: "Hello World."}}}, expected: 200},
+		{name: "when job not found", err: errors.WithStack(store.NotFoundError), expected: 404},
+		{name: "when store returns error", err: errors.New("test error"), expected: 500},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			// given
+			mockLogStore := mock_logstore.NewMockService(ctrl)
+			mockLogStore.EXPECT().
+				Get(gomock.Eq(id)).
+				Times(1).
+				Return(tt.job, tt.err)
+
+			handler := &controller.JobController{LogStore: mockLogStore}
+
+			// and
+			chiCtx := chi.NewRouteContext()
+			chiCtx.URLParams.Add("uuid", id.String())
+
+			req := httptest.NewRequest("GET", "/", nil).
+				WithContext(ctx.WithValue(ctx.Background(), chi.RouteCtxKey, chiCtx))
+			rec := httptest.NewRecorder()
+
+			// when
+			handler.Show(rec, req)
+
+			// then
+			if rec.Code != tt.expected {
+				t.Errorf("status must equal %+v, but got %+v", tt.expected, rec.Code)
+			}
+
+			if tt.job != nil {
+				actual := &model.Job{}
+				if err := json.NewDecoder(rec.Body).Decode(actual); err != nil {
+					t.Fatalf("error occurred: %+v", err)
+				}
+				if actual.ID != id || len(actual.Stream) != 0 {
+					t.Errorf("must be metadata of the job, but got %+v", actual)
+				}
+			}
+		})
+	}
+}
+
+func TestJobController_Cancel(t *testing.T) {
+	// setup
+	application.Config.Server.AdminToken = "admin_token"
+	defer func() {
+		application.Config.Server.AdminToken = ""
+	}()
+
+	t.Run("with valid uuid", func(t *testing.T) {
+		// setup
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		// given
+		id, err := uuid.NewRandom()
+		if err != nil {
+			t.Fatalf("error occurred: %+v", err)
+		}
+
+		for _, tt := range []struct {
+			name     string
+			err      error
+			expected int
+		}{
+			{name: "when job in flight", err: nil, expected: 200},
+			{name: "when job not found", err: runner.JobNotFound, expected: 404},
+			{name: "when runner returns error", err: errors.New("test error"), expected: 500},
+		} {
+			t.Run(tt.name, func(t *testing.T) {
+				// given
+				mockRunner := mock_runner.NewMockRunner(ctrl)
+				mockRunner.EXPECT().
+					Cancel(gomock.Eq(id)).
+					Times(1).
+					Return(tt.err)
+
+				handler := &controller.JobController{Runner: mockRunner}
+
+				// and
+				chiCtx := chi.NewRouteContext()
+				chiCtx.URLParams.Add("uuid", id.String())
+
+				req := httptest.NewRequest("DELETE", "/", nil).
+					WithContext(ctx.WithValue(ctx.Background(), chi.RouteCtxKey, chiCtx))
+				req.Header.Set("Authorization", "Bearer admin_token")
+				rec := httptest.NewRecorder()
+
+				// when
+				handler.Cancel(rec, req)
+
+				// then
+				if rec.Code != tt.expected {
+					t.Errorf("status must equal %+v, but got %+v", tt.expected, rec.Code)
+				}
+			})
+		}
+	})
+
+	t.Run("with invalid uuid", func(t *testing.T) {
+		// setup
+		handler := &controller.JobController{}
+
+		// given
+		chiCtx := chi.NewRouteContext()
+		chiCtx.URLParams.Add("uuid", "invalid_uuid")
+
+		req := httptest.NewRequest("DELETE", "/", nil).
+			WithContext(ctx.WithValue(ctx.Background(), chi.RouteCtxKey, chiCtx))
+		req.Header.Set("Authorization", "Bearer admin_token")
+		rec := httptest.NewRecorder()
+
+		// when
+		handler.Cancel(rec, req)
+
+		// then
+		if rec.Code != 400 {
+			t.Errorf("status must equal %+v, but got %+v", 400, rec.Code)
+		}
+	})
+
+	t.Run("with invalid token", func(t *testing.T) {
+		// setup
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		// given
+		mockRunner := mock_runner.NewMockRunner(ctrl)
+		mockRunner.EXPECT().
+			Cancel(gomock.Any()).
+			Times(0)
+
+		handler := &controller.JobController{Runner: mockRunner}
+
+		// and
+		chiCtx := chi.NewRouteContext()
+		chiCtx.URLParams.Add("uuid", uuid.New().String())
+
+		req := httptest.NewRequest("DELETE", "/", nil).
+			WithContext(ctx.WithValue(ctx.Background(), chi.RouteCtxKey, chiCtx))
+		req.Header.Set("Authorization", "Bearer wrong_token")
+		rec := httptest.NewRecorder()
+
+		// when
+		handler.Cancel(rec, req)
+
+		// then
+		if rec.Code != 401 {
+			t.Errorf("status must equal %+v, but got %+v", 401, rec.Code)
+		}
+	})
+}
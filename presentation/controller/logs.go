@@ -1,6 +1,7 @@
 package controller
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"github.com/duck8823/duci/application/service/logstore"
@@ -8,13 +9,19 @@ import (
 	"github.com/go-chi/chi"
 	"github.com/google/uuid"
 	"github.com/pkg/errors"
+	"io"
 	"net/http"
+	"strconv"
+	"strings"
 )
 
 type LogController struct {
 	LogStore logstore.Service
 }
 
+// ServeHTTP streams messages of the job until finished.
+// Messages are written as Server-Sent Events if the client accepts `text/event-stream`, otherwise as NDJSON.
+// Browsers are redirected to the dashboard.
 func (c *LogController) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	flusher, ok := w.(http.Flusher)
 	if !ok {
@@ -28,29 +35,109 @@ func (c *LogController) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if err := c.logs(w, flusher, id); err != nil {
+	if acceptsHTML(r) {
+		http.Redirect(w, r, fmt.Sprintf("../ui/jobs/%s", id), http.StatusFound)
+		return
+	}
+
+	if strings.Contains(r.Header.Get("Accept"), "text/event-stream") {
+		offset, err := lastEventOffset(r)
+		if err != nil {
+			http.Error(w, fmt.Sprintf("Error occurred: %s", err.Error()), http.StatusBadRequest)
+			return
+		}
+		if err := c.events(r.Context(), w, flusher, id, offset); err != nil {
+			http.Error(w, fmt.Sprintf("Sorry, Error occurred: %s", err.Error()), http.StatusInternalServerError)
+		}
+		return
+	}
+
+	if err := c.logs(r.Context(), w, flusher, id); err != nil {
 		http.Error(w, fmt.Sprintf("Sorry, Error occurred: %s", err.Error()), http.StatusInternalServerError)
 		return
 	}
 }
 
-func (c *LogController) logs(w http.ResponseWriter, f http.Flusher, id uuid.UUID) error {
-	var read int
-	var job *model.Job
-	var err error
+func (c *LogController) logs(ctx context.Context, w http.ResponseWriter, f http.Flusher, id uuid.UUID) error {
+	w.Header().Set("Content-Type", "application/x-ndjson")
+	return c.stream(ctx, id, 0, func(_ int, msg model.Message) {
+		json.NewEncoder(w).Encode(msg)
+		f.Flush()
+	}, func(_ *model.Job) {})
+}
+
+// events writes messages as Server-Sent Events with their sequence as id, and `finished` event at last.
+func (c *LogController) events(ctx context.Context, w http.ResponseWriter, f http.Flusher, id uuid.UUID, offset int) error {
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	return c.stream(ctx, id, offset, func(seq int, msg model.Message) {
+		writeEvent(w, seq, "", msg)
+		f.Flush()
+	}, func(job *model.Job) {
+		writeEvent(w, -1, "finished", struct {
+			State    model.State `json:"state"`
+			ExitCode int64       `json:"exitCode"`
+		}{job.State, job.ExitCode})
+		f.Flush()
+	})
+}
+
+// stream reads messages from the offset, and waits for changes of the job until finished or the client gone.
+func (c *LogController) stream(
+	ctx context.Context,
+	id uuid.UUID,
+	offset int,
+	write func(seq int, msg model.Message),
+	finish func(job *model.Job),
+) error {
+	// subscribe before reading, so that changes between reading and waiting are not missed
+	changed, unsubscribe := c.LogStore.Subscribe(id)
+	defer unsubscribe()
+
+	read := offset
 	for {
-		job, err = c.LogStore.Get(id)
+		job, err := c.LogStore.GetFrom(id, read)
 		if err != nil {
 			return errors.WithStack(err)
 		}
-		for _, msg := range job.Stream[read:] {
-			json.NewEncoder(w).Encode(msg)
-			f.Flush()
+		for _, msg := range job.Stream {
+			write(read, msg)
 			read++
 		}
 		if job.Finished {
-			break
+			finish(job)
+			return nil
+		}
+
+		select {
+		case <-changed:
+		case <-ctx.Done():
+			return nil
 		}
 	}
-	return nil
+}
+
+// lastEventOffset returns offset to resume from `Last-Event-ID` header.
+func lastEventOffset(r *http.Request) (int, error) {
+	lastEventId := r.Header.Get("Last-Event-ID")
+	if len(lastEventId) == 0 {
+		return 0, nil
+	}
+	seq, err := strconv.Atoi(lastEventId)
+	if err != nil || seq < 0 {
+		return 0, errors.Errorf("invalid Last-Event-ID: %s", lastEventId)
+	}
+	return seq + 1, nil
+}
+
+// writeEvent writes an event. The id and event name are omitted when negative or empty.
+func writeEvent(w io.Writer, seq int, event string, data interface{}) {
+	body, _ := json.Marshal(data)
+	if seq >= 0 {
+		fmt.Fprintf(w, "id: %d\n", seq)
+	}
+	if len(event) > 0 {
+		fmt.Fprintf(w, "event: %s\n", event)
+	}
+	fmt.Fprintf(w, "data: %s\n\n", body)
 }
@@ -29,10 +29,16 @@ func TestLogsController_ServeHTTP(t *testing.T) {
 			t.Fatalf("error occurred: %+v", err)
 		}
 
+		// and
+		mockService.EXPECT().
+			Subscribe(gomock.Eq(id)).
+			AnyTimes().
+			Return(make(chan struct{}), func() {})
+
 		t.Run("when service returns error", func(t *testing.T) {
 			// and
 			mockService.EXPECT().
-				Get(gomock.Eq(id)).
+				GetFrom(gomock.Eq(id), gomock.Eq(0)).
 				Return(nil, errors.New("hello error"))
 
 			// and
@@ -67,7 +73,7 @@ func TestLogsController_ServeHTTP(t *testing.T) {
 			}
 
 			mockService.EXPECT().
-				Get(gomock.Eq(id)).
+				GetFrom(gomock.Eq(id), gomock.Eq(0)).
 				Return(job, nil)
 
 			// and
@@ -92,6 +98,230 @@ func TestLogsController_ServeHTTP(t *testing.T) {
 		})
 	})
 
+	t.Run("when job changed", func(t *testing.T) {
+		// setup
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockService := mock_logstore.NewMockService(ctrl)
+		handler := &controller.LogController{LogStore: mockService}
+
+		// given
+		id, err := uuid.NewRandom()
+		if err != nil {
+			t.Fatalf("error occurred: %+v", err)
+		}
+
+		// and
+		changed := make(chan struct{}, 1)
+		changed <- struct{}{}
+		unsubscribed := false
+		mockService.EXPECT().
+			Subscribe(gomock.Eq(id)).
+			Times(1).
+			Return(changed, func() { unsubscribed = true })
+
+		// and
+		gomock.InOrder(
+			mockService.EXPECT().
+				GetFrom(gomock.Eq(id), gomock.Eq(0)).
+				Return(&model.Job{Stream: []model.Message{{Text: "Hello"}}}, nil),
+			mockService.EXPECT().
+				GetFrom(gomock.Eq(id), gomock.Eq(1)).
+				Return(&model.Job{Finished: true, Stream: []model.Message{{Text: "World"}}}, nil),
+		)
+
+		// and
+		chiCtx := chi.NewRouteContext()
+		chiCtx.URLParams.Add("uuid", id.String())
+
+		req := httptest.NewRequest("GET", "/", nil).
+			WithContext(ctx.WithValue(ctx.Background(), chi.RouteCtxKey, chiCtx))
+		rec := httptest.NewRecorder()
+
+		// when
+		handler.ServeHTTP(rec, req)
+
+		// then
+		expected := "{\"time\":\"0001-01-01T00:00:00Z\",\"message\":\"Hello\"}\n" +
+			"{\"time\":\"0001-01-01T00:00:00Z\",\"message\":\"World\"}\n"
+		if rec.Body.String() != expected {
+			t.Errorf("body must be %s, but got %s", expected, rec.Body.String())
+		}
+
+		if !unsubscribed {
+			t.Error("must unsubscribe")
+		}
+	})
+
+	t.Run("with event stream", func(t *testing.T) {
+		// setup
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockService := mock_logstore.NewMockService(ctrl)
+		handler := &controller.LogController{LogStore: mockService}
+
+		// given
+		id, err := uuid.NewRandom()
+		if err != nil {
+			t.Fatalf("error occurred: %+v", err)
+		}
+
+		// and
+		mockService.EXPECT().
+			Subscribe(gomock.Eq(id)).
+			AnyTimes().
+			Return(make(chan struct{}), func() {})
+
+		for _, tt := range []struct {
+			name        string
+			lastEventId string
+			offset      int
+			expected    string
+		}{
+			{
+				name:   "without Last-Event-ID",
+				offset: 0,
+				expected: "id: 0\ndata: {\"time\":\"0001-01-01T00:00:00Z\",\"message\":\"Hello World\"}\n\n" +
+					"event: finished\ndata: {\"state\":\"success\",\"exitCode\":0}\n\n",
+			},
+			{
+				name:        "with Last-Event-ID",
+				lastEventId: "4",
+				offset:      5,
+				expected: "id: 5\ndata: {\"time\":\"0001-01-01T00:00:00Z\",\"message\":\"Hello World\"}\n\n" +
+					"event: finished\ndata: {\"state\":\"success\",\"exitCode\":0}\n\n",
+			},
+		} {
+			t.Run(tt.name, func(t *testing.T) {
+				// given
+				mockService.EXPECT().
+					GetFrom(gomock.Eq(id), gomock.Eq(tt.offset)).
+					Return(&model.Job{
+						State:    model.SUCCESS,
+						Finished: true,
+						Stream:   []model.Message{{Text: "Hello World"}},
+					}, nil)
+
+				// and
+				chiCtx := chi.NewRouteContext()
+				chiCtx.URLParams.Add("uuid", id.String())
+
+				req := httptest.NewRequest("GET", "/", nil).
+					WithContext(ctx.WithValue(ctx.Background(), chi.RouteCtxKey, chiCtx))
+				req.Header.Set("Accept", "text/event-stream")
+				if len(tt.lastEventId) > 0 {
+					req.Header.Set("Last-Event-ID", tt.lastEventId)
+				}
+				rec := httptest.NewRecorder()
+
+				// when
+				handler.ServeHTTP(rec, req)
+
+				// then
+				if rec.Header().Get("Content-Type") != "text/event-stream" {
+					t.Errorf("content type must be text/event-stream, but got %s", rec.Header().Get("Content-Type"))
+				}
+
+				if rec.Body.String() != tt.expected {
+					t.Errorf("body must be %s, but got %s", tt.expected, rec.Body.String())
+				}
+			})
+		}
+
+		t.Run("with invalid Last-Event-ID", func(t *testing.T) {
+			// given
+			chiCtx := chi.NewRouteContext()
+			chiCtx.URLParams.Add("uuid", id.String())
+
+			req := httptest.NewRequest("GET", "/", nil).
+				WithContext(ctx.WithValue(ctx.Background(), chi.RouteCtxKey, chiCtx))
+			req.Header.Set("Accept", "text/event-stream")
+			req.Header.Set("Last-Event-ID", "invalid")
+			rec := httptest.NewRecorder()
+
+			// when
+			handler.ServeHTTP(rec, req)
+
+			// then
+			if rec.Code != 400 {
+				t.Errorf("status must equal %+v, but got %+v", 400, rec.Code)
+			}
+		})
+	})
+
+	t.Run("when client gone", func(t *testing.T) {
+		// setup
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockService := mock_logstore.NewMockService(ctrl)
+		handler := &controller.LogController{LogStore: mockService}
+
+		// given
+		id, err := uuid.NewRandom()
+		if err != nil {
+			t.Fatalf("error occurred: %+v", err)
+		}
+
+		// and
+		mockService.EXPECT().
+			Subscribe(gomock.Eq(id)).
+			Times(1).
+			Return(make(chan struct{}), func() {})
+		mockService.EXPECT().
+			GetFrom(gomock.Eq(id), gomock.Eq(0)).
+			Times(1).
+			Return(&model.Job{Finished: false}, nil)
+
+		// and
+		chiCtx := chi.NewRouteContext()
+		chiCtx.URLParams.Add("uuid", id.String())
+
+		reqCtx, cancel := ctx.WithCancel(ctx.WithValue(ctx.Background(), chi.RouteCtxKey, chiCtx))
+		cancel()
+
+		req := httptest.NewRequest("GET", "/", nil).WithContext(reqCtx)
+		rec := httptest.NewRecorder()
+
+		// expect
+		handler.ServeHTTP(rec, req)
+	})
+
+	t.Run("from browser", func(t *testing.T) {
+		// setup
+		handler := &controller.LogController{}
+
+		// given
+		id, err := uuid.NewRandom()
+		if err != nil {
+			t.Fatalf("error occurred: %+v", err)
+		}
+
+		// and
+		chiCtx := chi.NewRouteContext()
+		chiCtx.URLParams.Add("uuid", id.String())
+
+		req := httptest.NewRequest("GET", "/logs/"+id.String(), nil).
+			WithContext(ctx.WithValue(ctx.Background(), chi.RouteCtxKey, chiCtx))
+		req.Header.Set("Accept", "text/html,application/xhtml+xml")
+		rec := httptest.NewRecorder()
+
+		// when
+		handler.ServeHTTP(rec, req)
+
+		// then
+		if rec.Code != 302 {
+			t.Errorf("status must equal %+v, but got %+v", 302, rec.Code)
+		}
+
+		expected := "/ui/jobs/" + id.String()
+		if rec.Header().Get("Location") != expected {
+			t.Errorf("location must be %s, but got %s", expected, rec.Header().Get("Location"))
+		}
+	})
+
 	t.Run("with invalid uuid", func(t *testing.T) {
 		// setup
 		handler := &controller.LogController{}
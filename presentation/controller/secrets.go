@@ -0,0 +1,81 @@
+package controller
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/duck8823/duci/application/service/secret"
+	"github.com/duck8823/duci/infrastructure/store"
+	"github.com/go-chi/chi"
+	"github.com/pkg/errors"
+	"io/ioutil"
+	"net/http"
+)
+
+// maxSecretSize is the maximum size of value of a secret in bytes.
+const maxSecretSize = 64 * 1024
+
+type SecretController struct {
+	Secrets secret.Service
+}
+
+// List responds names of secrets of the repository, without values.
+func (c *SecretController) List(w http.ResponseWriter, r *http.Request) {
+	if !authorized(w, r) {
+		return
+	}
+
+	names, err := c.Secrets.Names(fullName(r))
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Sorry, Error occurred: %s", err.Error()), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(names)
+}
+
+// Put stores the request body as value of the secret.
+func (c *SecretController) Put(w http.ResponseWriter, r *http.Request) {
+	if !authorized(w, r) {
+		return
+	}
+
+	value, err := ioutil.ReadAll(http.MaxBytesReader(w, r.Body, maxSecretSize))
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Error occurred: %s", err.Error()), http.StatusBadRequest)
+		return
+	}
+
+	switch err := c.Secrets.Put(fullName(r), chi.URLParam(r, "name"), string(value)); err {
+	case nil:
+		w.WriteHeader(http.StatusNoContent)
+	case secret.InvalidName:
+		http.Error(w, fmt.Sprintf("Error occurred: %s", err.Error()), http.StatusBadRequest)
+	case secret.KeyNotConfigured:
+		http.Error(w, fmt.Sprintf("Error occurred: %s", err.Error()), http.StatusServiceUnavailable)
+	default:
+		http.Error(w, fmt.Sprintf("Sorry, Error occurred: %s", err.Error()), http.StatusInternalServerError)
+	}
+}
+
+// Delete removes the secret.
+func (c *SecretController) Delete(w http.ResponseWriter, r *http.Request) {
+	if !authorized(w, r) {
+		return
+	}
+
+	name := chi.URLParam(r, "name")
+	if err := c.Secrets.Delete(fullName(r), name); errors.Cause(err) == store.NotFoundError {
+		http.Error(w, fmt.Sprintf("Secret not found: %s", name), http.StatusNotFound)
+		return
+	} else if err != nil {
+		http.Error(w, fmt.Sprintf("Sorry, Error occurred: %s", err.Error()), http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func fullName(r *http.Request) string {
+	return fmt.Sprintf("%s/%s", chi.URLParam(r, "owner"), chi.URLParam(r, "repo"))
+}
@@ -0,0 +1,210 @@
+package controller_test
+
+import (
+	ctx "context"
+	"encoding/json"
+	"github.com/duck8823/duci/application"
+	"github.com/duck8823/duci/application/service/secret"
+	"github.com/duck8823/duci/application/service/secret/mock_secret"
+	"github.com/duck8823/duci/infrastructure/store"
+	"github.com/duck8823/duci/presentation/controller"
+	"github.com/go-chi/chi"
+	"github.com/golang/mock/gomock"
+	"github.com/pkg/errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestSecretController_List(t *testing.T) {
+	// setup
+	application.Config.Server.AdminToken = "admin_token"
+	defer func() {
+		application.Config.Server.AdminToken = ""
+	}()
+
+	t.Run("with valid token", func(t *testing.T) {
+		// setup
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		// given
+		mockSecret := mock_secret.NewMockService(ctrl)
+		mockSecret.EXPECT().
+			Names(gomock.Eq("duck8823/duci")).
+			Times(1).
+			Return([]string{"NPM_TOKEN"}, nil)
+
+		handler := &controller.SecretController{Secrets: mockSecret}
+
+		// and
+		req := secretRequest("GET", "Bearer admin_token", "")
+		rec := httptest.NewRecorder()
+
+		// when
+		handler.List(rec, req)
+
+		// then
+		if rec.Code != 200 {
+			t.Errorf("status must equal %+v, but got %+v", 200, rec.Code)
+		}
+
+		// and
+		var actual []string
+		if err := json.NewDecoder(rec.Body).Decode(&actual); err != nil {
+			t.Fatalf("error occurred: %+v", err)
+		}
+		if !reflect.DeepEqual(actual, []string{"NPM_TOKEN"}) {
+			t.Errorf("names must be %+v, but got %+v", []string{"NPM_TOKEN"}, actual)
+		}
+	})
+
+	t.Run("with invalid token", func(t *testing.T) {
+		for _, authorization := range []string{"", "Bearer wrong_token", "admin_token"} {
+			t.Run(authorization, func(t *testing.T) {
+				// given
+				handler := &controller.SecretController{}
+
+				req := secretRequest("GET", authorization, "")
+				rec := httptest.NewRecorder()
+
+				// when
+				handler.List(rec, req)
+
+				// then
+				if rec.Code != 401 {
+					t.Errorf("status must equal %+v, but got %+v", 401, rec.Code)
+				}
+			})
+		}
+	})
+
+	t.Run("without admin token of server", func(t *testing.T) {
+		// given
+		application.Config.Server.AdminToken = ""
+		defer func() {
+			application.Config.Server.AdminToken = "admin_token"
+		}()
+
+		// and
+		handler := &controller.SecretController{}
+
+		req := secretRequest("GET", "Bearer ", "")
+		rec := httptest.NewRecorder()
+
+		// when
+		handler.List(rec, req)
+
+		// then
+		if rec.Code != 403 {
+			t.Errorf("status must equal %+v, but got %+v", 403, rec.Code)
+		}
+	})
+}
+
+func TestSecretController_Put(t *testing.T) {
+	// setup
+	application.Config.Server.AdminToken = "admin_token"
+	defer func() {
+		application.Config.Server.AdminToken = ""
+	}()
+
+	for _, tt := range []struct {
+		name     string
+		err      error
+		expected int
+	}{
+		{name: "when stored", err: nil, expected: 204},
+		{name: "with invalid name", err: secret.InvalidName, expected: 400},
+		{name: "without secret key", err: secret.KeyNotConfigured, expected: 503},
+		{name: "when store returns error", err: errors.New("test error"), expected: 500},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			// setup
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			// given
+			mockSecret := mock_secret.NewMockService(ctrl)
+			mockSecret.EXPECT().
+				Put(gomock.Eq("duck8823/duci"), gomock.Eq("NPM_TOKEN"), gomock.Eq("s3cr3t")).
+				Times(1).
+				Return(tt.err)
+
+			handler := &controller.SecretController{Secrets: mockSecret}
+
+			// and
+			req := secretRequest("PUT", "Bearer admin_token", "s3cr3t")
+			rec := httptest.NewRecorder()
+
+			// when
+			handler.Put(rec, req)
+
+			// then
+			if rec.Code != tt.expected {
+				t.Errorf("status must equal %+v, but got %+v", tt.expected, rec.Code)
+			}
+		})
+	}
+}
+
+func TestSecretController_Delete(t *testing.T) {
+	// setup
+	application.Config.Server.AdminToken = "admin_token"
+	defer func() {
+		application.Config.Server.AdminToken = ""
+	}()
+
+	for _, tt := range []struct {
+		name     string
+		err      error
+		expected int
+	}{
+		{name: "when deleted", err: nil, expected: 204},
+		{name: "when not found", err: store.NotFoundError, expected: 404},
+		{name: "when store returns error", err: errors.New("test error"), expected: 500},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			// setup
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			// given
+			mockSecret := mock_secret.NewMockService(ctrl)
+			mockSecret.EXPECT().
+				Delete(gomock.Eq("duck8823/duci"), gomock.Eq("NPM_TOKEN")).
+				Times(1).
+				Return(tt.err)
+
+			handler := &controller.SecretController{Secrets: mockSecret}
+
+			// and
+			req := secretRequest("DELETE", "Bearer admin_token", "")
+			rec := httptest.NewRecorder()
+
+			// when
+			handler.Delete(rec, req)
+
+			// then
+			if rec.Code != tt.expected {
+				t.Errorf("status must equal %+v, but got %+v", tt.expected, rec.Code)
+			}
+		})
+	}
+}
+
+func secretRequest(method string, authorization string, body string) *http.Request {
+	chiCtx := chi.NewRouteContext()
+	chiCtx.URLParams.Add("owner", "duck8823")
+	chiCtx.URLParams.Add("repo", "duci")
+	chiCtx.URLParams.Add("name", "NPM_TOKEN")
+
+	req := httptest.NewRequest(method, "/", strings.NewReader(body)).
+		WithContext(ctx.WithValue(ctx.Background(), chi.RouteCtxKey, chiCtx))
+	if len(authorization) > 0 {
+		req.Header.Set("Authorization", authorization)
+	}
+	return req
+}
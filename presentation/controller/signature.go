@@ -0,0 +1,49 @@
+package controller
+
+import (
+	"crypto/hmac"
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"github.com/pkg/errors"
+	"hash"
+	"net/http"
+	"strings"
+)
+
+var InvalidSignature = errors.New("invalid signature")
+
+// verifySignature checks `X-Hub-Signature-256` ( or `X-Hub-Signature` ) header against the payload.
+// When secret is empty, verification is skipped.
+func verifySignature(r *http.Request, payload []byte, secret string) error {
+	if len(secret) == 0 {
+		return nil
+	}
+
+	var signature, prefix string
+	var hashFunc func() hash.Hash
+	if sig := r.Header.Get("X-Hub-Signature-256"); len(sig) > 0 {
+		signature, prefix, hashFunc = sig, "sha256", sha256.New
+	} else if sig := r.Header.Get("X-Hub-Signature"); len(sig) > 0 {
+		signature, prefix, hashFunc = sig, "sha1", sha1.New
+	} else {
+		return errors.Wrap(InvalidSignature, "missing signature header")
+	}
+
+	ss := strings.SplitN(signature, "=", 2)
+	if len(ss) != 2 || ss[0] != prefix {
+		return errors.Wrap(InvalidSignature, fmt.Sprintf("malformed signature: %s", signature))
+	}
+	actual, err := hex.DecodeString(ss[1])
+	if err != nil {
+		return errors.Wrap(InvalidSignature, err.Error())
+	}
+
+	mac := hmac.New(hashFunc, []byte(secret))
+	mac.Write(payload)
+	if !hmac.Equal(actual, mac.Sum(nil)) {
+		return errors.Wrap(InvalidSignature, "signature mismatch")
+	}
+	return nil
+}
@@ -6,12 +6,15 @@ import (
 	"github.com/duck8823/duci/application"
 	"github.com/duck8823/duci/application/context"
 	"github.com/duck8823/duci/application/service/github"
+	"github.com/duck8823/duci/application/service/reporter"
 	"github.com/duck8823/duci/application/service/runner"
+	"github.com/duck8823/duci/data/model"
 	"github.com/duck8823/duci/infrastructure/logger"
 	go_github "github.com/google/go-github/github"
 	"github.com/google/uuid"
 	"github.com/pkg/errors"
 	"gopkg.in/src-d/go-git.v4/plumbing"
+	"io/ioutil"
 	"net/http"
 	"net/url"
 	"regexp"
@@ -20,9 +23,22 @@ import (
 
 var SkipBuild = errors.New("build skip")
 
+var NotMergeable = errors.New("pull request is not mergeable")
+
+// maxPayloadSize is the maximum size of webhook payloads, same as GitHub caps.
+const maxPayloadSize = 25 * 1024 * 1024
+
+// Refs to checkout for pull requests.
+const (
+	CheckoutBranch = "branch"
+	CheckoutHead   = "head"
+	CheckoutMerge  = "merge"
+)
+
 type WebhooksController struct {
-	Runner runner.Runner
-	GitHub github.Service
+	Runner   runner.Runner
+	GitHub   github.Service
+	Reporter reporter.Reporter
 }
 
 func (c *WebhooksController) ServeHTTP(w http.ResponseWriter, r *http.Request) {
@@ -42,19 +58,78 @@ func (c *WebhooksController) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		runtimeUrl.Scheme = r.URL.Scheme
 	}
 
+	// Read Payload
+	payload, err := ioutil.ReadAll(http.MaxBytesReader(w, r.Body, maxPayloadSize))
+	if err != nil {
+		logger.Errorf(requestId, "%+v", err)
+		http.Error(w, err.Error(), http.StatusRequestEntityTooLarge)
+		return
+	}
+
+	// Verify Signature
+	repo := repository(payload)
+	secret := application.Config.WebhookSecret(repo.GetFullName())
+	if len(secret) == 0 {
+		logger.Info(requestId, "warning: signature is not verified, because webhook secret is not set")
+	}
+	if err := verifySignature(r, payload, secret); err != nil {
+		logger.Errorf(requestId, "%+v", err)
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+
+	// Register installation of GitHub App
+	if id := installationID(payload); id > 0 && len(repo.GetFullName()) > 0 {
+		c.GitHub.RegisterInstallation(repo, id)
+	}
+
 	// Trigger build
 	githubEvent := r.Header.Get("X-GitHub-Event")
 	switch githubEvent {
 	case "issue_comment":
-		// Read Payload
 		event := &go_github.IssueCommentEvent{}
-		if err := json.NewDecoder(r.Body).Decode(event); err != nil {
+		if err := json.Unmarshal(payload, event); err != nil {
+			logger.Errorf(requestId, "%+v", err)
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		ctx, repo, pr, command, err := c.parseIssueComment(event, requestId, runtimeUrl)
+		if err == SkipBuild {
+			logger.Info(requestId, "skip build")
+			w.WriteHeader(http.StatusOK)
+			w.Write([]byte(err.Error()))
+			return
+		} else if err != nil {
+			logger.Errorf(requestId, "%+v", err)
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		ref := pullRequestRef(pr)
+		if command[0] == "cancel" {
+			ids := c.Runner.CancelRef(repo, ref)
+			logger.Infof(requestId, "cancel jobs: %+v", ids)
+			w.WriteHeader(http.StatusOK)
+			w.Write([]byte(fmt.Sprintf("cancel %d job(s)", len(ids))))
+			return
+		}
+		if err := c.checkMergeable(ctx, repo, pr); err != nil {
+			logger.Info(requestId, err.Error())
+			w.WriteHeader(http.StatusOK)
+			w.Write([]byte(err.Error()))
+			return
+		}
+		go c.Runner.Run(ctx, repo, ref, plumbing.NewHash(pr.GetHead().GetSHA()), command...)
+	case "pull_request":
+		event := &go_github.PullRequestEvent{}
+		if err := json.Unmarshal(payload, event); err != nil {
 			logger.Errorf(requestId, "%+v", err)
 			http.Error(w, err.Error(), http.StatusInternalServerError)
 			return
 		}
 
-		ctx, repo, head, command, err := c.parseIssueComment(event, requestId, runtimeUrl)
+		ctx, repo, pr, err := c.parsePullRequest(event, payload, requestId, runtimeUrl)
 		if err == SkipBuild {
 			logger.Info(requestId, "skip build")
 			w.WriteHeader(http.StatusOK)
@@ -66,11 +141,16 @@ func (c *WebhooksController) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 			return
 		}
 
-		ref := fmt.Sprintf("refs/heads/%s", head.GetRef())
-		go c.Runner.Run(ctx, repo, ref, plumbing.NewHash(head.GetSHA()), command...)
+		if err := c.checkMergeable(ctx, repo, pr); err != nil {
+			logger.Info(requestId, err.Error())
+			w.WriteHeader(http.StatusOK)
+			w.Write([]byte(err.Error()))
+			return
+		}
+		go c.Runner.Run(ctx, repo, pullRequestRef(pr), plumbing.NewHash(pr.GetHead().GetSHA()))
 	case "push":
 		event := &go_github.PushEvent{}
-		if err := json.NewDecoder(r.Body).Decode(event); err != nil {
+		if err := json.Unmarshal(payload, event); err != nil {
 			logger.Errorf(requestId, "%+v", err)
 			http.Error(w, err.Error(), http.StatusInternalServerError)
 			return
@@ -85,10 +165,10 @@ func (c *WebhooksController) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		}
 
 		taskName := fmt.Sprintf("%s/push", application.Name)
-		ctx := context.New(taskName, requestId, runtimeUrl)
+		ctx := context.WithTrigger(context.New(taskName, requestId, runtimeUrl), string(model.PUSH))
 		go c.Runner.Run(ctx, event.GetRepo(), event.GetRef(), plumbing.NewHash(sha))
 	default:
-		message := fmt.Sprintf("payload event type must be issue_comment or push. but %s", githubEvent)
+		message := fmt.Sprintf("payload event type must be issue_comment, pull_request or push. but %s", githubEvent)
 		logger.Error(requestId, message)
 		http.Error(w, message, http.StatusInternalServerError)
 		return
@@ -102,7 +182,7 @@ func (c *WebhooksController) parseIssueComment(
 	event *go_github.IssueCommentEvent,
 	requestId uuid.UUID,
 	url *url.URL,
-) (ctx context.Context, repo *go_github.Repository, head *go_github.PullRequestBranch, command []string, err error) {
+) (ctx context.Context, repo *go_github.Repository, pr *go_github.PullRequest, command []string, err error) {
 
 	if !isValidAction(event.Action) {
 		return nil, nil, nil, nil, SkipBuild
@@ -113,16 +193,121 @@ func (c *WebhooksController) parseIssueComment(
 	}
 	phrase := regexp.MustCompile("^ci\\s+").ReplaceAllString(event.Comment.GetBody(), "")
 	command = strings.Split(phrase, " ")
-	ctx = context.New(fmt.Sprintf("%s/pr/%s", application.Name, command[0]), requestId, url)
+	ctx = context.WithTrigger(context.New(fmt.Sprintf("%s/pr/%s", application.Name, command[0]), requestId, url), string(model.COMMENT))
 
-	pr, err := c.GitHub.GetPullRequest(ctx, event.GetRepo(), event.GetIssue().GetNumber())
+	pr, err = c.GitHub.GetPullRequest(ctx, event.GetRepo(), event.GetIssue().GetNumber())
 	if err != nil {
 		return nil, nil, nil, nil, errors.WithStack(err)
 	}
 
 	repo = event.GetRepo()
-	head = pr.GetHead()
-	return ctx, repo, head, command, err
+	return ctx, repo, pr, command, err
+}
+
+func (c *WebhooksController) parsePullRequest(
+	event *go_github.PullRequestEvent,
+	payload []byte,
+	requestId uuid.UUID,
+	url *url.URL,
+) (ctx context.Context, repo *go_github.Repository, pr *go_github.PullRequest, err error) {
+
+	conf := application.Config.GitHub.PullRequest
+	if !contains(conf.Actions, event.GetAction()) {
+		return nil, nil, nil, SkipBuild
+	}
+
+	if conf.SkipDraft && isDraft(payload) {
+		return nil, nil, nil, SkipBuild
+	}
+
+	if event.GetPullRequest().GetHead().GetSHA() == "" {
+		return nil, nil, nil, errors.New("could not get head commit of the pull request")
+	}
+
+	ctx = context.WithTrigger(context.New(fmt.Sprintf("%s/pr", application.Name), requestId, url), string(model.PULL_REQUEST))
+	repo = event.GetRepo()
+	pr = event.GetPullRequest()
+	return ctx, repo, pr, nil
+}
+
+// pullRequestRef returns the ref to build for the pull request, as configured.
+// refs/pull/<n>/* is in the base repository, even if the pull request is from a fork.
+// Branches of forks are not in the base repository, then the head is built instead.
+func pullRequestRef(pr *go_github.PullRequest) string {
+	switch application.Config.GitHub.PullRequest.Checkout {
+	case CheckoutHead:
+		return fmt.Sprintf("refs/pull/%d/head", pr.GetNumber())
+	case CheckoutMerge:
+		return fmt.Sprintf("refs/pull/%d/merge", pr.GetNumber())
+	default:
+		if isFork(pr) {
+			return fmt.Sprintf("refs/pull/%d/head", pr.GetNumber())
+		}
+		return fmt.Sprintf("refs/heads/%s", pr.GetHead().GetRef())
+	}
+}
+
+// isFork returns whether the head of the pull request is in other repository than the base.
+func isFork(pr *go_github.PullRequest) bool {
+	return pr.GetHead().GetRepo().GetFullName() != pr.GetBase().GetRepo().GetFullName()
+}
+
+// checkMergeable reports error status to the head commit, if the merge commit is configured to build but it does not exist.
+// Mergeability is unknown ( nil ) while GitHub is computing it, then the build is tried.
+func (c *WebhooksController) checkMergeable(ctx context.Context, repo *go_github.Repository, pr *go_github.PullRequest) error {
+	if application.Config.GitHub.PullRequest.Checkout != CheckoutMerge || pr.Mergeable == nil || pr.GetMergeable() {
+		return nil
+	}
+
+	description := "pull request is not mergeable, resolve conflicts to build the merge commit"
+	if err := c.Reporter.Report(ctx, repo, plumbing.NewHash(pr.GetHead().GetSHA()), model.ERROR, description); err != nil {
+		logger.Errorf(ctx.UUID(), "Failed to report status: %+v", err)
+	}
+	return NotMergeable
+}
+
+// isDraft returns whether `pull_request.draft` in the payload is true.
+// go-github does not have this field yet.
+func isDraft(payload []byte) bool {
+	event := &struct {
+		PullRequest struct {
+			Draft bool `json:"draft"`
+		} `json:"pull_request"`
+	}{}
+	if err := json.Unmarshal(payload, event); err != nil {
+		return false
+	}
+	return event.PullRequest.Draft
+}
+
+func contains(list []string, target string) bool {
+	for _, item := range list {
+		if item == target {
+			return true
+		}
+	}
+	return false
+}
+
+// repository returns `repository` in the payload, or empty one if it could not read.
+func repository(payload []byte) *go_github.Repository {
+	event := &struct {
+		Repo *go_github.Repository `json:"repository"`
+	}{}
+	if err := json.Unmarshal(payload, event); err != nil || event.Repo == nil {
+		return &go_github.Repository{}
+	}
+	return event.Repo
+}
+
+func installationID(payload []byte) int64 {
+	event := &struct {
+		Installation *go_github.Installation `json:"installation"`
+	}{}
+	if err := json.Unmarshal(payload, event); err != nil {
+		return 0
+	}
+	return event.Installation.GetID()
 }
 
 func isValidAction(action *string) bool {
@@ -2,15 +2,25 @@ package controller_test
 
 import (
 	"bytes"
+	"crypto/hmac"
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
+	"fmt"
+	"github.com/duck8823/duci/application"
 	"github.com/duck8823/duci/application/service/github/mock_github"
+	"github.com/duck8823/duci/application/service/reporter/mock_reporter"
 	"github.com/duck8823/duci/application/service/runner/mock_runner"
+	"github.com/duck8823/duci/data/model"
 	"github.com/duck8823/duci/presentation/controller"
 	"github.com/golang/mock/gomock"
 	"github.com/google/go-github/github"
 	"github.com/google/uuid"
 	"github.com/pkg/errors"
+	"hash"
 	"io"
+	"io/ioutil"
 	"net/http/httptest"
 	"strings"
 	"testing"
@@ -73,6 +83,37 @@ func TestWebhooksController_ServeHTTP(t *testing.T) {
 					}
 				})
 
+				t.Run("with cancel command", func(t *testing.T) {
+					// given
+					cancelRunner := mock_runner.NewMockRunner(ctrl)
+					cancelRunner.EXPECT().CancelRef(gomock.Any(), gomock.Any()).
+						Times(1).
+						Return([]uuid.UUID{uuid.New()})
+					cancelRunner.EXPECT().Run(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+
+					handler := &controller.WebhooksController{Runner: cancelRunner, GitHub: githubService}
+
+					// and
+					payload := createIssueCommentPayload(t, "created", "ci cancel")
+
+					req := httptest.NewRequest("POST", "/", payload)
+					req.Header.Set("X-GitHub-Delivery", requestId.String())
+					req.Header.Set("X-GitHub-Event", event)
+					rec := httptest.NewRecorder()
+
+					// when
+					handler.ServeHTTP(rec, req)
+
+					// then
+					if rec.Code != 200 {
+						t.Errorf("status must equal %+v, but got %+v", 200, rec.Code)
+					}
+
+					if rec.Body.String() != "cancel 1 job(s)" {
+						t.Errorf("body must equal %+v, but got %+v", "cancel 1 job(s)", rec.Body.String())
+					}
+				})
+
 				t.Run("with invalid action", func(t *testing.T) {
 					actions := []string{"deleted", "foo", ""}
 					for _, action := range actions {
@@ -139,6 +180,99 @@ func TestWebhooksController_ServeHTTP(t *testing.T) {
 			})
 		})
 
+		t.Run("when pull_request", func(t *testing.T) {
+			// given
+			event := "pull_request"
+
+			// and
+			githubService := mock_github.NewMockService(ctrl)
+			githubService.EXPECT().CreateCommitStatus(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+				AnyTimes().
+				Return(nil)
+
+			t.Run("with valid action", func(t *testing.T) {
+				for _, action := range []string{"opened", "synchronize", "reopened"} {
+					t.Run(action, func(t *testing.T) {
+						// given
+						runner := mock_runner.NewMockRunner(ctrl)
+						runner.EXPECT().Run(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).AnyTimes()
+
+						handler := &controller.WebhooksController{Runner: runner, GitHub: githubService}
+
+						// and
+						req := httptest.NewRequest("POST", "/", createPullRequestPayload(t, action, false))
+						req.Header.Set("X-GitHub-Delivery", requestId.String())
+						req.Header.Set("X-GitHub-Event", event)
+						rec := httptest.NewRecorder()
+
+						// when
+						handler.ServeHTTP(rec, req)
+
+						// then
+						if rec.Code != 200 {
+							t.Errorf("status must equal %+v, but got %+v", 200, rec.Code)
+						}
+
+						if rec.Body.String() == "build skip" {
+							t.Error("build must not skip")
+						}
+					})
+				}
+			})
+
+			t.Run("with skip action", func(t *testing.T) {
+				for _, action := range []string{"closed", "labeled", ""} {
+					t.Run(action, func(t *testing.T) {
+						// given
+						runner := mock_runner.NewMockRunner(ctrl)
+						runner.EXPECT().Run(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+
+						handler := &controller.WebhooksController{Runner: runner, GitHub: githubService}
+
+						// and
+						req := httptest.NewRequest("POST", "/", createPullRequestPayload(t, action, false))
+						req.Header.Set("X-GitHub-Delivery", requestId.String())
+						req.Header.Set("X-GitHub-Event", event)
+						rec := httptest.NewRecorder()
+
+						// when
+						handler.ServeHTTP(rec, req)
+
+						// then
+						if rec.Code != 200 {
+							t.Errorf("status must equal %+v, but got %+v", 200, rec.Code)
+						}
+
+						if rec.Body.String() != "build skip" {
+							t.Errorf("body must equal %+v, but got %+v", "build skip", rec.Body.String())
+						}
+					})
+				}
+			})
+
+			t.Run("with draft", func(t *testing.T) {
+				// given
+				runner := mock_runner.NewMockRunner(ctrl)
+				runner.EXPECT().Run(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+
+				handler := &controller.WebhooksController{Runner: runner, GitHub: githubService}
+
+				// and
+				req := httptest.NewRequest("POST", "/", createPullRequestPayload(t, "opened", true))
+				req.Header.Set("X-GitHub-Delivery", requestId.String())
+				req.Header.Set("X-GitHub-Event", event)
+				rec := httptest.NewRecorder()
+
+				// when
+				handler.ServeHTTP(rec, req)
+
+				// then
+				if rec.Body.String() != "build skip" {
+					t.Errorf("body must equal %+v, but got %+v", "build skip", rec.Body.String())
+				}
+			})
+		})
+
 		t.Run("when push", func(t *testing.T) {
 			// setup
 			githubService := mock_github.NewMockService(ctrl)
@@ -208,6 +342,41 @@ func TestWebhooksController_ServeHTTP(t *testing.T) {
 					t.Errorf("status must equal %+v, but got %+v", 200, rec.Code)
 				}
 			})
+
+			t.Run("with installation", func(t *testing.T) {
+				// given
+				runner := mock_runner.NewMockRunner(ctrl)
+				runner.EXPECT().Run(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Times(1)
+
+				// and
+				githubService := mock_github.NewMockService(ctrl)
+				githubService.EXPECT().RegisterInstallation(gomock.Any(), gomock.Eq(int64(42))).
+					Times(1).
+					Do(func(repo interface{ GetFullName() string }, _ int64) {
+						if repo.GetFullName() != "test/repo" {
+							t.Errorf("repository must be %s, but got %s", "test/repo", repo.GetFullName())
+						}
+					})
+
+				// and
+				handler := &controller.WebhooksController{Runner: runner, GitHub: githubService}
+
+				// and
+				payload := strings.NewReader(`{"ref":"master","head_commit":{"id":"sha"},"repository":{"full_name":"test/repo"},"installation":{"id":42}}`)
+
+				req := httptest.NewRequest("POST", "/", payload)
+				req.Header.Set("X-GitHub-Delivery", requestId.String())
+				req.Header.Set("X-GitHub-Event", "push")
+				rec := httptest.NewRecorder()
+
+				// when
+				handler.ServeHTTP(rec, req)
+
+				// then
+				if rec.Code != 200 {
+					t.Errorf("status must equal %+v, but got %+v", 200, rec.Code)
+				}
+			})
 		})
 	})
 
@@ -350,6 +519,206 @@ func TestWebhooksController_ServeHTTP(t *testing.T) {
 	})
 }
 
+func TestWebhooksController_ServeHTTP_Checkout(t *testing.T) {
+	// setup
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	reset := application.Config.GitHub.PullRequest.Checkout
+	defer func() {
+		application.Config.GitHub.PullRequest.Checkout = reset
+	}()
+
+	// and
+	requestId, _ := uuid.NewRandom()
+	mergeable, conflicting := true, false
+
+	for _, tt := range []struct {
+		checkout  string
+		mergeable *bool
+		headRepo  string
+		expected  string
+	}{
+		{checkout: controller.CheckoutBranch, expected: "refs/heads/feature"},
+		{checkout: controller.CheckoutBranch, headRepo: "forker/duci", expected: "refs/pull/8/head"},
+		{checkout: controller.CheckoutHead, expected: "refs/pull/8/head"},
+		{checkout: controller.CheckoutMerge, expected: "refs/pull/8/merge"},
+		{checkout: controller.CheckoutMerge, mergeable: &mergeable, expected: "refs/pull/8/merge"},
+		{checkout: controller.CheckoutMerge, mergeable: &conflicting},
+	} {
+		t.Run(fmt.Sprintf("with %s", tt.checkout), func(t *testing.T) {
+			// given
+			application.Config.GitHub.PullRequest.Checkout = tt.checkout
+
+			// and
+			refs := make(chan string, 1)
+			runner := mock_runner.NewMockRunner(ctrl)
+			runner.EXPECT().Run(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+				AnyTimes().
+				Do(func(_, _ interface{}, ref string, _ interface{}, _ ...string) {
+					refs <- ref
+				})
+
+			githubService := mock_github.NewMockService(ctrl)
+			githubReporter := mock_reporter.NewMockReporter(ctrl)
+			if tt.mergeable != nil && !*tt.mergeable {
+				githubReporter.EXPECT().Report(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Eq(model.ERROR), gomock.Any()).
+					Times(1).
+					Return(nil)
+			}
+
+			handler := &controller.WebhooksController{Runner: runner, GitHub: githubService, Reporter: githubReporter}
+
+			// and
+			req := httptest.NewRequest("POST", "/", createMergeablePullRequestPayload(t, 8, tt.mergeable, tt.headRepo))
+			req.Header.Set("X-GitHub-Delivery", requestId.String())
+			req.Header.Set("X-GitHub-Event", "pull_request")
+			rec := httptest.NewRecorder()
+
+			// when
+			handler.ServeHTTP(rec, req)
+
+			// then
+			if rec.Code != 200 {
+				t.Errorf("status must equal %+v, but got %+v", 200, rec.Code)
+			}
+
+			if len(tt.expected) == 0 {
+				if rec.Body.String() != controller.NotMergeable.Error() {
+					t.Errorf("body must equal %+v, but got %+v", controller.NotMergeable.Error(), rec.Body.String())
+				}
+				return
+			}
+			if actual := <-refs; actual != tt.expected {
+				t.Errorf("ref must equal %+v, but got %+v", tt.expected, actual)
+			}
+		})
+	}
+}
+
+func TestWebhooksController_ServeHTTP_Signature(t *testing.T) {
+	// setup
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	application.Config.GitHub.WebhookSecret = "secret"
+	defer func() {
+		application.Config.GitHub.WebhookSecret = ""
+	}()
+
+	// and
+	githubService := mock_github.NewMockService(ctrl)
+	githubService.EXPECT().CreateCommitStatus(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+		AnyTimes().
+		Return(nil)
+
+	// and
+	requestId, _ := uuid.NewRandom()
+
+	t.Run("with valid signature", func(t *testing.T) {
+		for _, header := range []struct {
+			name   string
+			prefix string
+			hash   func() hash.Hash
+		}{
+			{name: "X-Hub-Signature-256", prefix: "sha256", hash: sha256.New},
+			{name: "X-Hub-Signature", prefix: "sha1", hash: sha1.New},
+		} {
+			t.Run(header.name, func(t *testing.T) {
+				// given
+				runner := mock_runner.NewMockRunner(ctrl)
+				runner.EXPECT().Run(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).AnyTimes()
+
+				handler := &controller.WebhooksController{Runner: runner, GitHub: githubService}
+
+				// and
+				payload, _ := ioutil.ReadAll(createPushPayload(t, "test/repo", "master", "sha"))
+				mac := hmac.New(header.hash, []byte("secret"))
+				mac.Write(payload)
+
+				req := httptest.NewRequest("POST", "/", bytes.NewReader(payload))
+				req.Header.Set("X-GitHub-Delivery", requestId.String())
+				req.Header.Set("X-GitHub-Event", "push")
+				req.Header.Set(header.name, fmt.Sprintf("%s=%s", header.prefix, hex.EncodeToString(mac.Sum(nil))))
+				rec := httptest.NewRecorder()
+
+				// when
+				handler.ServeHTTP(rec, req)
+
+				// then
+				if rec.Code != 200 {
+					t.Errorf("status must equal %+v, but got %+v", 200, rec.Code)
+				}
+			})
+		}
+	})
+
+	t.Run("with invalid signature", func(t *testing.T) {
+		for _, signature := range []string{"", "sha256=invalid", "sha256=0123456789abcdef", "md5=0123456789abcdef"} {
+			t.Run(signature, func(t *testing.T) {
+				// given
+				runner := mock_runner.NewMockRunner(ctrl)
+				runner.EXPECT().Run(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+
+				handler := &controller.WebhooksController{Runner: runner, GitHub: githubService}
+
+				// and
+				req := httptest.NewRequest("POST", "/", createPushPayload(t, "test/repo", "master", "sha"))
+				req.Header.Set("X-GitHub-Delivery", requestId.String())
+				req.Header.Set("X-GitHub-Event", "push")
+				req.Header.Set("X-Hub-Signature-256", signature)
+				rec := httptest.NewRecorder()
+
+				// when
+				handler.ServeHTTP(rec, req)
+
+				// then
+				if rec.Code != 401 {
+					t.Errorf("status must equal %+v, but got %+v", 401, rec.Code)
+				}
+			})
+		}
+	})
+}
+
+func TestWebhooksController_ServeHTTP_PayloadSize(t *testing.T) {
+	// setup
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	// given
+	runner := mock_runner.NewMockRunner(ctrl)
+	runner.EXPECT().Run(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+
+	handler := &controller.WebhooksController{Runner: runner}
+
+	// and
+	requestId, _ := uuid.NewRandom()
+
+	req := httptest.NewRequest("POST", "/", io.LimitReader(zeros{}, 25*1024*1024+1))
+	req.Header.Set("X-GitHub-Delivery", requestId.String())
+	req.Header.Set("X-GitHub-Event", "push")
+	rec := httptest.NewRecorder()
+
+	// when
+	handler.ServeHTTP(rec, req)
+
+	// then
+	if rec.Code != 413 {
+		t.Errorf("status must equal %+v, but got %+v", 413, rec.Code)
+	}
+}
+
+// zeros is an endless reader of zero bytes.
+type zeros struct{}
+
+func (zeros) Read(p []byte) (int, error) {
+	for i := range p {
+		p[i] = 0
+	}
+	return len(p), nil
+}
+
 func createIssueCommentPayload(t *testing.T, action, comment string) io.Reader {
 	t.Helper()
 
@@ -370,6 +739,62 @@ func createIssueCommentPayload(t *testing.T, action, comment string) io.Reader {
 	return bytes.NewReader(payload)
 }
 
+func createPullRequestPayload(t *testing.T, action string, draft bool) io.Reader {
+	t.Helper()
+
+	event := &struct {
+		*github.PullRequestEvent
+		PullRequest map[string]interface{} `json:"pull_request"`
+	}{
+		PullRequestEvent: &github.PullRequestEvent{
+			Action: &action,
+			Repo:   &github.Repository{},
+		},
+		PullRequest: map[string]interface{}{
+			"draft": draft,
+			"head": map[string]interface{}{
+				"ref": "feature",
+				"sha": "sha",
+			},
+		},
+	}
+	payload, err := json.Marshal(event)
+	if err != nil {
+		t.Fatalf("error occurred: %+v", err)
+	}
+	return bytes.NewReader(payload)
+}
+
+func createMergeablePullRequestPayload(t *testing.T, number int, mergeable *bool, headRepo string) io.Reader {
+	t.Helper()
+
+	if len(headRepo) == 0 {
+		headRepo = "duck8823/duci"
+	}
+	event := &github.PullRequestEvent{
+		Action: github.String("opened"),
+		Repo:   &github.Repository{FullName: github.String("duck8823/duci")},
+		PullRequest: &github.PullRequest{
+			Number:    &number,
+			Mergeable: mergeable,
+			Head: &github.PullRequestBranch{
+				Ref:  github.String("feature"),
+				SHA:  github.String("sha"),
+				Repo: &github.Repository{FullName: &headRepo},
+			},
+			Base: &github.PullRequestBranch{
+				Ref:  github.String("master"),
+				Repo: &github.Repository{FullName: github.String("duck8823/duci")},
+			},
+		},
+	}
+	payload, err := json.Marshal(event)
+	if err != nil {
+		t.Fatalf("error occurred: %+v", err)
+	}
+	return bytes.NewReader(payload)
+}
+
 func createPushPayload(t *testing.T, repoName, ref string, sha string) io.Reader {
 	t.Helper()
 
@@ -5,8 +5,12 @@ import (
 	"github.com/duck8823/duci/application/service/git"
 	"github.com/duck8823/duci/application/service/github"
 	"github.com/duck8823/duci/application/service/logstore"
+	"github.com/duck8823/duci/application/service/queue"
+	"github.com/duck8823/duci/application/service/reporter"
 	"github.com/duck8823/duci/application/service/runner"
+	"github.com/duck8823/duci/application/service/secret"
 	"github.com/duck8823/duci/infrastructure/docker"
+	"github.com/duck8823/duci/infrastructure/store"
 	"github.com/duck8823/duci/presentation/controller"
 	"github.com/go-chi/chi"
 	"github.com/pkg/errors"
@@ -14,41 +18,67 @@ import (
 )
 
 func New() (http.Handler, error) {
-	logstoreService, githubService, err := createCommonServices()
+	logstoreService, queueService, secretService, githubService, err := createCommonServices()
 	if err != nil {
 		return nil, errors.WithStack(err)
 	}
 
-	dockerRunner, err := createRunner(logstoreService, githubService)
+	githubReporter, err := reporter.New(githubService)
 	if err != nil {
 		return nil, errors.WithStack(err)
 	}
 
-	webhooksCtrl := &controller.WebhooksController{Runner: dockerRunner, GitHub: githubService}
+	dockerRunner, err := createRunner(logstoreService, queueService, secretService, githubService, githubReporter)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	if err := dockerRunner.Resume(); err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	webhooksCtrl := &controller.WebhooksController{Runner: dockerRunner, GitHub: githubService, Reporter: githubReporter}
 	logCtrl := &controller.LogController{LogStore: logstoreService}
+	jobCtrl := &controller.JobController{Runner: dockerRunner, LogStore: logstoreService}
+	dashboardCtrl := &controller.DashboardController{}
+	secretCtrl := &controller.SecretController{Secrets: secretService}
 
 	rtr := chi.NewRouter()
 	rtr.Post("/", webhooksCtrl.ServeHTTP)
 	rtr.Get("/logs/{uuid}", logCtrl.ServeHTTP)
+	rtr.Get("/jobs", jobCtrl.List)
+	rtr.Get("/jobs/{uuid}", jobCtrl.Show)
+	rtr.Delete("/jobs/{uuid}", jobCtrl.Cancel)
+	rtr.Get("/secrets/{owner}/{repo}", secretCtrl.List)
+	rtr.Put("/secrets/{owner}/{repo}/{name}", secretCtrl.Put)
+	rtr.Delete("/secrets/{owner}/{repo}/{name}", secretCtrl.Delete)
+	rtr.Get("/ui", dashboardCtrl.Index)
+	rtr.Get("/ui/jobs/{uuid}", dashboardCtrl.Job)
 
 	return rtr, nil
 }
 
-func createCommonServices() (logstore.Service, github.Service, error) {
-	logstoreService, err := logstore.New()
+func createCommonServices() (logstore.Service, queue.Service, secret.Service, github.Service, error) {
+	database, err := store.New(application.Config.Server.DatabasePath)
+	if err != nil {
+		return nil, nil, nil, nil, errors.WithStack(err)
+	}
+	logstoreService := logstore.New(database)
+	queueService := queue.New(database)
+	secretService, err := secret.New(database, string(application.Config.Server.SecretKey))
 	if err != nil {
-		return nil, nil, errors.WithStack(err)
+		return nil, nil, nil, nil, errors.WithStack(err)
 	}
+
 	githubService, err := github.New()
 	if err != nil {
-		return nil, nil, errors.WithStack(err)
+		return nil, nil, nil, nil, errors.WithStack(err)
 	}
 
-	return logstoreService, githubService, nil
+	return logstoreService, queueService, secretService, githubService, nil
 }
 
-func createRunner(logstoreService logstore.Service, githubService github.Service) (runner.Runner, error) {
-	gitClient, err := git.New(application.Config.GitHub.SSHKeyPath)
+func createRunner(logstoreService logstore.Service, queueService queue.Service, secretService secret.Service, githubService github.Service, githubReporter reporter.Reporter) (*runner.DockerRunner, error) {
+	gitClient, err := git.New(application.Config.GitHub.SSHKeyPath, githubService)
 	if err != nil {
 		return nil, errors.WithStack(err)
 	}
@@ -56,14 +86,15 @@ func createRunner(logstoreService logstore.Service, githubService github.Service
 	if err != nil {
 		return nil, errors.WithStack(err)
 	}
-
 	dockerRunner := &runner.DockerRunner{
 		Name:        application.Name,
 		BaseWorkDir: application.Config.Server.WorkDir,
 		Git:         gitClient,
-		GitHub:      githubService,
+		Reporter:    githubReporter,
 		Docker:      dockerClient,
 		LogStore:    logstoreService,
+		Queue:       queueService,
+		Secrets:     secretService,
 	}
 
 	return dockerRunner, nil